@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,29 +12,67 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"github.com/maxviazov/dolina-flower-order-backend/internal/config"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/handlers"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/health"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/metrics"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/ratelimit"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/repository/postgres"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
 )
 
 type App struct {
-	config *config.Config
-	logger *logger.Logger
-	server *http.Server
-	router *gin.Engine
-	repo   *postgres.Repository
+	config    *config.Config
+	logger    *logger.Logger
+	server    *http.Server
+	router    *gin.Engine
+	repo      *postgres.Repository
+	metrics   *metrics.Metrics
+	tracing   *tracing.Provider
+	health    *health.Checker
+	lifecycle *lifecycle
+	workers   *workerGroup
+	serveErr  chan error
 }
 
 func New() *App {
+	log := logger.GetLogger()
 	return &App{
-		logger: logger.GetLogger(),
+		logger:    log,
+		lifecycle: newLifecycle(log),
+		workers:   newWorkerGroup(log),
+		serveErr:  make(chan error, 1),
 	}
 }
 
+// Initialize загружает конфигурацию и создает компоненты приложения.
+// Если инициализация прервалась, уже открытые ресурсы освобождаются.
 func (a *App) Initialize() error {
+	if err := a.initialize(); err != nil {
+		if a.config != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
+			defer cancel()
+			_ = a.lifecycle.Stop(ctx)
+		}
+		return err
+	}
+	return nil
+}
+
+// initialize регистрирует компоненты в порядке запуска.
+// Останавливаются они в обратном порядке: HTTP-сервер, фоновые задачи,
+// БД, трассировка и в последнюю очередь логгер.
+func (a *App) initialize() error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -42,20 +82,54 @@ func (a *App) Initialize() error {
 	if err := a.logger.Initialize(cfg); err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
+	a.lifecycle.Append(component{
+		name: "logger",
+		stop: func(context.Context) error {
+			a.logger.Info("Shutdown completed")
+			return a.logger.Close()
+		},
+	})
 
 	a.logger.Info("Application initializing...")
 
+	tracingProvider, err := tracing.Setup(context.Background(), a.config.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	a.tracing = tracingProvider
+	a.lifecycle.Append(component{name: "tracing", stop: a.tracing.Shutdown})
+
 	repo, err := postgres.NewRepository(a.config.Database)
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	a.repo = repo
+	a.lifecycle.Append(component{
+		name: "database",
+		stop: func(context.Context) error {
+			return a.repo.Close()
+		},
+	})
+
+	a.health = health.NewChecker(a.config.Server.ReadinessTimeout)
+	a.health.Add("database", repo.Ping)
+	a.health.Add("migrations", repo.CheckMigrations)
+
+	a.metrics = metrics.New()
+	a.metrics.RegisterDB(repo.DB(), a.config.Database.Name)
+	a.metrics.RegisterOrderStatuses(repo)
+
+	a.workers.Add("idempotency-purge", a.purgeIdempotencyKeys(repo, a.config.Idempotency.PurgeInterval))
+	a.lifecycle.Append(component{name: "workers", start: a.workers.Start, stop: a.workers.Stop})
 
 	if a.config.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	a.router = gin.New()
+	if err := a.configureClientIP(); err != nil {
+		return err
+	}
 	a.setupMiddleware()
 	a.setupRoutes()
 
@@ -63,12 +137,15 @@ func (a *App) Initialize() error {
 		Addr:    a.config.GetServerAddress(),
 		Handler: a.router,
 	}
+	a.lifecycle.Append(component{name: "http", start: a.startServer, stop: a.stopServer})
 
 	a.logger.Info("Application initialized successfully")
 	return nil
 }
 
 func (a *App) setupMiddleware() {
+	a.router.Use(otelgin.Middleware(a.config.Tracing.ServiceName))
+	a.router.Use(a.requestIDMiddleware())
 	a.router.Use(a.loggingMiddleware())
 	a.router.Use(gin.Recovery())
 	a.router.Use(a.corsMiddleware())
@@ -76,20 +153,196 @@ func (a *App) setupMiddleware() {
 
 func (a *App) setupRoutes() {
 	a.router.GET("/health", a.healthCheck)
-
-	orderService := services.NewOrderService(a.repo)
-	orderHandler := handlers.NewOrderHandler(orderService)
+	a.router.GET("/livez", a.healthCheck)
+	a.router.GET("/readyz", a.readinessCheck)
+	a.router.GET("/metrics", gin.WrapH(a.metrics.Handler()))
+
+	priceService := services.NewPriceService(a.repo, a.repo)
+	customerService := services.NewCustomerService(a.repo)
+	exchangeRateService := services.NewExchangeRateService(a.repo)
+	adjustmentService := services.NewAdjustmentService(a.repo)
+	taxService := services.NewTaxService(a.repo, a.repo)
+	markBoxService := services.NewMarkBoxService(a.repo, a.repo)
+	farmService := services.NewFarmService(a.repo, a.metrics)
+	orderService := services.NewOrderService(
+		a.repo, priceService, exchangeRateService, adjustmentService, taxService, markBoxService, farmService,
+		a.repo, a.metrics,
+	)
+	invoiceService := services.NewInvoiceService(a.repo, a.repo, a.repo, a.repo, a.metrics)
+	shipmentService := services.NewShipmentService(a.repo, a.repo, a.metrics)
+	packingService := services.NewPackingService(a.repo, a.repo)
+	labelService := services.NewLabelService(a.repo)
+	farmOrderService := services.NewFarmOrderService(a.repo, a.repo)
+	claimService := services.NewClaimService(
+		a.repo, a.repo, a.repo, a.config.Claims.PhotoDir, int64(a.config.Claims.MaxPhotoBytes),
+	)
+	recurringOrderService := services.NewRecurringOrderService(
+		a.repo, orderService, markBoxService, a.config.Recurring.LeadTime,
+	)
+	// Сервисы создаются вместе с маршрутами, поэтому планировщик регистрируется здесь;
+	// фоновые задачи запускаются позже, в lifecycle.Start
+	a.workers.Add("recurring-orders", a.materializeRecurringOrders(recurringOrderService, a.config.Recurring.Interval))
+	orderHandler := handlers.NewOrderHandler(orderService, a.config.Limits.MaxItemsPerOrder)
 	flowerHandler := handlers.NewFlowerHandler(orderService)
-
-	api := a.router.Group("/api/v1")
+	priceHandler := handlers.NewPriceHandler(priceService)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
+	adjustmentHandler := handlers.NewAdjustmentHandler(adjustmentService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	taxHandler := handlers.NewTaxHandler(taxService)
+	shipmentHandler := handlers.NewShipmentHandler(shipmentService)
+	packingHandler := handlers.NewPackingHandler(packingService)
+	markBoxHandler := handlers.NewMarkBoxHandler(markBoxService)
+	labelHandler := handlers.NewLabelHandler(labelService)
+	farmOrderHandler := handlers.NewFarmOrderHandler(farmOrderService)
+	claimHandler := handlers.NewClaimHandler(claimService)
+	farmHandler := handlers.NewFarmHandler(farmService)
+	recurringOrderHandler := handlers.NewRecurringOrderHandler(recurringOrderService)
+
+	idempotency := a.idempotencyMiddleware(a.repo, a.config.Idempotency.KeyTTL, a.config.Idempotency.LeaseTimeout)
+
+	root := a.router.Group("/api/v1")
+	if limits := a.config.Limits; limits.RateLimitEnabled {
+		root.Use(a.rateLimitMiddleware(
+			ratelimit.NewMemoryLimiter(ratelimit.Rate{
+				RequestsPerMinute: limits.IPRequestsPerMinute,
+				Burst:             limits.IPBurst,
+			}),
+			ratelimit.NewMemoryLimiter(ratelimit.Rate{
+				RequestsPerMinute: limits.CustomerRequestsPerMinute,
+				Burst:             limits.CustomerBurst,
+			}),
+		))
+	}
+	// Загрузка фотографий принимает тело больше общего лимита, поэтому
+	// регистрируется в отдельной группе со своим лимитом
+	api := root.Group("", a.bodyLimitMiddleware(int64(a.config.Limits.MaxBodyBytes)))
+	uploads := root.Group("", a.uploadLimitMiddleware(int64(a.config.Claims.MaxPhotoBytes)))
 	{
 		api.GET("/ping", a.ping)
 		api.GET("/flowers", flowerHandler.GetAvailableFlowers)
+		api.GET("/flowers/availability", flowerHandler.ListAvailability)
+		api.PUT("/flowers/availability", flowerHandler.SetAvailability)
 
 		orders := api.Group("/orders")
 		{
-			orders.POST("", orderHandler.CreateOrder)
+			orders.GET("", orderHandler.ListOrders)
+			orders.POST("", idempotency, orderHandler.CreateOrder)
 			orders.GET("/:id", orderHandler.GetOrder)
+			orders.PATCH("/:id", orderHandler.UpdateOrder)
+			orders.POST("/:id/duplicate", orderHandler.DuplicateOrder)
+			orders.GET("/:id/invoices", invoiceHandler.ListOrderInvoices)
+			orders.GET("/:id/invoice", invoiceHandler.GetOrderInvoice)
+			orders.POST("/:id/invoice", invoiceHandler.IssueInvoice)
+			orders.POST("/:id/invoice/credit-note", invoiceHandler.CreditInvoice)
+			orders.GET("/:id/packing", packingHandler.GetOrderPacking)
+			orders.GET("/:id/labels", labelHandler.GetOrderLabels)
+			orders.GET("/:id/farm-orders", farmOrderHandler.ListOrderFarmOrders)
+			orders.POST("/:id/farm-orders", farmOrderHandler.CreateFarmOrders)
+		}
+
+		farmOrders := api.Group("/farm-orders")
+		{
+			farmOrders.GET("/:id", farmOrderHandler.GetFarmOrder)
+			farmOrders.POST("/:id/scans", farmOrderHandler.RecordScan)
+		}
+
+		claims := api.Group("/claims")
+		{
+			claims.GET("", claimHandler.ListClaims)
+			claims.POST("", claimHandler.CreateClaim)
+			claims.GET("/report", claimHandler.GetReport)
+			claims.GET("/:id", claimHandler.GetClaim)
+			claims.POST("/:id/status", claimHandler.UpdateStatus)
+			claims.GET("/:id/photos/:photo_id", claimHandler.GetPhoto)
+		}
+		uploads.POST("/claims/:id/photos", claimHandler.UploadPhoto)
+
+		prices := api.Group("/prices")
+		{
+			prices.GET("", priceHandler.ListPrices)
+			prices.POST("", priceHandler.CreatePrice)
+			prices.GET("/preview", priceHandler.PreviewPrice)
+			prices.DELETE("/:id", priceHandler.DeletePrice)
+		}
+
+		customers := api.Group("/customers")
+		{
+			customers.GET("/:id", customerHandler.GetCustomer)
+			customers.PUT("/:id", customerHandler.SaveCustomer)
+		}
+
+		farms := api.Group("/farms")
+		{
+			farms.GET("", farmHandler.ListFarms)
+			farms.POST("", farmHandler.CreateFarm)
+			farms.GET("/:id", farmHandler.GetFarm)
+			farms.PUT("/:id", farmHandler.UpdateFarm)
+			farms.GET("/:id/export", farmHandler.ExportOrders)
+		}
+
+		recurringOrders := api.Group("/recurring-orders")
+		{
+			recurringOrders.GET("", recurringOrderHandler.ListRecurringOrders)
+			recurringOrders.POST("", recurringOrderHandler.CreateRecurringOrder)
+			recurringOrders.GET("/:id", recurringOrderHandler.GetRecurringOrder)
+			recurringOrders.PUT("/:id", recurringOrderHandler.UpdateRecurringOrder)
+			recurringOrders.POST("/:id/pause", recurringOrderHandler.Pause)
+			recurringOrders.POST("/:id/resume", recurringOrderHandler.Resume)
+			recurringOrders.GET("/:id/preview", recurringOrderHandler.Preview)
+		}
+
+		markBoxes := api.Group("/mark-boxes")
+		{
+			markBoxes.GET("", markBoxHandler.ListMarkBoxes)
+			markBoxes.GET("/:code", markBoxHandler.GetMarkBox)
+			markBoxes.PUT("/:code", markBoxHandler.SaveMarkBox)
+			markBoxes.DELETE("/:code", markBoxHandler.DeleteMarkBox)
+		}
+
+		exchangeRates := api.Group("/exchange-rates")
+		{
+			exchangeRates.GET("", exchangeRateHandler.ListExchangeRates)
+			exchangeRates.POST("", exchangeRateHandler.CreateExchangeRate)
+		}
+
+		api.GET("/invoices/:id", invoiceHandler.GetInvoice)
+
+		adjustmentRules := api.Group("/adjustment-rules")
+		{
+			adjustmentRules.GET("", adjustmentHandler.ListRules)
+			adjustmentRules.POST("", adjustmentHandler.CreateRule)
+			adjustmentRules.DELETE("/:id", adjustmentHandler.DeleteRule)
+		}
+
+		freightRates := api.Group("/freight-rates")
+		{
+			freightRates.GET("", adjustmentHandler.ListFreightRates)
+			freightRates.PUT("/:truck", adjustmentHandler.SaveFreightRate)
+		}
+
+		taxRules := api.Group("/tax-rules")
+		{
+			taxRules.GET("", taxHandler.ListRules)
+			taxRules.POST("", taxHandler.CreateRule)
+			taxRules.DELETE("/:id", taxHandler.DeleteRule)
+		}
+
+		shipments := api.Group("/shipments")
+		{
+			shipments.GET("", shipmentHandler.ListShipments)
+			shipments.POST("", shipmentHandler.CreateShipment)
+			shipments.GET("/:id", shipmentHandler.GetShipment)
+			shipments.POST("/:id/items", shipmentHandler.AssignItems)
+			shipments.DELETE("/:id/items/:item_id", shipmentHandler.RemoveItem)
+			shipments.GET("/:id/manifest", shipmentHandler.GetManifest)
+		}
+
+		boxTypes := api.Group("/box-types")
+		{
+			boxTypes.GET("", packingHandler.ListBoxTypes)
+			boxTypes.POST("", packingHandler.CreateBoxType)
+			boxTypes.DELETE("/:id", packingHandler.DeleteBoxType)
 		}
 	}
 }
@@ -97,11 +350,13 @@ func (a *App) setupRoutes() {
 func (a *App) corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header(
 			"Access-Control-Allow-Headers",
-			"Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization",
+			"Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match, "+
+				requestIDHeader+", "+customerIDHeader+", "+idempotencyKeyHeader,
 		)
+		c.Header("Access-Control-Expose-Headers", requestIDHeader+", ETag, Retry-After, "+idempotentReplayedHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -112,16 +367,50 @@ func (a *App) corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// requestIDMiddleware принимает или генерирует X-Request-ID и сохраняет
+// логгер запроса в контексте
+func (a *App) requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		ctx := logger.ContextWithRequestID(c.Request.Context(), requestID)
+		ctx = a.logger.WithRequestID(requestID).ToContext(ctx)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(requestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// isValidRequestID проверяет, что переданный клиентом идентификатор
+// безопасно писать в логи и заголовки ответа
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
 func (a *App) loggingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
 		duration := time.Since(start)
 		a.logger.LogRequest(c.Request.Context(), c.Request.Method, c.Request.URL.Path, c.Writer.Status(), duration)
+		a.metrics.ObserveHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), duration)
 	}
 }
 
-// healthCheck обработчик проверки здоровья приложения
+// healthCheck обработчик проверки живости приложения: процесс запущен
+// и обрабатывает запросы, внешние зависимости не проверяются
 func (a *App) healthCheck(c *gin.Context) {
 	c.JSON(
 		http.StatusOK, gin.H{
@@ -132,6 +421,17 @@ func (a *App) healthCheck(c *gin.Context) {
 	)
 }
 
+// readinessCheck обработчик проверки готовности: проверяет БД и миграции,
+// во время остановки приложения возвращает 503
+func (a *App) readinessCheck(c *gin.Context) {
+	report, ready := a.health.Ready(c.Request.Context())
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
 // ping простой обработчик для тестирования
 func (a *App) ping(c *gin.Context) {
 	c.JSON(
@@ -141,47 +441,80 @@ func (a *App) ping(c *gin.Context) {
 	)
 }
 
+// Run запускает компоненты и блокируется до сигнала остановки, отмены
+// контекста или ошибки HTTP-сервера. Ошибки запуска и работы сервера
+// возвращаются вызывающему коду после освобождения ресурсов.
 func (a *App) Run(ctx context.Context) error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
 
-	a.logger.WithField("address", a.server.Addr).Info("Starting server")
-
-	go func() {
-		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			a.logger.WithError(err).Fatal("Failed to start server")
-		}
-	}()
+	if err := a.lifecycle.Start(ctx); err != nil {
+		return errors.Join(err, a.Shutdown())
+	}
 
 	a.logger.Info("Server started successfully")
 
+	var runErr error
 	select {
 	case <-quit:
 		a.logger.Info("Shutdown signal received")
 	case <-ctx.Done():
 		a.logger.Info("Context cancelled")
+	case runErr = <-a.serveErr:
+		a.logger.WithError(runErr).Error("Server stopped unexpectedly")
 	}
 
-	return a.Shutdown()
+	return errors.Join(runErr, a.Shutdown())
 }
 
+// Shutdown останавливает компоненты в обратном порядке в пределах ShutdownTimeout
 func (a *App) Shutdown() error {
 	a.logger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
 	defer cancel()
 
-	if a.repo != nil {
-		if err := a.repo.Close(); err != nil {
-			a.logger.WithError(err).Error("Failed to close database connection")
+	return a.lifecycle.Stop(ctx)
+}
+
+// startServer открывает порт синхронно, чтобы ошибка привязки вернулась
+// из Run, а обслуживание запросов выполняет в отдельной горутине
+func (a *App) startServer(context.Context) error {
+	a.logger.WithField("address", a.server.Addr).Info("Starting server")
+
+	listener, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := a.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.serveErr <- fmt.Errorf("http server failed: %w", err)
+		}
+	}()
+
+	return nil
+}
+
+// stopServer снимает готовность, ждет переключения трафика
+// и дожидается завершения текущих запросов
+func (a *App) stopServer(ctx context.Context) error {
+	// Сначала снимаем готовность, чтобы балансировщик перестал присылать трафик
+	a.health.SetShuttingDown()
+	if delay := a.config.Server.DrainDelay; delay > 0 {
+		a.logger.Infof("Waiting %s for traffic to drain", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
 		}
 	}
 
 	if err := a.server.Shutdown(ctx); err != nil {
-		a.logger.Error("Server forced to shutdown")
+		a.logger.WithError(err).Error("Server forced to shutdown")
 		return err
 	}
 
-	a.logger.Info("Server shutdown completed")
+	a.logger.Info("HTTP server stopped")
 	return nil
 }
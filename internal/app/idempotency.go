@@ -0,0 +1,176 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/handlers"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyStoreOpTimeout = 5 * time.Second
+)
+
+// idempotencyMiddleware повторно отдает сохраненный ответ на запрос
+// с уже использованным заголовком Idempotency-Key. Тот же ключ с другим
+// телом запроса отклоняется с 422. Пока запрос обрабатывается, ключ занят
+// на срок lease: если процесс упал, не сохранив ответ, повтор после
+// истечения аренды обрабатывается заново.
+func (a *App) idempotencyMiddleware(store domain.IdempotencyStore, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			handlers.RespondError(c, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			handlers.RespondError(c, http.StatusRequestEntityTooLarge, "Failed to read request body: "+err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		log := logger.FromContext(ctx).WithField("idempotency_key", key)
+		scopedKey := idempotencyScope(c) + " " + key
+		requestHash := hashRequest(body)
+
+		existing, err := store.ReserveIdempotencyKey(ctx, domain.IdempotencyRecord{
+			Key:         scopedKey,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(lease),
+		})
+		if errors.Is(err, domain.ErrConflict) {
+			log.WithError(err).Warn("Idempotency key is contended")
+			handlers.RespondError(c, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			return
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to reserve idempotency key")
+			handlers.RespondError(c, http.StatusInternalServerError, "Failed to process idempotency key")
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != requestHash:
+				handlers.RespondError(c, http.StatusUnprocessableEntity,
+					"Idempotency-Key was already used with a different request body")
+			case !existing.Completed():
+				handlers.RespondError(c, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			default:
+				log.Info("Replaying stored response for idempotency key")
+				c.Header(idempotentReplayedHeader, "true")
+				c.Data(existing.StatusCode, "application/json; charset=utf-8", existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// Сохранение не должно зависеть от отмены клиентского запроса
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreOpTimeout)
+		defer cancel()
+
+		release := func() {
+			if err := store.ReleaseIdempotencyKey(storeCtx, scopedKey); err != nil {
+				log.WithError(err).Error("Failed to release idempotency key")
+			}
+		}
+		// gin.Recovery находится снаружи: при панике обработчика ключ освобождается,
+		// а паника передается дальше
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				release()
+				panic(recovered)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Ошибки сервера не сохраняются: клиент может повторить запрос с тем же ключом
+		if status := recorder.Status(); status >= http.StatusInternalServerError {
+			release()
+			return
+		}
+
+		err = store.CompleteIdempotencyKey(
+			storeCtx, scopedKey, recorder.Status(), recorder.body.Bytes(), time.Now().Add(ttl),
+		)
+		if err != nil {
+			log.WithError(err).Error("Failed to store idempotent response")
+		}
+	}
+}
+
+// idempotencyScope возвращает область действия ключа: маршрут и IP клиента,
+// определенный сервером с учетом доверенных прокси (configureClientIP).
+// X-Customer-ID задает сам клиент, поэтому по нему область не строится:
+// иначе подменой заголовка можно было бы получить чужой сохраненный ответ.
+func idempotencyScope(c *gin.Context) string {
+	return c.Request.Method + " " + c.FullPath() + " " + c.ClientIP()
+}
+
+// purgeIdempotencyKeys периодически удаляет истекшие ключи идемпотентности
+func (a *App) purgeIdempotencyKeys(store domain.IdempotencyStore, interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := store.DeleteExpiredIdempotencyKeys(ctx)
+				if err != nil {
+					a.logger.WithError(err).Error("Failed to purge expired idempotency keys")
+					continue
+				}
+				if deleted > 0 {
+					a.logger.Debugf("Purged %d expired idempotency keys", deleted)
+				}
+			}
+		}
+	}
+}
+
+func hashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder дублирует тело ответа для сохранения
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
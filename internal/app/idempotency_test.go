@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// memoryIdempotencyStore хранит ключи в памяти; reserveErr возвращается вместо резервирования
+type memoryIdempotencyStore struct {
+	records    map[string]*domain.IdempotencyRecord
+	reserveErr error
+}
+
+func (s *memoryIdempotencyStore) ReserveIdempotencyKey(_ context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	if s.reserveErr != nil {
+		return nil, s.reserveErr
+	}
+	if existing, ok := s.records[record.Key]; ok {
+		return existing, nil
+	}
+	s.records[record.Key] = &record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) CompleteIdempotencyKey(
+	_ context.Context, key string, statusCode int, body []byte, expiresAt time.Time,
+) error {
+	record := s.records[key]
+	record.StatusCode, record.ResponseBody, record.ExpiresAt = statusCode, body, expiresAt
+	return nil
+}
+
+func (s *memoryIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpiredIdempotencyKeys(context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		ip         string
+		customerID string
+	}
+	tests := []struct {
+		name         string
+		reserveErr   error
+		requests     []request
+		wantStatuses []int
+		wantCreated  int
+	}{
+		{
+			name:         "replay from the same client",
+			requests:     []request{{ip: "10.0.0.1"}, {ip: "10.0.0.1"}},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+			wantCreated:  1,
+		},
+		{
+			name:         "customer header does not change the scope",
+			requests:     []request{{ip: "10.0.0.1", customerID: "a"}, {ip: "10.0.0.1", customerID: "b"}},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+			wantCreated:  1,
+		},
+		{
+			name:         "spoofed customer header from another address is not replayed",
+			requests:     []request{{ip: "10.0.0.1", customerID: "a"}, {ip: "10.0.0.2", customerID: "a"}},
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+			wantCreated:  2,
+		},
+		{
+			name:         "contended reservation is a conflict",
+			reserveErr:   domain.ErrConflict,
+			requests:     []request{{ip: "10.0.0.1"}},
+			wantStatuses: []int{http.StatusConflict},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryIdempotencyStore{records: make(map[string]*domain.IdempotencyRecord), reserveErr: tt.reserveErr}
+			var created int
+
+			router := gin.New()
+			router.POST("/orders", (&App{}).idempotencyMiddleware(store, time.Hour, time.Minute), func(c *gin.Context) {
+				created++
+				c.JSON(http.StatusCreated, gin.H{"created": created})
+			})
+
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"items":[]}`))
+				req.RemoteAddr = r.ip + ":40000"
+				req.Header.Set(idempotencyKeyHeader, "key-1")
+				if r.customerID != "" {
+					req.Header.Set(customerIDHeader, r.customerID)
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				if rec.Code != tt.wantStatuses[i] {
+					t.Errorf("request %d status = %d, want %d", i, rec.Code, tt.wantStatuses[i])
+				}
+			}
+			if created != tt.wantCreated {
+				t.Errorf("handler ran %d times, want %d", created, tt.wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+// component часть приложения с управляемым жизненным циклом.
+// start и stop необязательны: ресурсы, открытые при инициализации
+// (логгер, БД), регистрируются только с функцией stop.
+type component struct {
+	name    string
+	start   func(ctx context.Context) error
+	stop    func(ctx context.Context) error
+	running bool
+}
+
+// lifecycle запускает компоненты в порядке регистрации
+// и останавливает их в обратном порядке
+type lifecycle struct {
+	mu         sync.Mutex
+	logger     *logger.Logger
+	components []*component
+}
+
+func newLifecycle(log *logger.Logger) *lifecycle {
+	return &lifecycle{logger: log}
+}
+
+// Append регистрирует компонент. Компоненты без start считаются уже запущенными.
+func (l *lifecycle) Append(c component) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c.running = c.start == nil
+	l.components = append(l.components, &c)
+}
+
+// Start запускает компоненты по порядку. При ошибке уже запущенные
+// компоненты остаются запущенными и должны быть остановлены через Stop.
+func (l *lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, c := range l.components {
+		if c.running {
+			continue
+		}
+		l.logger.WithField("component", c.name).Debug("Starting component")
+		if err := c.start(ctx); err != nil {
+			return fmt.Errorf("failed to start %s: %w", c.name, err)
+		}
+		c.running = true
+	}
+	return nil
+}
+
+// Stop останавливает запущенные компоненты в обратном порядке.
+// Ошибки не прерывают остановку остальных компонентов и возвращаются вместе.
+func (l *lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for i := len(l.components) - 1; i >= 0; i-- {
+		c := l.components[i]
+		if !c.running {
+			continue
+		}
+		c.running = false
+		if c.stop == nil {
+			continue
+		}
+		l.logger.WithField("component", c.name).Debug("Stopping component")
+		if err := c.stop(ctx); err != nil {
+			l.logger.WithField("component", c.name).WithError(err).Error("Failed to stop component")
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// worker фоновая задача, работающая до отмены контекста
+type worker struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// workerGroup управляет фоновыми задачами, которые должны завершиться
+// до закрытия БД
+type workerGroup struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	logger  *logger.Logger
+	workers []worker
+}
+
+func newWorkerGroup(log *logger.Logger) *workerGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workerGroup{ctx: ctx, cancel: cancel, logger: log}
+}
+
+// Add регистрирует фоновую задачу. Задача должна завершиться после отмены контекста.
+func (w *workerGroup) Add(name string, run func(ctx context.Context)) {
+	w.workers = append(w.workers, worker{name: name, run: run})
+}
+
+// Start запускает все зарегистрированные задачи
+func (w *workerGroup) Start(context.Context) error {
+	for _, wk := range w.workers {
+		w.wg.Add(1)
+		go func(wk worker) {
+			defer w.wg.Done()
+			w.logger.WithField("worker", wk.name).Debug("Background worker started")
+			wk.run(w.ctx)
+			w.logger.WithField("worker", wk.name).Debug("Background worker stopped")
+		}(wk)
+	}
+	return nil
+}
+
+// Stop отменяет контекст задач и ждет их завершения не дольше дедлайна ctx
+func (w *workerGroup) Stop(ctx context.Context) error {
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background workers did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/handlers"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/ratelimit"
+)
+
+// customerIDHeader идентифицирует клиента для лимитов, пока в API нет аутентификации.
+// Значение задает сам клиент, поэтому лимит по нему рекомендательный.
+const customerIDHeader = "X-Customer-ID"
+
+// rateLimitMiddleware ограничивает частоту запросов по IP-адресу
+// и, если клиент передал X-Customer-ID, дополнительно по клиенту.
+// IP определяется с учетом доверенных прокси (configureClientIP).
+func (a *App) rateLimitMiddleware(byIP, byCustomer ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.allow(c, byIP, "ip:"+c.ClientIP()) {
+			return
+		}
+		if customerID := c.GetHeader(customerIDHeader); customerID != "" {
+			if !a.allow(c, byCustomer, "customer:"+customerID) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// configureClientIP задает, каким прокси и заголовкам платформы доверять при
+// определении IP клиента. Без этого gin доверяет X-Forwarded-For от любого
+// клиента, и лимит по IP обходится подменой заголовка.
+func (a *App) configureClientIP() error {
+	if err := a.router.SetTrustedProxies(a.config.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	a.router.TrustedPlatform = a.config.Server.TrustedPlatform
+	return nil
+}
+
+// allow проверяет лимит и при превышении отвечает 429 с заголовком Retry-After
+func (a *App) allow(c *gin.Context, limiter ratelimit.Limiter, key string) bool {
+	decision, err := limiter.Allow(c.Request.Context(), key)
+	if err != nil {
+		// Недоступность хранилища лимитов не должна останавливать прием заказов
+		logger.FromContext(c.Request.Context()).WithError(err).Warn("Rate limiter unavailable")
+		return true
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	if decision.Allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	logger.FromContext(c.Request.Context()).WithField("key", key).Warn("Rate limit exceeded")
+	handlers.RespondError(c, http.StatusTooManyRequests, "Rate limit exceeded, retry in "+
+		(time.Duration(retryAfter)*time.Second).String())
+	return false
+}
+
+// multipartOverheadBytes запас на заголовки и границы частей формы multipart
+const multipartOverheadBytes = 64 << 10
+
+// bodyLimitMiddleware ограничивает размер тела запроса. Общий лимит задается
+// для группы /api/v1, загрузка файлов получает свой лимит на уровне маршрута
+// (uploadLimitMiddleware), чтобы большой лимит не действовал на остальные маршруты.
+func (a *App) bodyLimitMiddleware(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			handlers.RespondError(c, http.StatusRequestEntityTooLarge,
+				"Request body exceeds "+strconv.FormatInt(limit, 10)+" bytes")
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// uploadLimitMiddleware ограничивает тело формы multipart/form-data с файлом
+// до uploadBytes с запасом на заголовки и границы частей
+func (a *App) uploadLimitMiddleware(uploadBytes int64) gin.HandlerFunc {
+	return a.bodyLimitMiddleware(uploadBytes + multipartOverheadBytes)
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const (
+		maxBody     = 1 << 10
+		uploadBytes = 256 << 10
+	)
+
+	a := &App{}
+	router := gin.New()
+	root := router.Group("/api/v1")
+	api := root.Group("", a.bodyLimitMiddleware(maxBody))
+	uploads := root.Group("", a.uploadLimitMiddleware(uploadBytes))
+
+	read := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+	api.POST("/claims", read)
+	uploads.POST("/claims/:id/photos", read)
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		size        int
+		chunked     bool
+		wantStatus  int
+	}{
+		{name: "small json", path: "/api/v1/claims", contentType: "application/json", size: 512, wantStatus: http.StatusNoContent},
+		{name: "large json", path: "/api/v1/claims", contentType: "application/json", size: 4 << 10, wantStatus: http.StatusRequestEntityTooLarge},
+		{
+			name:        "multipart on a regular route keeps the general limit",
+			path:        "/api/v1/claims",
+			contentType: "multipart/form-data; boundary=x",
+			size:        4 << 10,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "multipart body without length on a regular route",
+			path:        "/api/v1/claims",
+			contentType: "multipart/form-data; boundary=x",
+			size:        4 << 10,
+			chunked:     true,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "photo within the upload limit",
+			path:        "/api/v1/claims/1/photos",
+			contentType: "multipart/form-data; boundary=x",
+			size:        200 << 10,
+			wantStatus:  http.StatusNoContent,
+		},
+		{
+			name:        "photo over the upload limit",
+			path:        "/api/v1/claims/1/photos",
+			contentType: "multipart/form-data; boundary=x",
+			size:        uploadBytes + multipartOverheadBytes + 1,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("a", tt.size)))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+// materializeRecurringOrders создает заказы по постоянным заказам при запуске
+// и затем с периодичностью interval
+func (a *App) materializeRecurringOrders(
+	recurring *services.RecurringOrderService,
+	interval time.Duration,
+) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			created, err := recurring.MaterializeDue(a.logger.ToContext(ctx), time.Now())
+			if err != nil {
+				a.logger.WithError(err).Error("Failed to materialize recurring orders")
+			} else if created > 0 {
+				a.logger.Infof("Created %d orders from recurring orders", created)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -8,10 +8,15 @@ import (
 
 // Config представляет конфигурацию приложения
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	Logger   LoggerConfig   `json:"logger"`
-	Security SecurityConfig `json:"security"`
+	Server      ServerConfig      `json:"server"`
+	Database    DatabaseConfig    `json:"database"`
+	Logger      LoggerConfig      `json:"logger"`
+	Security    SecurityConfig    `json:"security"`
+	Tracing     TracingConfig     `json:"tracing"`
+	Limits      LimitsConfig      `json:"limits"`
+	Idempotency IdempotencyConfig `json:"idempotency"`
+	Claims      ClaimsConfig      `json:"claims"`
+	Recurring   RecurringConfig   `json:"recurring"`
 }
 
 // ServerConfig конфигурация сервера
@@ -21,6 +26,17 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `json:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"30s"`
 	WriteTimeout time.Duration `json:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
 	IdleTimeout  time.Duration `json:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s"`
+	// ReadinessTimeout ограничивает время проверки каждой зависимости в /readyz
+	ReadinessTimeout time.Duration `json:"readiness_timeout" env:"SERVER_READINESS_TIMEOUT" default:"2s"`
+	// DrainDelay время, в течение которого /readyz отвечает 503 перед остановкой сервера
+	DrainDelay time.Duration `json:"drain_delay" env:"SERVER_DRAIN_DELAY" default:"5s"`
+	// ShutdownTimeout общий дедлайн остановки всех компонентов
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s"`
+	// TrustedProxies адреса и подсети прокси, которым доверяется X-Forwarded-For.
+	// Пустой список: IP клиента берется из адреса соединения.
+	TrustedProxies []string `json:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+	// TrustedPlatform заголовок с IP клиента, выставляемый платформой (например, CF-Connecting-IP)
+	TrustedPlatform string `json:"trusted_platform" env:"SERVER_TRUSTED_PLATFORM"`
 }
 
 // DatabaseConfig конфигурация базы данных
@@ -41,6 +57,15 @@ type LoggerConfig struct {
 	Format     string `json:"format" env:"LOG_FORMAT" default:"console"`
 	Output     string `json:"output" env:"LOG_OUTPUT" default:"stdout"`
 	TimeFormat string `json:"time_format" env:"LOG_TIME_FORMAT" default:"2006-01-02T15:04:05.000Z07:00"`
+	// Sinks задает несколько выходов в виде "формат:вывод",
+	// например "console:stdout,json:/var/log/dolina/app.log".
+	// Если не задано, используется пара Format/Output.
+	Sinks []string `json:"sinks" env:"LOG_SINKS"`
+	// Параметры ротации файловых выходов
+	MaxSizeMB  int           `json:"max_size_mb" env:"LOG_MAX_SIZE_MB" default:"100"`
+	MaxAge     time.Duration `json:"max_age" env:"LOG_MAX_AGE" default:"24h"`
+	MaxBackups int           `json:"max_backups" env:"LOG_MAX_BACKUPS" default:"7"`
+	Compress   bool          `json:"compress" env:"LOG_COMPRESS" default:"true"`
 }
 
 // SecurityConfig конфигурация безопасности
@@ -50,6 +75,59 @@ type SecurityConfig struct {
 	CORSOrigins   []string      `json:"cors_origins" env:"CORS_ORIGINS" default:"*"`
 }
 
+// TracingConfig конфигурация трассировки OpenTelemetry
+type TracingConfig struct {
+	// Exporter: none, stdout или otlp
+	Exporter     string  `json:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	OTLPEndpoint string  `json:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+	OTLPInsecure bool    `json:"otlp_insecure" env:"TRACING_OTLP_INSECURE" default:"true"`
+	SampleRatio  float64 `json:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+	ServiceName  string  `json:"service_name" env:"TRACING_SERVICE_NAME" default:"dolina-flower-order"`
+}
+
+// LimitsConfig ограничения частоты и размера запросов
+type LimitsConfig struct {
+	RateLimitEnabled bool `json:"rate_limit_enabled" env:"RATE_LIMIT_ENABLED" default:"true"`
+	// Лимит по IP-адресу клиента
+	IPRequestsPerMinute int `json:"ip_requests_per_minute" env:"RATE_LIMIT_IP_PER_MINUTE" default:"120"`
+	IPBurst             int `json:"ip_burst" env:"RATE_LIMIT_IP_BURST" default:"30"`
+	// Лимит по клиенту (заголовок X-Customer-ID). Заголовок не аутентифицирован,
+	// поэтому лимит рекомендательный: защиту от злоупотреблений дает лимит по IP.
+	CustomerRequestsPerMinute int `json:"customer_requests_per_minute" env:"RATE_LIMIT_CUSTOMER_PER_MINUTE" default:"60"`
+	CustomerBurst             int `json:"customer_burst" env:"RATE_LIMIT_CUSTOMER_BURST" default:"20"`
+	// Максимальный размер тела запроса в байтах
+	MaxBodyBytes int `json:"max_body_bytes" env:"MAX_BODY_BYTES" default:"1048576"`
+	// Максимальное количество позиций в одном заказе
+	MaxItemsPerOrder int `json:"max_items_per_order" env:"MAX_ITEMS_PER_ORDER" default:"200"`
+}
+
+// IdempotencyConfig настройки ключей идемпотентности
+type IdempotencyConfig struct {
+	// KeyTTL срок, в течение которого повтор запроса с тем же ключом возвращает исходный ответ
+	KeyTTL time.Duration `json:"key_ttl" env:"IDEMPOTENCY_KEY_TTL" default:"24h"`
+	// PurgeInterval периодичность удаления истекших ключей
+	PurgeInterval time.Duration `json:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL" default:"1h"`
+	// LeaseTimeout срок, на который ключ занимается обрабатываемым запросом; если ответ
+	// не сохранен (например, процесс упал), повтор после него обрабатывается заново
+	LeaseTimeout time.Duration `json:"lease_timeout" env:"IDEMPOTENCY_LEASE_TIMEOUT" default:"1m"`
+}
+
+// ClaimsConfig настройки рекламаций
+type ClaimsConfig struct {
+	// PhotoDir каталог на локальном диске для фотографий рекламаций
+	PhotoDir string `json:"photo_dir" env:"CLAIM_PHOTO_DIR" default:"data/claims"`
+	// MaxPhotoBytes максимальный размер загружаемой фотографии
+	MaxPhotoBytes int `json:"max_photo_bytes" env:"CLAIM_MAX_PHOTO_BYTES" default:"10485760"`
+}
+
+// RecurringConfig настройки планировщика постоянных заказов
+type RecurringConfig struct {
+	// LeadTime за сколько до даты отправки создается заказ по шаблону
+	LeadTime time.Duration `json:"lead_time" env:"RECURRING_ORDER_LEAD_TIME" default:"72h"`
+	// Interval периодичность проверки шаблонов
+	Interval time.Duration `json:"interval" env:"RECURRING_ORDER_INTERVAL" default:"15m"`
+}
+
 var (
 	instance *Config
 	once     sync.Once
@@ -81,12 +81,24 @@ func setFieldValue(field reflect.Value, value string) error {
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolVal)
 	case reflect.Int:
 		intVal, err := strconv.Atoi(value)
 		if err != nil {
 			return err
 		}
 		field.SetInt(int64(intVal))
+	case reflect.Float64:
+		floatVal, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatVal)
 	case reflect.Slice:
 		if field.Type().Elem().Kind() == reflect.String {
 			values := strings.Split(value, ",")
@@ -142,5 +154,48 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("invalid log level: %s", cfg.Logger.Level)
 	}
 
+	for _, sink := range cfg.Logger.Sinks {
+		format, output, found := strings.Cut(sink, ":")
+		if !found || output == "" || (format != "console" && format != "json") {
+			return fmt.Errorf("invalid log sink %q: expected console:<output> or json:<output>", sink)
+		}
+	}
+
+	switch cfg.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		return fmt.Errorf("invalid tracing exporter: %s", cfg.Tracing.Exporter)
+	}
+
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("invalid tracing sample ratio: %v", cfg.Tracing.SampleRatio)
+	}
+
+	if cfg.Limits.MaxBodyBytes <= 0 || cfg.Limits.MaxItemsPerOrder <= 0 {
+		return fmt.Errorf("invalid request limits: max body %d bytes, max %d items",
+			cfg.Limits.MaxBodyBytes, cfg.Limits.MaxItemsPerOrder)
+	}
+
+	if cfg.Limits.RateLimitEnabled && (cfg.Limits.IPBurst <= 0 || cfg.Limits.CustomerBurst <= 0) {
+		return fmt.Errorf("rate limit burst must be positive")
+	}
+
+	if cfg.Idempotency.KeyTTL <= 0 || cfg.Idempotency.PurgeInterval <= 0 || cfg.Idempotency.LeaseTimeout <= 0 {
+		return fmt.Errorf("idempotency key TTL, purge interval and lease timeout must be positive")
+	}
+
+	if cfg.Claims.PhotoDir == "" || cfg.Claims.MaxPhotoBytes <= 0 {
+		return fmt.Errorf("invalid claim photo settings: dir %q, max %d bytes",
+			cfg.Claims.PhotoDir, cfg.Claims.MaxPhotoBytes)
+	}
+
+	if cfg.Recurring.LeadTime <= 0 || cfg.Recurring.Interval <= 0 {
+		return fmt.Errorf("recurring order lead time and interval must be positive")
+	}
+
+	if cfg.Logger.MaxSizeMB < 0 || cfg.Logger.MaxBackups < 0 || cfg.Logger.MaxAge < 0 {
+		return fmt.Errorf("invalid log rotation settings")
+	}
+
 	return nil
 }
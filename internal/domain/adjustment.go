@@ -0,0 +1,247 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AdjustmentKind вид корректировки суммы заказа
+type AdjustmentKind string
+
+const (
+	AdjustmentDiscount  AdjustmentKind = "discount"
+	AdjustmentSurcharge AdjustmentKind = "surcharge"
+	AdjustmentFreight   AdjustmentKind = "freight"
+)
+
+// AdjustmentMethod способ расчета корректировки
+type AdjustmentMethod string
+
+const (
+	// AdjustmentPercent процент от суммы позиции или заказа
+	AdjustmentPercent AdjustmentMethod = "percent"
+	// AdjustmentFixed фиксированная сумма в валюте корректировки
+	AdjustmentFixed AdjustmentMethod = "fixed"
+)
+
+// AdjustmentSource происхождение корректировки
+type AdjustmentSource string
+
+const (
+	AdjustmentSourceRule    AdjustmentSource = "rule"
+	AdjustmentSourceFreight AdjustmentSource = "freight"
+	AdjustmentSourceManual  AdjustmentSource = "manual"
+)
+
+// AdjustmentScope уровень применения правила
+type AdjustmentScope string
+
+const (
+	AdjustmentScopeOrder AdjustmentScope = "order"
+	AdjustmentScopeLine  AdjustmentScope = "line"
+)
+
+// Adjustment скидка, наценка или доставка, примененная к позиции (ItemID)
+// или ко всему заказу (пустой ItemID)
+type Adjustment struct {
+	ID          string           `json:"id"`
+	ItemID      string           `json:"item_id,omitempty"`
+	Kind        AdjustmentKind   `json:"kind"`
+	Method      AdjustmentMethod `json:"method"`
+	Source      AdjustmentSource `json:"source"`
+	Description string           `json:"description,omitempty"`
+	RuleID      string           `json:"rule_id,omitempty"`
+	// Percent процент для Method=percent
+	Percent Rate `json:"percent"`
+	// Value сумма в валюте Currency для Method=fixed
+	Value    Money  `json:"value"`
+	Currency string `json:"currency,omitempty"`
+	// Amount рассчитанная сумма в валюте заказа; скидки отрицательные
+	Amount Money `json:"amount"`
+	// TaxRate ставка налога в процентах; TaxAmount налог с Amount
+	TaxRate   Rate  `json:"tax_rate"`
+	TaxAmount Money `json:"tax_amount"`
+}
+
+// Validate проверяет согласованность полей корректировки
+func (a *Adjustment) Validate() error {
+	switch a.Kind {
+	case AdjustmentDiscount, AdjustmentSurcharge, AdjustmentFreight:
+	default:
+		return fmt.Errorf("%w: unknown adjustment kind %q", ErrValidation, a.Kind)
+	}
+	switch a.Method {
+	case AdjustmentPercent:
+		if !a.Percent.IsPositive() {
+			return fmt.Errorf("%w: adjustment percent must be positive", ErrValidation)
+		}
+	case AdjustmentFixed:
+		if a.Value.IsNegative() || a.Value.IsZero() {
+			return fmt.Errorf("%w: adjustment value must be positive", ErrValidation)
+		}
+	default:
+		return fmt.Errorf("%w: unknown adjustment method %q", ErrValidation, a.Method)
+	}
+	return nil
+}
+
+// OrderTotals разбивка суммы заказа в валюте заказа
+type OrderTotals struct {
+	// Subtotal сумма позиций с учетом скидок и наценок на позиции
+	Subtotal   Money `json:"subtotal"`
+	Discounts  Money `json:"discounts"`
+	Surcharges Money `json:"surcharges"`
+	Freight    Money `json:"freight"`
+	// Net сумма без налога
+	Net   Money `json:"net"`
+	Tax   Money `json:"tax"`
+	Total Money `json:"total"`
+}
+
+func (t *OrderTotals) add(kind AdjustmentKind, amount Money) {
+	switch kind {
+	case AdjustmentDiscount:
+		t.Discounts = t.Discounts.Add(amount)
+	case AdjustmentSurcharge:
+		t.Surcharges = t.Surcharges.Add(amount)
+	case AdjustmentFreight:
+		t.Freight = t.Freight.Add(amount)
+	}
+}
+
+// applyAdjustments рассчитывает корректировки, налог и разбивку суммы.
+// lines - суммы позиций в валюте заказа по ID позиции, goods - их сумма.
+// Сначала применяются корректировки позиций, затем процентные корректировки
+// заказа считаются от суммы позиций с учетом корректировок позиций без доставки.
+func (o *Order) applyAdjustments(lines map[string]Money, goods Money) (OrderTotals, error) {
+	totals := OrderTotals{Subtotal: goods}
+
+	for i := range o.Adjustments {
+		adj := &o.Adjustments[i]
+		if adj.ItemID == "" {
+			continue
+		}
+		line, ok := lines[adj.ItemID]
+		if !ok {
+			return OrderTotals{}, fmt.Errorf("%w: adjustment for unknown item %s", ErrValidation, adj.ItemID)
+		}
+		amount, err := o.adjustmentAmount(adj, line)
+		if err != nil {
+			return OrderTotals{}, err
+		}
+		adj.Amount = amount
+		totals.add(adj.Kind, amount)
+		if adj.Kind != AdjustmentFreight {
+			totals.Subtotal = totals.Subtotal.Add(amount)
+		}
+	}
+
+	for i := range o.Adjustments {
+		adj := &o.Adjustments[i]
+		if adj.ItemID != "" {
+			continue
+		}
+		amount, err := o.adjustmentAmount(adj, totals.Subtotal)
+		if err != nil {
+			return OrderTotals{}, err
+		}
+		adj.Amount = amount
+		totals.add(adj.Kind, amount)
+	}
+
+	totals.Net = totals.Subtotal
+	for _, adj := range o.Adjustments {
+		if adj.ItemID == "" || adj.Kind == AdjustmentFreight {
+			totals.Net = totals.Net.Add(adj.Amount)
+		}
+	}
+
+	for _, item := range o.Items {
+		totals.Tax = totals.Tax.Add(item.TaxAmount)
+	}
+	for i := range o.Adjustments {
+		adj := &o.Adjustments[i]
+		adj.TaxAmount = adj.Amount.Percent(adj.TaxRate)
+		totals.Tax = totals.Tax.Add(adj.TaxAmount)
+	}
+	totals.Total = totals.Net.Add(totals.Tax)
+	return totals, nil
+}
+
+// adjustmentAmount вычисляет сумму корректировки со знаком в валюте заказа
+func (o *Order) adjustmentAmount(adj *Adjustment, base Money) (Money, error) {
+	var amount Money
+	switch adj.Method {
+	case AdjustmentPercent:
+		amount = base.Percent(adj.Percent)
+	case AdjustmentFixed:
+		amount = adj.Value
+		if adj.Currency != "" && adj.Currency != o.Currency {
+			rate, err := o.rateFor(adj.Currency)
+			if err != nil {
+				return Money{}, err
+			}
+			amount = amount.Convert(rate)
+		}
+	}
+	if adj.Kind == AdjustmentDiscount {
+		amount = amount.Neg()
+	}
+	return amount, nil
+}
+
+// AdjustmentRule правило автоматической скидки или наценки, применяемое
+// при создании заказа, например «5% скидки от 5000 стеблей»
+type AdjustmentRule struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Kind     AdjustmentKind   `json:"kind"`
+	Scope    AdjustmentScope  `json:"scope"`
+	Method   AdjustmentMethod `json:"method"`
+	Percent  Rate             `json:"percent"`
+	Value    Money            `json:"value"`
+	Currency string           `json:"currency,omitempty"`
+	// MinStems минимальное количество стеблей в заказе (scope=order)
+	// или в позиции (scope=line), с которого действует правило
+	MinStems  int       `json:"min_stems"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Applies проверяет, действует ли правило для указанного количества стеблей
+func (r *AdjustmentRule) Applies(stems int) bool {
+	return r.Active && stems >= r.MinStems
+}
+
+// Adjustment создает корректировку по правилу для позиции или заказа
+func (r *AdjustmentRule) Adjustment(itemID string) Adjustment {
+	return Adjustment{
+		ItemID:      itemID,
+		Kind:        r.Kind,
+		Method:      r.Method,
+		Source:      AdjustmentSourceRule,
+		Description: r.Name,
+		RuleID:      r.ID,
+		Percent:     r.Percent,
+		Value:       r.Value,
+		Currency:    r.Currency,
+	}
+}
+
+// FreightRate стоимость доставки одной коробки грузовиком TruckName
+type FreightRate struct {
+	TruckName string    `json:"truck_name"`
+	PerBox    Money     `json:"per_box"`
+	Currency  string    `json:"currency"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AdjustmentRepository хранилище правил корректировок и тарифов доставки
+type AdjustmentRepository interface {
+	CreateAdjustmentRule(ctx context.Context, rule *AdjustmentRule) error
+	ListAdjustmentRules(ctx context.Context, activeOnly bool) ([]AdjustmentRule, error)
+	DeleteAdjustmentRule(ctx context.Context, id string) error
+	SaveFreightRate(ctx context.Context, rate *FreightRate) error
+	ListFreightRates(ctx context.Context) ([]FreightRate, error)
+}
@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCalculateTotalAdjustments(t *testing.T) {
+	// две позиции по 100.00 EUR
+	items := func() []Item {
+		return []Item{
+			{ID: "rose", TotalStems: 200, Price: mustMoney(t, "0.50")},
+			{ID: "tulip", TotalStems: 400, Price: mustMoney(t, "0.25")},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		adjustments []Adjustment
+		wantAmounts []string
+		wantTotals  OrderTotals
+		wantErr     bool
+	}{
+		{
+			name:        "no adjustments",
+			wantTotals:  OrderTotals{Subtotal: mustMoney(t, "200.00"), Net: mustMoney(t, "200.00"), Total: mustMoney(t, "200.00")},
+			wantAmounts: []string{},
+		},
+		{
+			name: "percent discount on an item",
+			adjustments: []Adjustment{
+				{ItemID: "rose", Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "10")},
+			},
+			wantAmounts: []string{"-10.00"},
+			wantTotals: OrderTotals{
+				Subtotal: mustMoney(t, "190.00"), Discounts: mustMoney(t, "-10.00"),
+				Net: mustMoney(t, "190.00"), Total: mustMoney(t, "190.00"),
+			},
+		},
+		{
+			name: "fixed discount on an item",
+			adjustments: []Adjustment{
+				{ItemID: "tulip", Kind: AdjustmentDiscount, Method: AdjustmentFixed, Value: mustMoney(t, "15.00")},
+			},
+			wantAmounts: []string{"-15.00"},
+			wantTotals: OrderTotals{
+				Subtotal: mustMoney(t, "185.00"), Discounts: mustMoney(t, "-15.00"),
+				Net: mustMoney(t, "185.00"), Total: mustMoney(t, "185.00"),
+			},
+		},
+		{
+			// процент заказа считается от суммы позиций после корректировок позиций
+			name: "order percent after item adjustments",
+			adjustments: []Adjustment{
+				{Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "5")},
+				{ItemID: "rose", Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "10")},
+			},
+			wantAmounts: []string{"-9.50", "-10.00"},
+			wantTotals: OrderTotals{
+				Subtotal: mustMoney(t, "190.00"), Discounts: mustMoney(t, "-19.50"),
+				Net: mustMoney(t, "180.50"), Total: mustMoney(t, "180.50"),
+			},
+		},
+		{
+			name: "fixed order surcharge",
+			adjustments: []Adjustment{
+				{Kind: AdjustmentSurcharge, Method: AdjustmentFixed, Value: mustMoney(t, "12.34")},
+			},
+			wantAmounts: []string{"12.34"},
+			wantTotals: OrderTotals{
+				Subtotal: mustMoney(t, "200.00"), Surcharges: mustMoney(t, "12.34"),
+				Net: mustMoney(t, "212.34"), Total: mustMoney(t, "212.34"),
+			},
+		},
+		{
+			// доставка позиции (тариф за коробку * число коробок) не входит
+			// в базу процентной корректировки заказа
+			name: "freight per box is not part of the order percent base",
+			adjustments: []Adjustment{
+				{
+					ItemID: "rose", Kind: AdjustmentFreight, Method: AdjustmentFixed, Source: AdjustmentSourceFreight,
+					Value: mustMoney(t, "4.00").MulQuantity(2.5),
+				},
+				{Kind: AdjustmentSurcharge, Method: AdjustmentPercent, Percent: mustRate(t, "10")},
+			},
+			wantAmounts: []string{"10.00", "20.00"},
+			wantTotals: OrderTotals{
+				Subtotal: mustMoney(t, "200.00"), Surcharges: mustMoney(t, "20.00"), Freight: mustMoney(t, "10.00"),
+				Net: mustMoney(t, "230.00"), Total: mustMoney(t, "230.00"),
+			},
+		},
+		{
+			name: "fixed freight in another currency",
+			adjustments: []Adjustment{
+				{Kind: AdjustmentFreight, Method: AdjustmentFixed, Value: mustMoney(t, "25.00"), Currency: "USD"},
+			},
+			wantAmounts: []string{"23.00"},
+			wantTotals: OrderTotals{
+				Subtotal: mustMoney(t, "200.00"), Freight: mustMoney(t, "23.00"),
+				Net: mustMoney(t, "223.00"), Total: mustMoney(t, "223.00"),
+			},
+		},
+		{
+			name: "adjustment in a currency without rate",
+			adjustments: []Adjustment{
+				{Kind: AdjustmentFreight, Method: AdjustmentFixed, Value: mustMoney(t, "25.00"), Currency: "GBP"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "adjustment for an unknown item",
+			adjustments: []Adjustment{
+				{ItemID: "lily", Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "10")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{
+				Currency:      "EUR",
+				Items:         items(),
+				Adjustments:   tt.adjustments,
+				ExchangeRates: []ExchangeRate{{FromCurrency: "USD", ToCurrency: "EUR", Rate: mustRate(t, "0.92")}},
+			}
+			total, err := order.CalculateTotal()
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("CalculateTotal error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CalculateTotal: %v", err)
+			}
+
+			for i, adj := range order.Adjustments {
+				if adj.Amount.String() != tt.wantAmounts[i] {
+					t.Errorf("adjustment %d amount = %s, want %s", i, adj.Amount, tt.wantAmounts[i])
+				}
+			}
+			// суммы decimal сравниваются по строковому представлению
+			if fmt.Sprintf("%+v", order.Totals) != fmt.Sprintf("%+v", tt.wantTotals) {
+				t.Errorf("totals = %+v, want %+v", order.Totals, tt.wantTotals)
+			}
+			if total.String() != tt.wantTotals.Total.String() {
+				t.Errorf("total = %s, want %s", total, tt.wantTotals.Total)
+			}
+		})
+	}
+}
+
+func TestAdjustmentValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		adj     Adjustment
+		wantErr bool
+	}{
+		{name: "percent", adj: Adjustment{Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "5")}},
+		{name: "fixed", adj: Adjustment{Kind: AdjustmentFreight, Method: AdjustmentFixed, Value: mustMoney(t, "1.00")}},
+		{name: "zero percent", adj: Adjustment{Kind: AdjustmentDiscount, Method: AdjustmentPercent}, wantErr: true},
+		{name: "zero value", adj: Adjustment{Kind: AdjustmentSurcharge, Method: AdjustmentFixed}, wantErr: true},
+		{
+			name:    "negative value",
+			adj:     Adjustment{Kind: AdjustmentSurcharge, Method: AdjustmentFixed, Value: mustMoney(t, "-1.00")},
+			wantErr: true,
+		},
+		{name: "unknown kind", adj: Adjustment{Kind: "tip", Method: AdjustmentPercent, Percent: mustRate(t, "5")}, wantErr: true},
+		{name: "unknown method", adj: Adjustment{Kind: AdjustmentDiscount, Method: "ratio"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.adj.Validate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("Validate error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+		})
+	}
+}
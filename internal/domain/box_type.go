@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// VolumetricDivisor делитель объемного веса авиагруза: см³ на килограмм
+const VolumetricDivisor = 6000
+
+// BoxType тип коробки фермы, например HB (half box) или QB (quarter box)
+type BoxType struct {
+	ID       string `json:"id"`
+	FarmName string `json:"farm_name"`
+	Code     string `json:"code"`
+	Name     string `json:"name,omitempty"`
+	// Size доля полной коробки: 1 - FB, 0.5 - HB, 0.25 - QB.
+	// Item.BoxCount и Item.PackRate указываются в полных коробках.
+	Size     float64 `json:"size"`
+	LengthCm float64 `json:"length_cm"`
+	WidthCm  float64 `json:"width_cm"`
+	HeightCm float64 `json:"height_cm"`
+	// TareWeightKg вес пустой коробки
+	TareWeightKg float64 `json:"tare_weight_kg"`
+	// GrossWeightKg вес заполненной коробки
+	GrossWeightKg float64   `json:"gross_weight_kg"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// VolumetricWeightKg возвращает объемный вес коробки
+func (b *BoxType) VolumetricWeightKg() float64 {
+	return b.LengthCm * b.WidthCm * b.HeightCm / VolumetricDivisor
+}
+
+// BoxTypeRepository хранилище типов коробок
+type BoxTypeRepository interface {
+	CreateBoxType(ctx context.Context, boxType *BoxType) error
+	// ListBoxTypes возвращает типы коробок фермы; пустая ферма - все типы
+	ListBoxTypes(ctx context.Context, farmName string) ([]BoxType, error)
+	DeleteBoxType(ctx context.Context, id string) error
+}
+
+// DefaultBoxTypes стандартные коробки, если для фермы типы не заданы
+func DefaultBoxTypes() []BoxType {
+	return []BoxType{
+		{Code: "FB", Name: "Full box", Size: 1, LengthCm: 105, WidthCm: 50, HeightCm: 30, TareWeightKg: 3, GrossWeightKg: 30},
+		{Code: "HB", Name: "Half box", Size: 0.5, LengthCm: 105, WidthCm: 25, HeightCm: 30, TareWeightKg: 1.6, GrossWeightKg: 15},
+		{Code: "QB", Name: "Quarter box", Size: 0.25, LengthCm: 105, WidthCm: 25, HeightCm: 15, TareWeightKg: 1, GrossWeightKg: 8},
+	}
+}
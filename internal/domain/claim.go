@@ -0,0 +1,330 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ClaimReason код причины рекламации
+type ClaimReason string
+
+const (
+	ClaimReasonBotrytis     ClaimReason = "botrytis"
+	ClaimReasonBrokenHeads  ClaimReason = "broken_heads"
+	ClaimReasonShortLength  ClaimReason = "short_length"
+	ClaimReasonDehydration  ClaimReason = "dehydration"
+	ClaimReasonWrongVariety ClaimReason = "wrong_variety"
+	ClaimReasonMissing      ClaimReason = "missing"
+	ClaimReasonOther        ClaimReason = "other"
+)
+
+// ClaimReasons допустимые коды причин
+var ClaimReasons = []ClaimReason{
+	ClaimReasonBotrytis,
+	ClaimReasonBrokenHeads,
+	ClaimReasonShortLength,
+	ClaimReasonDehydration,
+	ClaimReasonWrongVariety,
+	ClaimReasonMissing,
+	ClaimReasonOther,
+}
+
+// IsValid проверяет код причины
+func (r ClaimReason) IsValid() bool {
+	for _, reason := range ClaimReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimStatus статус рекламации
+type ClaimStatus string
+
+const (
+	ClaimStatusOpen        ClaimStatus = "open"
+	ClaimStatusUnderReview ClaimStatus = "under_review"
+	ClaimStatusApproved    ClaimStatus = "approved"
+	ClaimStatusRejected    ClaimStatus = "rejected"
+	ClaimStatusClosed      ClaimStatus = "closed"
+)
+
+// claimTransitions допустимые переходы статусов рекламации
+var claimTransitions = map[ClaimStatus][]ClaimStatus{
+	ClaimStatusOpen:        {ClaimStatusUnderReview, ClaimStatusRejected},
+	ClaimStatusUnderReview: {ClaimStatusApproved, ClaimStatusRejected},
+	ClaimStatusApproved:    {ClaimStatusClosed},
+	ClaimStatusRejected:    {ClaimStatusClosed},
+}
+
+// Claim рекламация по позиции доставленного заказа
+type Claim struct {
+	ID       string      `json:"id"`
+	OrderID  string      `json:"order_id"`
+	ItemID   string      `json:"item_id"`
+	FarmName string      `json:"farm_name"`
+	Variety  string      `json:"variety"`
+	Length   int         `json:"length"`
+	Reason   ClaimReason `json:"reason"`
+	// ClaimedStems число стеблей, на которые заявлена рекламация
+	ClaimedStems int `json:"claimed_stems"`
+	// ApprovedStems число стеблей, признанных при одобрении
+	ApprovedStems int         `json:"approved_stems"`
+	Status        ClaimStatus `json:"status"`
+	// Currency валюта заказа; Credit - одобренная сумма компенсации в ней
+	Currency    string       `json:"currency"`
+	Credit      Money        `json:"credit"`
+	Description string       `json:"description,omitempty"`
+	Resolution  string       `json:"resolution,omitempty"`
+	Photos      []ClaimPhoto `json:"photos"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// ClaimPhoto фотография к рекламации, файл хранится на локальном диске
+type ClaimPhoto struct {
+	ID          string `json:"id"`
+	ClaimID     string `json:"claim_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	// StoragePath путь к файлу относительно каталога фотографий
+	StoragePath string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ClaimFilter фильтр списка рекламаций; пустые поля не учитываются
+type ClaimFilter struct {
+	OrderID  string
+	FarmName string
+	Status   ClaimStatus
+	// From и To ограничивают дату создания рекламации: [From, To)
+	From *time.Time
+	To   *time.Time
+	// OrderFrom и OrderTo ограничивают дату создания заказа рекламации: [OrderFrom, OrderTo)
+	OrderFrom *time.Time
+	OrderTo   *time.Time
+}
+
+// DeliveredStems число доставленных стеблей фермы и сорта
+type DeliveredStems struct {
+	FarmName string
+	Variety  string
+	Stems    int
+}
+
+// ClaimRepository хранилище рекламаций и их фотографий
+type ClaimRepository interface {
+	// CreateClaim сохраняет рекламацию, проверяя, что вместе с другими
+	// неотклоненными рекламациями по позиции она не превышает число стеблей
+	CreateClaim(ctx context.Context, claim *Claim) error
+	GetClaim(ctx context.Context, id string) (*Claim, error)
+	ListClaims(ctx context.Context, filter ClaimFilter) ([]Claim, error)
+	// UpdateClaim сохраняет статус и компенсацию, если статус в базе
+	// все еще равен previous; иначе возвращает ErrConflict
+	UpdateClaim(ctx context.Context, claim *Claim, previous ClaimStatus) error
+	AddClaimPhoto(ctx context.Context, photo *ClaimPhoto) error
+	GetClaimPhoto(ctx context.Context, claimID, photoID string) (*ClaimPhoto, error)
+	// ListDeliveredStems возвращает доставленные стебли по фермам и сортам
+	// для заказов, созданных в [from, to): позиции завершенных заказов
+	// и позиции доставленных заказов ферм
+	ListDeliveredStems(ctx context.Context, from, to *time.Time) ([]DeliveredStems, error)
+}
+
+// NewClaim создает рекламацию по позиции заказа. Рекламации принимаются
+// только по доставленным позициям: заказ завершен или заказ фермы позиции
+// из farmOrders в статусе delivered.
+func (o *Order) NewClaim(itemID string, reason ClaimReason, stems int, farmOrders []FarmOrder) (*Claim, error) {
+	if !reason.IsValid() {
+		return nil, fmt.Errorf("%w: unknown claim reason %q", ErrValidation, reason)
+	}
+
+	for _, item := range o.Items {
+		if item.ID != itemID {
+			continue
+		}
+		if !o.isItemDelivered(item, farmOrders) {
+			return nil, fmt.Errorf("%w: item %s of order %s is not delivered, claims are accepted for delivered items",
+				ErrValidation, item.ID, o.ID)
+		}
+		if stems <= 0 || stems > item.TotalStems {
+			return nil, fmt.Errorf("%w: claimed stems must be between 1 and %d", ErrValidation, item.TotalStems)
+		}
+		return &Claim{
+			OrderID:      o.ID,
+			ItemID:       item.ID,
+			FarmName:     item.FarmName,
+			Variety:      item.Variety,
+			Length:       item.Length,
+			Reason:       reason,
+			ClaimedStems: stems,
+			Status:       ClaimStatusOpen,
+			Currency:     o.Currency,
+			Photos:       []ClaimPhoto{},
+		}, nil
+	}
+	return nil, fmt.Errorf("item %s in order %s: %w", itemID, o.ID, ErrNotFound)
+}
+
+// isItemDelivered сообщает, доставлена ли позиция: заказ завершен
+// или доставлен заказ фермы позиции
+func (o *Order) isItemDelivered(item Item, farmOrders []FarmOrder) bool {
+	if o.Status == OrderStatusCompleted {
+		return true
+	}
+	for _, farmOrder := range farmOrders {
+		if farmOrder.OrderID == o.ID && farmOrder.FarmName == item.FarmName {
+			return farmOrder.Status == FarmOrderStatusDelivered
+		}
+	}
+	return false
+}
+
+// CheckClaimedStems проверяет, что стебли рекламации вместе с уже заявленными
+// по позиции не превышают число стеблей позиции
+func (c *Claim) CheckClaimedStems(itemStems, alreadyClaimed int) error {
+	if alreadyClaimed+c.ClaimedStems > itemStems {
+		return fmt.Errorf("%w: item %s has %d stems, %d are already claimed",
+			ErrConflict, c.ItemID, itemStems, alreadyClaimed)
+	}
+	return nil
+}
+
+// CanTransitionTo проверяет возможность перехода к новому статусу
+func (c *Claim) CanTransitionTo(status ClaimStatus) bool {
+	for _, allowed := range claimTransitions[c.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition переводит рекламацию в новый статус. При одобрении компенсация
+// считается по цене позиции (Item.Price) за одобренные стебли в валюте заказа
+// по зафиксированному курсу; approvedStems равное 0 означает все заявленные стебли.
+// При отклонении компенсация обнуляется.
+func (c *Claim) Transition(status ClaimStatus, approvedStems int, order *Order) error {
+	if !c.CanTransitionTo(status) {
+		return fmt.Errorf("%w: claim %s cannot move from %s to %s", ErrConflict, c.ID, c.Status, status)
+	}
+
+	switch status {
+	case ClaimStatusApproved:
+		if approvedStems == 0 {
+			approvedStems = c.ClaimedStems
+		}
+		if approvedStems < 0 || approvedStems > c.ClaimedStems {
+			return fmt.Errorf("%w: approved stems must be between 1 and %d", ErrValidation, c.ClaimedStems)
+		}
+		credit, err := c.computeCredit(approvedStems, order)
+		if err != nil {
+			return err
+		}
+		c.ApprovedStems = approvedStems
+		c.Credit = credit
+	case ClaimStatusRejected:
+		c.ApprovedStems = 0
+		c.Credit = Money{}
+	}
+
+	c.Status = status
+	return nil
+}
+
+func (c *Claim) computeCredit(stems int, order *Order) (Money, error) {
+	for _, item := range order.Items {
+		if item.ID == c.ItemID {
+			item.TotalStems = stems
+			return order.LineAmount(item)
+		}
+	}
+	return Money{}, fmt.Errorf("item %s in order %s: %w", c.ItemID, order.ID, ErrNotFound)
+}
+
+// ClaimRate доля рекламаций фермы по сорту
+type ClaimRate struct {
+	FarmName       string `json:"farm_name"`
+	Variety        string `json:"variety"`
+	DeliveredStems int    `json:"delivered_stems"`
+	Claims         int    `json:"claims"`
+	// ClaimedStems стебли неотклоненных рекламаций, RejectedStems - отклоненных
+	ClaimedStems  int `json:"claimed_stems"`
+	RejectedStems int `json:"rejected_stems"`
+	ApprovedStems int `json:"approved_stems"`
+	// ClaimRate доля заявленных стеблей от доставленных, ApprovedRate - одобренных
+	ClaimRate    float64 `json:"claim_rate"`
+	ApprovedRate float64 `json:"approved_rate"`
+	// Reasons стебли неотклоненных рекламаций по причинам
+	Reasons map[ClaimReason]int `json:"reasons"`
+	// Credit одобренная компенсация по валютам заказов
+	Credit map[string]Money `json:"credit"`
+}
+
+// ClaimRates сводит рекламации с доставленными стеблями по фермам и сортам.
+// Доставленные стебли и рекламации должны относиться к одним и тем же заказам.
+// Отклоненные рекламации учитываются отдельно (RejectedStems): после отклонения
+// по позиции можно заявить те же стебли снова, и доля не должна превышать 1.
+func ClaimRates(delivered []DeliveredStems, claims []Claim) []ClaimRate {
+	type key struct {
+		farm    string
+		variety string
+	}
+	rates := make(map[key]*ClaimRate)
+	get := func(farm, variety string) *ClaimRate {
+		k := key{farm, variety}
+		rate, ok := rates[k]
+		if !ok {
+			rate = &ClaimRate{
+				FarmName: farm,
+				Variety:  variety,
+				Reasons:  make(map[ClaimReason]int),
+				Credit:   make(map[string]Money),
+			}
+			rates[k] = rate
+		}
+		return rate
+	}
+
+	for _, d := range delivered {
+		get(d.FarmName, d.Variety).DeliveredStems += d.Stems
+	}
+	for _, claim := range claims {
+		rate := get(claim.FarmName, claim.Variety)
+		rate.Claims++
+		if claim.Status == ClaimStatusRejected {
+			rate.RejectedStems += claim.ClaimedStems
+			continue
+		}
+		rate.ClaimedStems += claim.ClaimedStems
+		rate.ApprovedStems += claim.ApprovedStems
+		rate.Reasons[claim.Reason] += claim.ClaimedStems
+		if !claim.Credit.IsZero() {
+			rate.Credit[claim.Currency] = rate.Credit[claim.Currency].Add(claim.Credit)
+		}
+	}
+
+	result := make([]ClaimRate, 0, len(rates))
+	for _, rate := range rates {
+		if rate.DeliveredStems > 0 {
+			rate.ClaimRate = roundRate(float64(rate.ClaimedStems) / float64(rate.DeliveredStems))
+			rate.ApprovedRate = roundRate(float64(rate.ApprovedStems) / float64(rate.DeliveredStems))
+		}
+		result = append(result, *rate)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FarmName != result[j].FarmName {
+			return result[i].FarmName < result[j].FarmName
+		}
+		return result[i].Variety < result[j].Variety
+	})
+	return result
+}
+
+func roundRate(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
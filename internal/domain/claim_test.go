@@ -0,0 +1,256 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func claimOrder(t *testing.T, status OrderStatus) *Order {
+	t.Helper()
+	return &Order{
+		ID:       "order",
+		Status:   status,
+		Currency: "EUR",
+		Items: []Item{
+			{ID: "rose", Variety: "Rose", Length: 50, FarmName: "Alpha", TotalStems: 200, Price: mustMoney(t, "0.45")},
+			{
+				ID: "tulip", Variety: "Tulip", Length: 40, FarmName: "Beta", TotalStems: 100,
+				Price: mustMoney(t, "0.30"), Currency: "USD",
+			},
+		},
+		ExchangeRates: []ExchangeRate{{FromCurrency: "USD", ToCurrency: "EUR", Rate: mustRate(t, "0.92")}},
+	}
+}
+
+func TestNewClaim(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     OrderStatus
+		farmOrders []FarmOrder
+		itemID     string
+		reason     ClaimReason
+		stems      int
+		wantErr    error
+	}{
+		{name: "completed order", status: OrderStatusCompleted, itemID: "rose", reason: ClaimReasonBotrytis, stems: 20},
+		{
+			name:       "delivered farm order",
+			status:     OrderStatusFarmOrder,
+			farmOrders: []FarmOrder{{OrderID: "order", FarmName: "Alpha", Status: FarmOrderStatusDelivered}},
+			itemID:     "rose",
+			reason:     ClaimReasonBrokenHeads,
+			stems:      200,
+		},
+		{
+			name:       "farm order of another farm is delivered",
+			status:     OrderStatusFarmOrder,
+			farmOrders: []FarmOrder{{OrderID: "order", FarmName: "Beta", Status: FarmOrderStatusDelivered}},
+			itemID:     "rose",
+			reason:     ClaimReasonBotrytis,
+			stems:      10,
+			wantErr:    ErrValidation,
+		},
+		{
+			name:       "farm order is only confirmed",
+			status:     OrderStatusFarmOrder,
+			farmOrders: []FarmOrder{{OrderID: "order", FarmName: "Alpha", Status: FarmOrderStatusConfirmed}},
+			itemID:     "rose",
+			reason:     ClaimReasonBotrytis,
+			stems:      10,
+			wantErr:    ErrValidation,
+		},
+		{name: "unknown reason", status: OrderStatusCompleted, itemID: "rose", reason: "smell", stems: 10, wantErr: ErrValidation},
+		{name: "no stems", status: OrderStatusCompleted, itemID: "rose", reason: ClaimReasonOther, stems: 0, wantErr: ErrValidation},
+		{
+			name: "more stems than delivered", status: OrderStatusCompleted, itemID: "rose",
+			reason: ClaimReasonMissing, stems: 201, wantErr: ErrValidation,
+		},
+		{name: "unknown item", status: OrderStatusCompleted, itemID: "lily", reason: ClaimReasonOther, stems: 1, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := claimOrder(t, tt.status)
+			claim, err := order.NewClaim(tt.itemID, tt.reason, tt.stems, tt.farmOrders)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewClaim error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClaim: %v", err)
+			}
+			if claim.Status != ClaimStatusOpen || claim.ClaimedStems != tt.stems || claim.Currency != "EUR" {
+				t.Errorf("claim = %+v", claim)
+			}
+			if claim.FarmName != "Alpha" || claim.Variety != "Rose" || claim.Length != 50 {
+				t.Errorf("claim copies item as %s %s %d", claim.FarmName, claim.Variety, claim.Length)
+			}
+		})
+	}
+}
+
+func TestClaimTransition(t *testing.T) {
+	tests := []struct {
+		name          string
+		itemID        string
+		from          ClaimStatus
+		to            ClaimStatus
+		approvedStems int
+		wantStems     int
+		wantCredit    string
+		wantErr       error
+	}{
+		{
+			name: "approve all claimed stems", itemID: "rose", from: ClaimStatusUnderReview, to: ClaimStatusApproved,
+			wantStems: 40, wantCredit: "18.00",
+		},
+		{
+			name: "approve part of the stems", itemID: "rose", from: ClaimStatusUnderReview, to: ClaimStatusApproved,
+			approvedStems: 10, wantStems: 10, wantCredit: "4.50",
+		},
+		{
+			// 40 * 0.30 USD = 12.00 USD по курсу 0.92
+			name: "credit is converted to the order currency", itemID: "tulip", from: ClaimStatusUnderReview,
+			to: ClaimStatusApproved, wantStems: 40, wantCredit: "11.04",
+		},
+		{
+			name: "approve more than claimed", itemID: "rose", from: ClaimStatusUnderReview, to: ClaimStatusApproved,
+			approvedStems: 41, wantErr: ErrValidation,
+		},
+		{
+			name: "approve open claim", itemID: "rose", from: ClaimStatusOpen, to: ClaimStatusApproved,
+			wantErr: ErrConflict,
+		},
+		{name: "reject open claim", itemID: "rose", from: ClaimStatusOpen, to: ClaimStatusRejected, wantCredit: "0.00"},
+		{name: "close rejected claim", itemID: "rose", from: ClaimStatusRejected, to: ClaimStatusClosed, wantCredit: "0.00"},
+		{name: "reopen closed claim", itemID: "rose", from: ClaimStatusClosed, to: ClaimStatusOpen, wantErr: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := claimOrder(t, OrderStatusCompleted)
+			claim := &Claim{ID: "claim", ItemID: tt.itemID, ClaimedStems: 40, Status: tt.from, Currency: "EUR"}
+
+			err := claim.Transition(tt.to, tt.approvedStems, order)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Transition error = %v, want %v", err, tt.wantErr)
+				}
+				if claim.Status != tt.from {
+					t.Errorf("status changed to %s on error", claim.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transition: %v", err)
+			}
+			if claim.Status != tt.to {
+				t.Errorf("status = %s, want %s", claim.Status, tt.to)
+			}
+			if claim.ApprovedStems != tt.wantStems {
+				t.Errorf("ApprovedStems = %d, want %d", claim.ApprovedStems, tt.wantStems)
+			}
+			if claim.Credit.String() != tt.wantCredit {
+				t.Errorf("Credit = %s, want %s", claim.Credit, tt.wantCredit)
+			}
+		})
+	}
+}
+
+func TestClaimTransitionUnknownItem(t *testing.T) {
+	order := claimOrder(t, OrderStatusCompleted)
+	claim := &Claim{ID: "claim", ItemID: "lily", ClaimedStems: 5, Status: ClaimStatusUnderReview}
+	if err := claim.Transition(ClaimStatusApproved, 0, order); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Transition error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClaimRates(t *testing.T) {
+	delivered := []DeliveredStems{
+		{FarmName: "Beta", Variety: "Tulip", Stems: 100},
+		{FarmName: "Alpha", Variety: "Rose", Stems: 150},
+		{FarmName: "Alpha", Variety: "Rose", Stems: 50},
+	}
+	claims := []Claim{
+		{
+			FarmName: "Alpha", Variety: "Rose", Reason: ClaimReasonBotrytis, ClaimedStems: 30, ApprovedStems: 20,
+			Status: ClaimStatusClosed, Currency: "EUR", Credit: mustMoney(t, "9.00"),
+		},
+		{FarmName: "Alpha", Variety: "Rose", Reason: ClaimReasonBotrytis, ClaimedStems: 10, Status: ClaimStatusOpen},
+		// отклоненная рекламация на все стебли: те же стебли можно заявить снова
+		{FarmName: "Beta", Variety: "Tulip", Reason: ClaimReasonMissing, ClaimedStems: 100, Status: ClaimStatusRejected},
+		{
+			FarmName: "Beta", Variety: "Tulip", Reason: ClaimReasonBrokenHeads, ClaimedStems: 100, ApprovedStems: 100,
+			Status: ClaimStatusApproved, Currency: "USD", Credit: mustMoney(t, "30.00"),
+		},
+		// рекламация без доставленных стеблей за период
+		{FarmName: "Gamma", Variety: "Lily", Reason: ClaimReasonOther, ClaimedStems: 5, Status: ClaimStatusOpen},
+	}
+
+	rates := ClaimRates(delivered, claims)
+	if len(rates) != 3 {
+		t.Fatalf("got %d rates, want 3", len(rates))
+	}
+
+	tests := []struct {
+		farm         string
+		delivered    int
+		claims       int
+		claimed      int
+		rejected     int
+		approved     int
+		claimRate    float64
+		approvedRate float64
+		reasons      map[ClaimReason]int
+		credit       map[string]string
+	}{
+		{
+			farm: "Alpha", delivered: 200, claims: 2, claimed: 40, approved: 20, claimRate: 0.2, approvedRate: 0.1,
+			reasons: map[ClaimReason]int{ClaimReasonBotrytis: 40}, credit: map[string]string{"EUR": "9.00"},
+		},
+		{
+			farm: "Beta", delivered: 100, claims: 2, claimed: 100, rejected: 100, approved: 100, claimRate: 1, approvedRate: 1,
+			reasons: map[ClaimReason]int{ClaimReasonBrokenHeads: 100}, credit: map[string]string{"USD": "30.00"},
+		},
+		{
+			farm: "Gamma", claims: 1, claimed: 5,
+			reasons: map[ClaimReason]int{ClaimReasonOther: 5}, credit: map[string]string{},
+		},
+	}
+
+	// результат отсортирован по ферме и сорту
+	for i, tt := range tests {
+		t.Run(tt.farm, func(t *testing.T) {
+			got := rates[i]
+			if got.FarmName != tt.farm {
+				t.Fatalf("rates[%d] is farm %s, want %s", i, got.FarmName, tt.farm)
+			}
+			if got.DeliveredStems != tt.delivered || got.Claims != tt.claims || got.ClaimedStems != tt.claimed ||
+				got.RejectedStems != tt.rejected || got.ApprovedStems != tt.approved {
+				t.Errorf("rate = %+v", got)
+			}
+			if got.ClaimRate != tt.claimRate || got.ApprovedRate != tt.approvedRate {
+				t.Errorf("ClaimRate = %v, ApprovedRate = %v, want %v and %v",
+					got.ClaimRate, got.ApprovedRate, tt.claimRate, tt.approvedRate)
+			}
+			if len(got.Reasons) != len(tt.reasons) {
+				t.Errorf("Reasons = %v, want %v", got.Reasons, tt.reasons)
+			}
+			for reason, stems := range tt.reasons {
+				if got.Reasons[reason] != stems {
+					t.Errorf("Reasons[%s] = %d, want %d", reason, got.Reasons[reason], stems)
+				}
+			}
+			if len(got.Credit) != len(tt.credit) {
+				t.Errorf("Credit = %v, want %v", got.Credit, tt.credit)
+			}
+			for currency, amount := range tt.credit {
+				if got.Credit[currency].String() != amount {
+					t.Errorf("Credit[%s] = %s, want %s", currency, got.Credit[currency], amount)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultCurrency валюта по умолчанию для цен и счетов
+const DefaultCurrency = "USD"
+
+// ExchangeRate курс: 1 единица FromCurrency = Rate единиц ToCurrency
+type ExchangeRate struct {
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         Rate      `json:"rate"`
+	EffectiveAt  time.Time `json:"effective_at"`
+}
+
+// Inverse возвращает обратный курс
+func (r ExchangeRate) Inverse() ExchangeRate {
+	return ExchangeRate{
+		FromCurrency: r.ToCurrency,
+		ToCurrency:   r.FromCurrency,
+		Rate:         r.Rate.Inverse(),
+		EffectiveAt:  r.EffectiveAt,
+	}
+}
+
+// IsValidCurrency проверяет формат кода валюты ISO 4217
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ExchangeRateRepository хранилище истории курсов валют
+type ExchangeRateRepository interface {
+	CreateExchangeRate(ctx context.Context, rate *ExchangeRate) error
+	ListExchangeRates(ctx context.Context, from, to string) ([]ExchangeRate, error)
+	// FindExchangeRate возвращает последний курс from->to, действовавший на момент at
+	FindExchangeRate(ctx context.Context, from, to string, at time.Time) (*ExchangeRate, error)
+}
+
+// rateFor возвращает курс пересчета из валюты позиции в валюту заказа
+// по сохраненным в заказе курсам
+func (o *Order) rateFor(currency string) (Rate, error) {
+	for _, rate := range o.ExchangeRates {
+		if rate.FromCurrency == currency && rate.ToCurrency == o.Currency {
+			return rate.Rate, nil
+		}
+	}
+	return Rate{}, fmt.Errorf("%w: no exchange rate %s->%s in order", ErrValidation, currency, o.Currency)
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExchangeRateInverse(t *testing.T) {
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rate := ExchangeRate{FromCurrency: "USD", ToCurrency: "EUR", Rate: mustRate(t, "0.92"), EffectiveAt: at}
+
+	got := rate.Inverse()
+	if got.FromCurrency != "EUR" || got.ToCurrency != "USD" {
+		t.Errorf("Inverse pair = %s->%s, want EUR->USD", got.FromCurrency, got.ToCurrency)
+	}
+	if got.Rate.String() != "1.08695652" {
+		t.Errorf("Inverse rate = %s, want 1.08695652", got.Rate)
+	}
+	if !got.EffectiveAt.Equal(at) {
+		t.Errorf("Inverse EffectiveAt = %v, want %v", got.EffectiveAt, at)
+	}
+}
+
+func TestIsValidCurrency(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{code: "USD", want: true},
+		{code: "EUR", want: true},
+		{code: "usd"},
+		{code: "US"},
+		{code: "USDT"},
+		{code: "U5D"},
+		{code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := IsValidCurrency(tt.code); got != tt.want {
+				t.Errorf("IsValidCurrency(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderRateFor(t *testing.T) {
+	order := &Order{
+		Currency: "EUR",
+		ExchangeRates: []ExchangeRate{
+			{FromCurrency: "EUR", ToCurrency: "USD", Rate: mustRate(t, "1.10")},
+			{FromCurrency: "USD", ToCurrency: "EUR", Rate: mustRate(t, "0.92")},
+		},
+	}
+
+	rate, err := order.rateFor("USD")
+	if err != nil {
+		t.Fatalf("rateFor(USD): %v", err)
+	}
+	if rate.String() != "0.92" {
+		t.Errorf("rateFor(USD) = %s, want 0.92", rate)
+	}
+
+	// курс в обратную сторону не подходит: снимок хранит курсы к валюте заказа
+	if _, err := order.rateFor("COP"); !errors.Is(err, ErrValidation) {
+		t.Errorf("rateFor(COP) error = %v, want ErrValidation", err)
+	}
+}
+
+func TestCalculateTotalInSourceAndBillingCurrency(t *testing.T) {
+	order := &Order{
+		Currency: "EUR",
+		Items: []Item{
+			{ID: "1", TotalStems: 100, Price: mustMoney(t, "0.50"), Currency: "USD"},
+			{ID: "2", TotalStems: 200, Price: mustMoney(t, "0.25"), Currency: "USD"},
+			{ID: "3", TotalStems: 50, Price: mustMoney(t, "0.40")},
+			{ID: "4", TotalStems: 1000, Price: mustMoney(t, "1000.00"), Currency: "COP"},
+		},
+		ExchangeRates: []ExchangeRate{
+			{FromCurrency: "USD", ToCurrency: "EUR", Rate: mustRate(t, "0.92")},
+			// обратный курс EUR->COP 4200, сохраненный в заказе как COP->EUR
+			{FromCurrency: "COP", ToCurrency: "EUR", Rate: mustRate(t, "4200").Inverse()},
+		},
+	}
+
+	total, err := order.CalculateTotal()
+	if err != nil {
+		t.Fatalf("CalculateTotal: %v", err)
+	}
+
+	// 46.00 + 46.00 + 20.00 + 1000000 COP * 0.00023810 = 238.10
+	if total.String() != "350.10" {
+		t.Errorf("total = %s, want 350.10", total)
+	}
+	if order.TotalAmount.String() != "350.10" {
+		t.Errorf("TotalAmount = %s, want 350.10", order.TotalAmount)
+	}
+
+	wantSource := map[string]string{"USD": "100.00", "EUR": "20.00", "COP": "1000000.00"}
+	if len(order.SourceTotals) != len(wantSource) {
+		t.Fatalf("SourceTotals = %v, want %v", order.SourceTotals, wantSource)
+	}
+	for currency, amount := range wantSource {
+		if got := order.SourceTotals[currency]; got.String() != amount {
+			t.Errorf("SourceTotals[%s] = %s, want %s", currency, got, amount)
+		}
+	}
+}
+
+func TestCalculateTotalWithoutRate(t *testing.T) {
+	order := &Order{
+		Currency: "EUR",
+		Items:    []Item{{ID: "1", TotalStems: 10, Price: mustMoney(t, "1.00"), Currency: "USD"}},
+	}
+	if _, err := order.CalculateTotal(); !errors.Is(err, ErrValidation) {
+		t.Fatalf("CalculateTotal error = %v, want ErrValidation", err)
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound запрошенная сущность не существует
+	ErrNotFound = errors.New("not found")
+	// ErrValidation входные данные нарушают бизнес-правила
+	ErrValidation = errors.New("validation error")
+)
+
+// ErrConflict изменение отклонено, так как сущность была изменена другим запросом
+var ErrConflict = errors.New("conflict")
+
+// VersionConflictError возвращается при обновлении устаревшей версии сущности
+type VersionConflictError struct {
+	Entity          string
+	ID              string
+	ExpectedVersion int
+	CurrentVersion  int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %s was modified concurrently: expected version %d, current version %d",
+		e.Entity, e.ID, e.ExpectedVersion, e.CurrentVersion)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrConflict)
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
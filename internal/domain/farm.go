@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	// база часовых поясов встроена в бинарник: в образе alpine ее нет
+	_ "time/tzdata"
+)
+
+// cutoffLayout формат времени отсечки заказов фермы
+const cutoffLayout = "15:04"
+
+// Farm ферма-поставщик с контактами и правилами приема заказов
+type Farm struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Country string `json:"country,omitempty"`
+	// TimeZone часовой пояс фермы (IANA), в котором задано время отсечки
+	TimeZone string `json:"time_zone"`
+	// CutoffTime время отсечки "ЧЧ:ММ": заказ на дату отправки принимается
+	// до этого времени за LeadTimeDays дней до отправки
+	CutoffTime   string `json:"cutoff_time"`
+	LeadTimeDays int    `json:"lead_time_days"`
+	// Active неактивную ферму нельзя указать в новых заказах
+	Active    bool      `json:"active"`
+	Notes     string    `json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FarmRepository реестр ферм
+type FarmRepository interface {
+	CreateFarm(ctx context.Context, farm *Farm) error
+	UpdateFarm(ctx context.Context, farm *Farm) error
+	GetFarm(ctx context.Context, id string) (*Farm, error)
+	GetFarmByName(ctx context.Context, name string) (*Farm, error)
+	ListFarms(ctx context.Context) ([]Farm, error)
+	// ListFarmItems возвращает позиции неотмененных заказов фермы
+	// с датой отправки в [from, to] включительно
+	ListFarmItems(ctx context.Context, farmID string, from, to *time.Time) ([]FarmExportItem, error)
+}
+
+// Validate проверяет часовой пояс, время отсечки и срок поставки
+func (f *Farm) Validate() error {
+	if _, err := time.LoadLocation(f.TimeZone); err != nil {
+		return fmt.Errorf("%w: unknown time zone %q", ErrValidation, f.TimeZone)
+	}
+	if _, err := time.Parse(cutoffLayout, f.CutoffTime); err != nil {
+		return fmt.Errorf("%w: cutoff time must be HH:MM, got %q", ErrValidation, f.CutoffTime)
+	}
+	if f.LeadTimeDays < 0 {
+		return fmt.Errorf("%w: lead time must not be negative", ErrValidation)
+	}
+	return nil
+}
+
+// OrderDeadline возвращает момент, до которого ферма принимает заказ
+// на дату отправки: время отсечки в часовом поясе фермы за LeadTimeDays дней
+func (f *Farm) OrderDeadline(shipDate time.Time) (time.Time, error) {
+	location, err := time.LoadLocation(f.TimeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown time zone %q", ErrValidation, f.TimeZone)
+	}
+	cutoff, err := time.Parse(cutoffLayout, f.CutoffTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: cutoff time must be HH:MM, got %q", ErrValidation, f.CutoffTime)
+	}
+	year, month, day := shipDate.Date()
+	return time.Date(year, month, day-f.LeadTimeDays, cutoff.Hour(), cutoff.Minute(), 0, 0, location), nil
+}
+
+// OrderProblem возвращает причину, по которой ферма не примет заказ на дату
+// отправки в момент now, или пустую строку. Без даты отправки проверяется
+// только активность фермы.
+func (f *Farm) OrderProblem(shipDate *time.Time, now time.Time) (string, error) {
+	if !f.Active {
+		return fmt.Sprintf("farm %s is inactive", f.Name), nil
+	}
+	if shipDate == nil {
+		return "", nil
+	}
+	deadline, err := f.OrderDeadline(*shipDate)
+	if err != nil {
+		return "", err
+	}
+	if !now.Before(deadline) {
+		return fmt.Sprintf("farm %s cutoff for ship date %s passed at %s",
+			f.Name, shipDate.Format("2006-01-02"), deadline.Format(time.RFC3339)), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFarmOrderDeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		farm     Farm
+		shipDate string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "same day in Bogota",
+			farm:     Farm{TimeZone: "America/Bogota", CutoffTime: "14:00"},
+			shipDate: "2024-03-10",
+			want:     "2024-03-10T19:00:00Z",
+		},
+		{
+			name:     "lead time in Bogota",
+			farm:     Farm{TimeZone: "America/Bogota", CutoffTime: "14:00", LeadTimeDays: 2},
+			shipDate: "2024-03-10",
+			want:     "2024-03-08T19:00:00Z",
+		},
+		{
+			// отсечка до перехода на летнее время, отправка после него
+			name:     "lead time across daylight saving change",
+			farm:     Farm{TimeZone: "Europe/Amsterdam", CutoffTime: "10:30", LeadTimeDays: 3},
+			shipDate: "2024-04-01",
+			want:     "2024-03-29T09:30:00Z",
+		},
+		{
+			name:     "lead time across month end",
+			farm:     Farm{TimeZone: "Africa/Nairobi", CutoffTime: "23:59", LeadTimeDays: 1},
+			shipDate: "2024-03-01",
+			want:     "2024-02-29T20:59:00Z",
+		},
+		{name: "unknown time zone", farm: Farm{TimeZone: "Mars/Olympus", CutoffTime: "10:00"}, shipDate: "2024-03-10", wantErr: true},
+		{name: "invalid cutoff", farm: Farm{TimeZone: "UTC", CutoffTime: "25:00"}, shipDate: "2024-03-10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline, err := tt.farm.OrderDeadline(date(tt.shipDate))
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("OrderDeadline error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OrderDeadline: %v", err)
+			}
+			if got := deadline.UTC().Format(time.RFC3339); got != tt.want {
+				t.Errorf("OrderDeadline = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFarmOrderProblem(t *testing.T) {
+	// отсечка 14:00 в Боготе (UTC-5) за 2 дня до отправки: 8 марта 19:00 UTC
+	farm := Farm{Name: "Alpha", TimeZone: "America/Bogota", CutoffTime: "14:00", LeadTimeDays: 2, Active: true}
+	deadline := time.Date(2024, 3, 8, 19, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		inactive    bool
+		shipDate    *time.Time
+		now         time.Time
+		wantProblem string
+	}{
+		{name: "before the deadline", shipDate: datePtr("2024-03-10"), now: deadline.Add(-time.Minute)},
+		{
+			name:        "at the deadline",
+			shipDate:    datePtr("2024-03-10"),
+			now:         deadline,
+			wantProblem: "farm Alpha cutoff for ship date 2024-03-10 passed at 2024-03-08T14:00:00-05:00",
+		},
+		{
+			name:        "after the deadline",
+			shipDate:    datePtr("2024-03-10"),
+			now:         deadline.Add(time.Hour),
+			wantProblem: "cutoff for ship date 2024-03-10 passed",
+		},
+		{
+			// в UTC уже 9 марта, но отсечка считается по времени фермы
+			name:     "next day in UTC is still before the deadline for a later ship date",
+			shipDate: datePtr("2024-03-11"),
+			now:      time.Date(2024, 3, 9, 3, 0, 0, 0, time.UTC),
+		},
+		{name: "no ship date", now: deadline.Add(time.Hour)},
+		{
+			name:        "inactive farm",
+			inactive:    true,
+			shipDate:    datePtr("2024-03-10"),
+			now:         deadline.Add(-time.Hour),
+			wantProblem: "farm Alpha is inactive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := farm
+			f.Active = !tt.inactive
+			problem, err := f.OrderProblem(tt.shipDate, tt.now)
+			if err != nil {
+				t.Fatalf("OrderProblem: %v", err)
+			}
+			if tt.wantProblem == "" {
+				if problem != "" {
+					t.Errorf("OrderProblem = %q, want none", problem)
+				}
+				return
+			}
+			if !strings.Contains(problem, tt.wantProblem) {
+				t.Errorf("OrderProblem = %q, want %q", problem, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestFarmValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		farm    Farm
+		wantErr bool
+	}{
+		{name: "valid", farm: Farm{TimeZone: "America/Bogota", CutoffTime: "09:30", LeadTimeDays: 1}},
+		{name: "unknown time zone", farm: Farm{TimeZone: "Bogota", CutoffTime: "09:30"}, wantErr: true},
+		{name: "cutoff without minutes", farm: Farm{TimeZone: "UTC", CutoffTime: "9"}, wantErr: true},
+		{name: "negative lead time", farm: Farm{TimeZone: "UTC", CutoffTime: "09:30", LeadTimeDays: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.farm.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrValidation) {
+				t.Errorf("Validate error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
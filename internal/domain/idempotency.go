@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord сохраненный результат запроса с ключом идемпотентности
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	// StatusCode равен 0, пока исходный запрос еще обрабатывается
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	// ExpiresAt для незавершенной записи - конец аренды ключа, после которого
+	// повтор может занять ключ заново; для завершенной - конец срока хранения ответа
+	ExpiresAt time.Time
+}
+
+// Completed сообщает, сохранен ли уже ответ на исходный запрос
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// IdempotencyStore хранит ключи идемпотентности и ответы на запросы
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey атомарно создает запись для нового ключа. Если действующая
+	// запись с таким ключом уже есть, она возвращается без изменений.
+	ReserveIdempotencyKey(ctx context.Context, record IdempotencyRecord) (existing *IdempotencyRecord, err error)
+	// CompleteIdempotencyKey сохраняет ответ на исходный запрос до expiresAt
+	CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte, expiresAt time.Time) error
+	// ReleaseIdempotencyKey удаляет незавершенную запись, чтобы запрос можно было повторить
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	// DeleteExpiredIdempotencyKeys удаляет записи с истекшим сроком действия
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// InvoiceType тип бухгалтерского документа
+type InvoiceType string
+
+const (
+	InvoiceTypeInvoice    InvoiceType = "invoice"
+	InvoiceTypeCreditNote InvoiceType = "credit_note"
+)
+
+// Series возвращает префикс нумерации документов: у счетов и кредит-нот
+// отдельные последовательности номеров в пределах года
+func (t InvoiceType) Series() string {
+	if t == InvoiceTypeCreditNote {
+		return "CN"
+	}
+	return "INV"
+}
+
+// FormatInvoiceNumber формирует номер документа вида INV-2025-000042
+func FormatInvoiceNumber(invoiceType InvoiceType, year, sequence int) string {
+	return fmt.Sprintf("%s-%d-%06d", invoiceType.Series(), year, sequence)
+}
+
+// InvoiceLine строка счета. Суммы указаны в валюте счета.
+type InvoiceLine struct {
+	Position    int    `json:"position"`
+	Description string `json:"description"`
+	ItemID      string `json:"item_id,omitempty"`
+	Variety     string `json:"variety,omitempty"`
+	Length      int    `json:"length,omitempty"`
+	FarmName    string `json:"farm_name,omitempty"`
+	Stems       int    `json:"stems,omitempty"`
+	// UnitPrice цена стебля в валюте PriceCurrency
+	UnitPrice     Money  `json:"unit_price"`
+	PriceCurrency string `json:"price_currency,omitempty"`
+	Amount        Money  `json:"amount"`
+	// TaxRate ставка налога в процентах
+	TaxRate   Rate  `json:"tax_rate"`
+	TaxAmount Money `json:"tax_amount"`
+	Total     Money `json:"total"`
+}
+
+// Invoice счет или кредит-нота по заказу. Выставленный документ не изменяется:
+// исправления оформляются кредит-нотой и новым счетом.
+type Invoice struct {
+	ID         string      `json:"id"`
+	Number     string      `json:"number"`
+	Type       InvoiceType `json:"type"`
+	Year       int         `json:"year"`
+	Sequence   int         `json:"sequence"`
+	OrderID    string      `json:"order_id"`
+	CustomerID string      `json:"customer_id"`
+	// CustomerName и BillingAddress копируются из карточки клиента на момент выставления
+	CustomerName   string  `json:"customer_name,omitempty"`
+	BillingAddress Address `json:"billing_address"`
+	VATID          string  `json:"vat_id,omitempty"`
+	MarkBox        string  `json:"mark_box"`
+	// Consignee получатель груза по коду маркировки на момент выставления
+	Consignee *Consignee `json:"consignee,omitempty"`
+	Currency  string     `json:"currency"`
+	// ReverseCharge налог уплачивает покупатель (B2B внутри ЕС)
+	ReverseCharge bool `json:"reverse_charge"`
+	// CreditedInvoiceID счет, который сторнирует кредит-нота
+	CreditedInvoiceID string        `json:"credited_invoice_id,omitempty"`
+	Reason            string        `json:"reason,omitempty"`
+	Lines             []InvoiceLine `json:"lines"`
+	Subtotal          Money         `json:"subtotal"`
+	TaxTotal          Money         `json:"tax_total"`
+	Total             Money         `json:"total"`
+	IssuedAt          time.Time     `json:"issued_at"`
+}
+
+// NewInvoice формирует счет по выполненному заказу: позиции заказа
+// и его корректировки становятся строками счета
+func NewInvoice(order *Order, customer *Customer, issuedAt time.Time) (*Invoice, error) {
+	if order.Status != OrderStatusCompleted {
+		return nil, fmt.Errorf("%w: order %s is %s, only completed orders can be invoiced",
+			ErrValidation, order.ID, order.Status)
+	}
+	if _, err := order.CalculateTotal(); err != nil {
+		return nil, err
+	}
+
+	invoice := &Invoice{
+		Type:          InvoiceTypeInvoice,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		MarkBox:       order.MarkBox,
+		Currency:      order.Currency,
+		ReverseCharge: order.ReverseCharge,
+		IssuedAt:      issuedAt,
+		Year:          issuedAt.Year(),
+	}
+	if customer != nil {
+		invoice.CustomerName = customer.Name
+		invoice.BillingAddress = customer.Address
+		invoice.VATID = customer.VATID
+	}
+
+	for _, item := range order.Items {
+		amount, err := order.LineAmount(item)
+		if err != nil {
+			return nil, err
+		}
+		invoice.addLine(InvoiceLine{
+			Description:   fmt.Sprintf("%s %dcm (%s)", item.Variety, item.Length, item.FarmName),
+			ItemID:        item.ID,
+			Variety:       item.Variety,
+			Length:        item.Length,
+			FarmName:      item.FarmName,
+			Stems:         item.TotalStems,
+			UnitPrice:     item.Price,
+			PriceCurrency: order.itemCurrency(item),
+			Amount:        amount,
+			TaxRate:       item.TaxRate,
+			TaxAmount:     item.TaxAmount,
+		})
+	}
+
+	for _, adj := range order.Adjustments {
+		description := adj.Description
+		if description == "" {
+			description = string(adj.Kind)
+		}
+		invoice.addLine(InvoiceLine{
+			Description: description,
+			ItemID:      adj.ItemID,
+			Amount:      adj.Amount,
+			TaxRate:     adj.TaxRate,
+			TaxAmount:   adj.TaxAmount,
+		})
+	}
+
+	invoice.calculateTotals()
+	return invoice, nil
+}
+
+// CreditNote формирует кредит-ноту, полностью сторнирующую счет
+func (inv *Invoice) CreditNote(reason string, issuedAt time.Time) (*Invoice, error) {
+	if inv.Type != InvoiceTypeInvoice {
+		return nil, fmt.Errorf("%w: only invoices can be credited", ErrValidation)
+	}
+
+	note := &Invoice{
+		Type:              InvoiceTypeCreditNote,
+		OrderID:           inv.OrderID,
+		CustomerID:        inv.CustomerID,
+		CustomerName:      inv.CustomerName,
+		BillingAddress:    inv.BillingAddress,
+		VATID:             inv.VATID,
+		MarkBox:           inv.MarkBox,
+		Consignee:         inv.Consignee,
+		Currency:          inv.Currency,
+		ReverseCharge:     inv.ReverseCharge,
+		CreditedInvoiceID: inv.ID,
+		Reason:            reason,
+		IssuedAt:          issuedAt,
+		Year:              issuedAt.Year(),
+	}
+	for _, line := range inv.Lines {
+		line.Amount = line.Amount.Neg()
+		line.TaxAmount = line.TaxAmount.Neg()
+		note.addLine(line)
+	}
+
+	note.calculateTotals()
+	return note, nil
+}
+
+func (inv *Invoice) addLine(line InvoiceLine) {
+	line.Position = len(inv.Lines) + 1
+	line.Total = line.Amount.Add(line.TaxAmount)
+	inv.Lines = append(inv.Lines, line)
+}
+
+func (inv *Invoice) calculateTotals() {
+	inv.Subtotal, inv.TaxTotal, inv.Total = Money{}, Money{}, Money{}
+	for _, line := range inv.Lines {
+		inv.Subtotal = inv.Subtotal.Add(line.Amount)
+		inv.TaxTotal = inv.TaxTotal.Add(line.TaxAmount)
+		inv.Total = inv.Total.Add(line.Total)
+	}
+}
+
+// InvoiceRepository хранилище счетов. Документы только добавляются.
+type InvoiceRepository interface {
+	// CreateInvoice присваивает документу следующий номер в серии его года и сохраняет его.
+	// Для счета возвращает ошибку ErrConflict, если у заказа уже есть
+	// действующий (не сторнированный) счет; для кредит-ноты - если счет уже сторнирован.
+	CreateInvoice(ctx context.Context, invoice *Invoice) error
+	GetInvoice(ctx context.Context, id string) (*Invoice, error)
+	// ListOrderInvoices возвращает документы заказа, новые первыми
+	ListOrderInvoices(ctx context.Context, orderID string) ([]Invoice, error)
+}
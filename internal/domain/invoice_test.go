@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func invoicedOrder(t *testing.T, status OrderStatus) *Order {
+	t.Helper()
+	return &Order{
+		ID:         "order",
+		CustomerID: "customer",
+		MarkBox:    "MB1",
+		Status:     status,
+		Currency:   "EUR",
+		Items: []Item{
+			{
+				ID: "rose", Variety: "Rose", Length: 50, FarmName: "Alpha", TotalStems: 200,
+				Price: mustMoney(t, "0.50"), TaxRate: mustRate(t, "7"),
+			},
+			{
+				ID: "tulip", Variety: "Tulip", Length: 40, FarmName: "Beta", TotalStems: 100,
+				Price: mustMoney(t, "0.40"), Currency: "USD", TaxRate: mustRate(t, "7"),
+			},
+		},
+		Adjustments: []Adjustment{
+			{
+				Kind: AdjustmentFreight, Method: AdjustmentFixed, Value: mustMoney(t, "15.00"),
+				Description: "Freight North", TaxRate: mustRate(t, "19"),
+			},
+			{
+				ItemID: "rose", Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "10"),
+				TaxRate: mustRate(t, "7"),
+			},
+		},
+		ExchangeRates: []ExchangeRate{{FromCurrency: "USD", ToCurrency: "EUR", Rate: mustRate(t, "0.92")}},
+	}
+}
+
+func TestNewInvoice(t *testing.T) {
+	issuedAt := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	customer := &Customer{Name: "Blumen GmbH", VATID: "DE123456789", Address: Address{City: "Berlin", Country: "DE"}}
+
+	invoice, err := NewInvoice(invoicedOrder(t, OrderStatusCompleted), customer, issuedAt)
+	if err != nil {
+		t.Fatalf("NewInvoice: %v", err)
+	}
+
+	if invoice.Type != InvoiceTypeInvoice || invoice.Year != 2025 || invoice.Currency != "EUR" {
+		t.Errorf("invoice = %s %d %s", invoice.Type, invoice.Year, invoice.Currency)
+	}
+	if invoice.CustomerName != customer.Name || invoice.VATID != customer.VATID || invoice.BillingAddress.City != "Berlin" {
+		t.Errorf("customer is not copied: %+v", invoice)
+	}
+
+	tests := []struct {
+		description   string
+		stems         int
+		priceCurrency string
+		amount        string
+		tax           string
+		total         string
+	}{
+		{description: "Rose 50cm (Alpha)", stems: 200, priceCurrency: "EUR", amount: "100.00", tax: "7.00", total: "107.00"},
+		// 100 * 0.40 USD по курсу 0.92
+		{description: "Tulip 40cm (Beta)", stems: 100, priceCurrency: "USD", amount: "36.80", tax: "2.58", total: "39.38"},
+		{description: "Freight North", amount: "15.00", tax: "2.85", total: "17.85"},
+		// корректировка без описания называется по виду
+		{description: "discount", amount: "-10.00", tax: "-0.70", total: "-10.70"},
+	}
+	if len(invoice.Lines) != len(tests) {
+		t.Fatalf("got %d lines, want %d", len(invoice.Lines), len(tests))
+	}
+	for i, tt := range tests {
+		line := invoice.Lines[i]
+		if line.Position != i+1 {
+			t.Errorf("line %d position = %d", i, line.Position)
+		}
+		if line.Description != tt.description || line.Stems != tt.stems || line.PriceCurrency != tt.priceCurrency {
+			t.Errorf("line %d = %q %d %q, want %q %d %q", i, line.Description, line.Stems, line.PriceCurrency,
+				tt.description, tt.stems, tt.priceCurrency)
+		}
+		if line.Amount.String() != tt.amount || line.TaxAmount.String() != tt.tax || line.Total.String() != tt.total {
+			t.Errorf("line %d amounts = %s + %s = %s, want %s + %s = %s", i,
+				line.Amount, line.TaxAmount, line.Total, tt.amount, tt.tax, tt.total)
+		}
+	}
+
+	if invoice.Subtotal.String() != "141.80" || invoice.TaxTotal.String() != "11.73" || invoice.Total.String() != "153.53" {
+		t.Errorf("totals = %s + %s = %s, want 141.80 + 11.73 = 153.53", invoice.Subtotal, invoice.TaxTotal, invoice.Total)
+	}
+}
+
+func TestNewInvoiceRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		order func(t *testing.T) *Order
+	}{
+		{name: "order is not completed", order: func(t *testing.T) *Order { return invoicedOrder(t, OrderStatusFarmOrder) }},
+		{
+			name: "exchange rate is missing",
+			order: func(t *testing.T) *Order {
+				order := invoicedOrder(t, OrderStatusCompleted)
+				order.ExchangeRates = nil
+				return order
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewInvoice(tt.order(t), nil, time.Now()); !errors.Is(err, ErrValidation) {
+				t.Fatalf("NewInvoice error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestInvoiceCreditNote(t *testing.T) {
+	invoice, err := NewInvoice(invoicedOrder(t, OrderStatusCompleted), nil, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("NewInvoice: %v", err)
+	}
+	invoice.ID = "inv-1"
+	invoice.ReverseCharge = true
+
+	issuedAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	note, err := invoice.CreditNote("wrong price", issuedAt)
+	if err != nil {
+		t.Fatalf("CreditNote: %v", err)
+	}
+
+	if note.Type != InvoiceTypeCreditNote || note.CreditedInvoiceID != "inv-1" || note.Reason != "wrong price" {
+		t.Errorf("credit note = %s for %s (%q)", note.Type, note.CreditedInvoiceID, note.Reason)
+	}
+	// номер кредит-ноты относится к году ее выставления
+	if note.Year != 2025 || !note.ReverseCharge || note.Currency != invoice.Currency {
+		t.Errorf("credit note year %d, reverse charge %v, currency %s", note.Year, note.ReverseCharge, note.Currency)
+	}
+	if len(note.Lines) != len(invoice.Lines) {
+		t.Fatalf("credit note has %d lines, want %d", len(note.Lines), len(invoice.Lines))
+	}
+	for i, line := range note.Lines {
+		original := invoice.Lines[i]
+		if line.Amount.Add(original.Amount).String() != "0.00" ||
+			line.TaxAmount.Add(original.TaxAmount).String() != "0.00" ||
+			line.Total.Add(original.Total).String() != "0.00" {
+			t.Errorf("line %d = %s/%s/%s does not reverse %s/%s/%s", i,
+				line.Amount, line.TaxAmount, line.Total, original.Amount, original.TaxAmount, original.Total)
+		}
+		if line.Stems != original.Stems || line.UnitPrice.String() != original.UnitPrice.String() {
+			t.Errorf("line %d changes stems or unit price", i)
+		}
+	}
+	if note.Total.Add(invoice.Total).String() != "0.00" || note.Subtotal.Add(invoice.Subtotal).String() != "0.00" {
+		t.Errorf("credit note total %s does not reverse %s", note.Total, invoice.Total)
+	}
+
+	if _, err := note.CreditNote("again", issuedAt); !errors.Is(err, ErrValidation) {
+		t.Errorf("crediting a credit note error = %v, want ErrValidation", err)
+	}
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	if got := FormatInvoiceNumber(InvoiceTypeInvoice, 2025, 42); got != "INV-2025-000042" {
+		t.Errorf("invoice number = %s", got)
+	}
+	if got := FormatInvoiceNumber(InvoiceTypeCreditNote, 2025, 7); got != "CN-2025-000007" {
+		t.Errorf("credit note number = %s", got)
+	}
+}
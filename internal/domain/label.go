@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// boxCodePrefixLength число шестнадцатеричных символов ID позиции в коде коробки
+const boxCodePrefixLength = 10
+
+// BoxLabel этикетка одной коробки позиции заказа
+type BoxLabel struct {
+	// Code код штрихкода: префикс ID позиции и номер коробки
+	Code     string `json:"code"`
+	OrderID  string `json:"order_id"`
+	ItemID   string `json:"item_id"`
+	MarkBox  string `json:"mark_box"`
+	Variety  string `json:"variety"`
+	Length   int    `json:"length"`
+	FarmName string `json:"farm_name"`
+	Stems    int    `json:"stems"`
+	// Box номер коробки позиции, Boxes - число коробок позиции
+	Box   int `json:"box"`
+	Boxes int `json:"boxes"`
+	// Size доля полной коробки; меньше 1 только у последней коробки дробного BoxCount
+	Size float64 `json:"size"`
+}
+
+// BoxLabelCode возвращает код коробки: первые символы ID позиции без дефисов
+// и номер коробки, например 3F2A9C1B4D-007
+func BoxLabelCode(itemID string, box int) string {
+	prefix := strings.ToUpper(strings.ReplaceAll(itemID, "-", ""))
+	if len(prefix) > boxCodePrefixLength {
+		prefix = prefix[:boxCodePrefixLength]
+	}
+	return fmt.Sprintf("%s-%03d", prefix, box)
+}
+
+// ParseBoxLabelCode разбирает код коробки на префикс ID позиции (в нижнем регистре,
+// без дефисов) и номер коробки
+func ParseBoxLabelCode(code string) (string, int, error) {
+	prefix, number, ok := strings.Cut(strings.TrimSpace(code), "-")
+	if !ok || len(prefix) != boxCodePrefixLength {
+		return "", 0, fmt.Errorf("%w: invalid box code %q", ErrValidation, code)
+	}
+	if _, err := strconv.ParseUint(prefix, 16, 64); err != nil {
+		return "", 0, fmt.Errorf("%w: invalid box code %q", ErrValidation, code)
+	}
+	box, err := strconv.Atoi(number)
+	if err != nil || box < 1 {
+		return "", 0, fmt.Errorf("%w: invalid box code %q", ErrValidation, code)
+	}
+	return strings.ToLower(prefix), box, nil
+}
+
+// Boxes возвращает число физических коробок позиции: дробный остаток
+// занимает отдельную коробку
+func (i *Item) Boxes() int {
+	return int(math.Ceil(i.BoxCount - 1e-6))
+}
+
+// BoxLabels разворачивает позиции заказа в этикетки по одной на коробку позиции:
+// ферма упаковывает каждую позицию отдельно, и приемка сверяет коды коробок
+// с Item.Boxes(). Дробные остатки разных позиций получают отдельные этикетки,
+// даже если план упаковки (packing.Pack) объединяет их в смешанную коробку.
+// Стебли распределяются по коробкам пропорционально их размеру.
+func (o *Order) BoxLabels() []BoxLabel {
+	var labels []BoxLabel
+	for _, item := range o.Items {
+		boxes := item.Boxes()
+		remaining := item.TotalStems
+		for box := 1; box <= boxes; box++ {
+			size := 1.0
+			if box == boxes {
+				size = item.BoxCount - float64(boxes-1)
+			}
+			stems := remaining
+			if box < boxes {
+				stems = int(math.Round(float64(item.TotalStems) * size / item.BoxCount))
+				if stems > remaining {
+					stems = remaining
+				}
+			}
+			remaining -= stems
+
+			labels = append(labels, BoxLabel{
+				Code:     BoxLabelCode(item.ID, box),
+				OrderID:  o.ID,
+				ItemID:   item.ID,
+				MarkBox:  o.MarkBox,
+				Variety:  item.Variety,
+				Length:   item.Length,
+				FarmName: item.FarmName,
+				Stems:    stems,
+				Box:      box,
+				Boxes:    boxes,
+				Size:     math.Round(size*100) / 100,
+			})
+		}
+	}
+	return labels
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBoxLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []Item
+		wantCodes []string
+		wantStems []int
+		wantSizes []float64
+	}{
+		{
+			name:      "fractional box count adds a partial box",
+			items:     []Item{{ID: "3f2a9c1b-4d00-4000-8000-000000000001", BoxCount: 2.5, TotalStems: 250}},
+			wantCodes: []string{"3F2A9C1B4D-001", "3F2A9C1B4D-002", "3F2A9C1B4D-003"},
+			wantStems: []int{100, 100, 50},
+			wantSizes: []float64{1, 1, 0.5},
+		},
+		{
+			name:      "uneven stems go to the last box",
+			items:     []Item{{ID: "aaaaaaaaaa", BoxCount: 3, TotalStems: 100}},
+			wantCodes: []string{"AAAAAAAAAA-001", "AAAAAAAAAA-002", "AAAAAAAAAA-003"},
+			wantStems: []int{33, 33, 34},
+			wantSizes: []float64{1, 1, 1},
+		},
+		{
+			// packing.Pack кладет эти остатки в одну смешанную коробку,
+			// но этикетки строятся по позициям
+			name: "remainders of different lines get separate labels",
+			items: []Item{
+				{ID: "aaaaaaaaaa", BoxCount: 1.25, TotalStems: 250},
+				{ID: "bbbbbbbbbb", BoxCount: 0.25, TotalStems: 50},
+			},
+			wantCodes: []string{"AAAAAAAAAA-001", "AAAAAAAAAA-002", "BBBBBBBBBB-001"},
+			wantStems: []int{200, 50, 50},
+			wantSizes: []float64{1, 0.25, 0.25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{ID: "order", MarkBox: "MB1", Items: tt.items}
+			labels := order.BoxLabels()
+
+			codes := make([]string, len(labels))
+			stems := make([]int, len(labels))
+			sizes := make([]float64, len(labels))
+			for i, label := range labels {
+				codes[i], stems[i], sizes[i] = label.Code, label.Stems, label.Size
+				if label.OrderID != order.ID || label.MarkBox != order.MarkBox {
+					t.Errorf("label %s has order %s and mark box %s", label.Code, label.OrderID, label.MarkBox)
+				}
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.wantCodes, ",") {
+				t.Errorf("codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if fmt.Sprint(stems) != fmt.Sprint(tt.wantStems) {
+				t.Errorf("stems = %v, want %v", stems, tt.wantStems)
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.wantSizes) {
+				t.Errorf("sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+
+			// число этикеток позиции совпадает с числом коробок, которые ждет приемка
+			for _, item := range tt.items {
+				var count int
+				for _, label := range labels {
+					if label.ItemID == item.ID {
+						count++
+						if label.Boxes != item.Boxes() {
+							t.Errorf("label %s has Boxes = %d, want %d", label.Code, label.Boxes, item.Boxes())
+						}
+					}
+				}
+				if count != item.Boxes() {
+					t.Errorf("item %s has %d labels, want %d", item.ID, count, item.Boxes())
+				}
+			}
+		})
+	}
+}
+
+func TestParseBoxLabelCode(t *testing.T) {
+	tests := []struct {
+		code       string
+		wantPrefix string
+		wantBox    int
+		wantErr    bool
+	}{
+		{code: "3F2A9C1B4D-007", wantPrefix: "3f2a9c1b4d", wantBox: 7},
+		{code: " 3f2a9c1b4d-1 ", wantPrefix: "3f2a9c1b4d", wantBox: 1},
+		{code: "3F2A9C1B4D-000", wantErr: true},
+		{code: "3F2A9C1B4D", wantErr: true},
+		{code: "3F2A9C-001", wantErr: true},
+		{code: "ZZZZZZZZZZ-001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			prefix, box, err := ParseBoxLabelCode(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBoxLabelCode(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+			if prefix != tt.wantPrefix || box != tt.wantBox {
+				t.Errorf("ParseBoxLabelCode(%q) = %s, %d, want %s, %d", tt.code, prefix, box, tt.wantPrefix, tt.wantBox)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Consignee получатель груза, указанный на маркировке коробок
+type Consignee struct {
+	Name    string  `json:"name"`
+	Address Address `json:"address,omitempty"`
+	Phone   string  `json:"phone,omitempty"`
+	// DestinationAirport IATA-код аэропорта назначения
+	DestinationAirport string `json:"destination_airport,omitempty"`
+}
+
+// MarkBox код маркировки коробок, связывающий клиента с получателем груза
+type MarkBox struct {
+	Code string `json:"code"`
+	// CustomerID клиент, которому разрешен код; пустой - код общий для всех клиентов
+	CustomerID string    `json:"customer_id,omitempty"`
+	Consignee  Consignee `json:"consignee"`
+	Notes      string    `json:"notes,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AllowedFor сообщает, может ли клиент использовать код в заказах
+func (m *MarkBox) AllowedFor(customerID string) bool {
+	return m.Active && (m.CustomerID == "" || m.CustomerID == customerID)
+}
+
+// MarkBoxRepository реестр кодов маркировки
+type MarkBoxRepository interface {
+	SaveMarkBox(ctx context.Context, markBox *MarkBox) error
+	GetMarkBox(ctx context.Context, code string) (*MarkBox, error)
+	// ListMarkBoxes возвращает коды клиента вместе с общими; пустой клиент - все коды
+	ListMarkBoxes(ctx context.Context, customerID string) ([]MarkBox, error)
+	DeleteMarkBox(ctx context.Context, code string) error
+}
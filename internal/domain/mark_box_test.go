@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestMarkBoxAllowedFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		markBox    MarkBox
+		customerID string
+		want       bool
+	}{
+		{name: "own code", markBox: MarkBox{CustomerID: "acme", Active: true}, customerID: "acme", want: true},
+		{name: "other customer's code", markBox: MarkBox{CustomerID: "acme", Active: true}, customerID: "globex", want: false},
+		{name: "shared code", markBox: MarkBox{Active: true}, customerID: "globex", want: true},
+		{name: "inactive own code", markBox: MarkBox{CustomerID: "acme"}, customerID: "acme", want: false},
+		{name: "inactive shared code", markBox: MarkBox{}, customerID: "acme", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.markBox.AllowedFor(tt.customerID); got != tt.want {
+				t.Errorf("AllowedFor(%q) = %v, want %v", tt.customerID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// MoneyScale число знаков после запятой в денежных суммах, как в NUMERIC(10, 2)
+	MoneyScale = 2
+	// RateScale число знаков после запятой в курсах валют, как в NUMERIC(18, 8)
+	RateScale = 8
+)
+
+// Money денежная сумма с фиксированной точкой и двумя знаками после запятой.
+//
+// Правила округления:
+//   - суммы из запросов с большим числом знаков отклоняются, а не округляются;
+//   - цена позиции, умноженная на количество стеблей, вычисляется точно;
+//   - пересчет в другую валюту округляется до цента по каждой позиции
+//     (половина округляется от нуля), после чего суммы складываются.
+//
+// В JSON сумма выводится строкой ("12.30"); на входе принимаются строка и число.
+type Money struct {
+	d decimal.Decimal
+}
+
+// NewMoneyFromCents создает сумму из целого числа центов
+func NewMoneyFromCents(cents int64) Money {
+	return Money{d: decimal.New(cents, -MoneyScale)}
+}
+
+// ParseMoney разбирает сумму из строки вида "12.30"
+func ParseMoney(value string) (Money, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: invalid amount %q", ErrValidation, value)
+	}
+	return newMoney(d)
+}
+
+func newMoney(d decimal.Decimal) (Money, error) {
+	if !d.Equal(d.Round(MoneyScale)) {
+		return Money{}, fmt.Errorf("%w: amount %s has more than %d decimal places", ErrValidation, d, MoneyScale)
+	}
+	return Money{d: d}, nil
+}
+
+// Add возвращает сумму двух значений
+func (m Money) Add(other Money) Money {
+	return Money{d: m.d.Add(other.d)}
+}
+
+// Mul умножает сумму на целое количество (например, цену стебля на число стеблей)
+func (m Money) Mul(quantity int) Money {
+	return Money{d: m.d.Mul(decimal.NewFromInt(int64(quantity)))}
+}
+
+// Convert пересчитывает сумму по курсу с округлением до цента
+func (m Money) Convert(rate Rate) Money {
+	return Money{d: m.d.Mul(rate.d).Round(MoneyScale)}
+}
+
+// MulQuantity умножает сумму на дробное количество (например, тариф на число коробок)
+// с округлением до цента
+func (m Money) MulQuantity(quantity float64) Money {
+	return Money{d: m.d.Mul(decimal.NewFromFloat(quantity)).Round(MoneyScale)}
+}
+
+// Percent возвращает указанный процент от суммы с округлением до цента
+func (m Money) Percent(percent Rate) Money {
+	return Money{d: m.d.Mul(percent.d).Div(decimal.NewFromInt(100)).Round(MoneyScale)}
+}
+
+// Neg возвращает сумму с противоположным знаком
+func (m Money) Neg() Money {
+	return Money{d: m.d.Neg()}
+}
+
+// IsZero сообщает, равна ли сумма нулю
+func (m Money) IsZero() bool {
+	return m.d.IsZero()
+}
+
+// IsNegative сообщает, меньше ли сумма нуля
+func (m Money) IsNegative() bool {
+	return m.d.IsNegative()
+}
+
+// Equal сравнивает суммы по значению
+func (m Money) Equal(other Money) bool {
+	return m.d.Equal(other.d)
+}
+
+// Float64 возвращает приближенное значение для отчетов и метрик
+func (m Money) Float64() float64 {
+	f, _ := m.d.Float64()
+	return f
+}
+
+// String возвращает сумму с двумя знаками после запятой
+func (m Money) String() string {
+	return m.d.StringFixed(MoneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.String() + `"`), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var d decimal.Decimal
+	if err := d.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	parsed, err := newMoney(d)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value передает сумму в БД строкой, чтобы NUMERIC сохранил ее без потерь
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan читает NUMERIC из БД; NULL считается нулем
+func (m *Money) Scan(value interface{}) error {
+	if value == nil {
+		*m = Money{}
+		return nil
+	}
+	var d decimal.Decimal
+	if err := d.Scan(value); err != nil {
+		return err
+	}
+	m.d = d
+	return nil
+}
+
+// Rate десятичный коэффициент (курс валюты или процент) с фиксированной точкой
+// и восемью знаками после запятой.
+// В JSON выводится строкой; на входе принимаются строка и число.
+type Rate struct {
+	d decimal.Decimal
+}
+
+// ParseRate разбирает курс из строки вида "0.92"
+func ParseRate(value string) (Rate, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: invalid rate %q", ErrValidation, value)
+	}
+	return Rate{d: d}, nil
+}
+
+// IsZero сообщает, равен ли коэффициент нулю
+func (r Rate) IsZero() bool {
+	return r.d.IsZero()
+}
+
+// IsNegative сообщает, меньше ли коэффициент нуля
+func (r Rate) IsNegative() bool {
+	return r.d.IsNegative()
+}
+
+// IsPositive сообщает, больше ли курс нуля
+func (r Rate) IsPositive() bool {
+	return r.d.IsPositive()
+}
+
+// Inverse возвращает обратный курс, округленный до RateScale знаков
+func (r Rate) Inverse() Rate {
+	return Rate{d: decimal.NewFromInt(1).DivRound(r.d, RateScale)}
+}
+
+func (r Rate) String() string {
+	return r.d.String()
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + r.String() + `"`), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var d decimal.Decimal
+	if err := d.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("invalid rate: %w", err)
+	}
+	if !d.Equal(d.Round(RateScale)) {
+		return fmt.Errorf("%w: rate %s has more than %d decimal places", ErrValidation, d, RateScale)
+	}
+	r.d = d
+	return nil
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return r.d.String(), nil
+}
+
+func (r *Rate) Scan(value interface{}) error {
+	return r.d.Scan(value)
+}
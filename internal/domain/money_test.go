@@ -0,0 +1,318 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func mustMoney(t *testing.T, value string) Money {
+	t.Helper()
+	m, err := ParseMoney(value)
+	if err != nil {
+		t.Fatalf("ParseMoney(%q): %v", value, err)
+	}
+	return m
+}
+
+func mustRate(t *testing.T, value string) Rate {
+	t.Helper()
+	r, err := ParseRate(value)
+	if err != nil {
+		t.Fatalf("ParseRate(%q): %v", value, err)
+	}
+	return r
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "12.3", want: "12.30"},
+		{value: "12", want: "12.00"},
+		{value: "-1.5", want: "-1.50"},
+		{value: "0.10", want: "0.10"},
+		{value: "12.300", want: "12.30"},
+		{value: "12.345", wantErr: true},
+		{value: "0.001", wantErr: true},
+		{value: "abc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseMoney(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("ParseMoney(%q) error = %v, want ErrValidation", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q): %v", tt.value, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseMoney(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  func() Money
+		want string
+	}{
+		{
+			name: "add",
+			got:  func() Money { return mustMoney(t, "0.10").Add(mustMoney(t, "0.20")) },
+			want: "0.30",
+		},
+		{
+			name: "mul is exact",
+			got:  func() Money { return mustMoney(t, "0.33").Mul(3) },
+			want: "0.99",
+		},
+		{
+			name: "convert rounds to cents",
+			got:  func() Money { return mustMoney(t, "10.00").Convert(mustRate(t, "0.925")) },
+			want: "9.25",
+		},
+		{
+			name: "convert rounds half away from zero",
+			got:  func() Money { return mustMoney(t, "0.01").Convert(mustRate(t, "0.5")) },
+			want: "0.01",
+		},
+		{
+			name: "convert negative rounds half away from zero",
+			got:  func() Money { return mustMoney(t, "-0.01").Convert(mustRate(t, "0.5")) },
+			want: "-0.01",
+		},
+		{
+			name: "convert with eight decimal places",
+			got:  func() Money { return mustMoney(t, "1234.56").Convert(mustRate(t, "3.71234567")) },
+			want: "4583.11",
+		},
+		{
+			name: "mul quantity rounds to cents",
+			got:  func() Money { return mustMoney(t, "0.33").MulQuantity(0.5) },
+			want: "0.17",
+		},
+		{
+			name: "mul fractional box count",
+			got:  func() Money { return mustMoney(t, "12.00").MulQuantity(10.5) },
+			want: "126.00",
+		},
+		{
+			name: "percent rounds to cents",
+			got:  func() Money { return mustMoney(t, "99.99").Percent(mustRate(t, "15")) },
+			want: "15.00",
+		},
+		{
+			name: "fractional percent",
+			got:  func() Money { return mustMoney(t, "10.00").Percent(mustRate(t, "2.5")) },
+			want: "0.25",
+		},
+		{
+			name: "neg",
+			got:  func() Money { return mustMoney(t, "5.50").Neg() },
+			want: "-5.50",
+		},
+		{
+			name: "cents",
+			got:  func() Money { return NewMoneyFromCents(1234) },
+			want: "12.34",
+		},
+		{
+			name: "zero value",
+			got:  func() Money { return Money{} },
+			want: "0.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(); got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "string", input: `"12.30"`, want: `"12.30"`},
+		{name: "number", input: `12.3`, want: `"12.30"`},
+		{name: "integer", input: `7`, want: `"7.00"`},
+		{name: "negative", input: `"-0.05"`, want: `"-0.05"`},
+		{name: "too many decimal places", input: `"12.345"`, wantErr: true},
+		{name: "too many decimal places in number", input: `0.005`, wantErr: true},
+		{name: "not a number", input: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tt.input), &m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("round trip of %s = %s, want %s", tt.input, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyJSONInStruct(t *testing.T) {
+	type line struct {
+		Price *Money `json:"price"`
+	}
+	var got line
+	if err := json.Unmarshal([]byte(`{"price": 0.1}`), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.Price == nil || got.Price.String() != "0.10" {
+		t.Fatalf("price = %v, want 0.10", got.Price)
+	}
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"price":"0.10"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "null", value: nil, want: "0.00"},
+		{name: "bytes", value: []byte("12.50"), want: "12.50"},
+		{name: "string", value: "0.99", want: "0.99"},
+		{name: "float", value: 3.5, want: "3.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustMoney(t, "1.00")
+			if err := m.Scan(tt.value); err != nil {
+				t.Fatalf("Scan(%v): %v", tt.value, err)
+			}
+			if m.String() != tt.want {
+				t.Errorf("Scan(%v) = %s, want %s", tt.value, m, tt.want)
+			}
+			value, err := m.Value()
+			if err != nil {
+				t.Fatalf("Value: %v", err)
+			}
+			if value != tt.want {
+				t.Errorf("Value() = %v, want %s", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "string", input: `"0.92"`, want: `"0.92"`},
+		{name: "number", input: `0.92`, want: `"0.92"`},
+		{name: "eight decimal places", input: `"1.08695652"`, want: `"1.08695652"`},
+		{name: "too many decimal places", input: `"1.086956521"`, wantErr: true},
+		{name: "not a number", input: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Rate
+			err := json.Unmarshal([]byte(tt.input), &r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			data, err := json.Marshal(r)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("round trip of %s = %s, want %s", tt.input, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateInverse(t *testing.T) {
+	tests := []struct {
+		rate string
+		want string
+	}{
+		{rate: "0.92", want: "1.08695652"},
+		{rate: "2", want: "0.5"},
+		{rate: "3", want: "0.33333333"},
+		{rate: "0.00000001", want: "100000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			if got := mustRate(t, tt.rate).Inverse(); got.String() != tt.want {
+				t.Errorf("Inverse(%s) = %s, want %s", tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantErr      bool
+		wantPositive bool
+	}{
+		{value: "0.92", wantPositive: true},
+		{value: "0"},
+		{value: "-1"},
+		{value: "rate", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			r, err := ParseRate(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("ParseRate(%q) error = %v, want ErrValidation", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRate(%q): %v", tt.value, err)
+			}
+			if r.IsPositive() != tt.wantPositive {
+				t.Errorf("ParseRate(%q).IsPositive() = %v, want %v", tt.value, r.IsPositive(), tt.wantPositive)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -26,7 +27,26 @@ type Order struct {
 	ProcessedAt *time.Time  `json:"processed_at,omitempty" db:"processed_at"`
 	FarmOrderID *string     `json:"farm_order_id,omitempty" db:"farm_order_id"`
 	Notes       string      `json:"notes,omitempty" db:"notes"`
-	TotalAmount float64     `json:"total_amount" db:"total_amount"`
+	// ShipDate запрошенная дата отправки; по ней проверяется время отсечки ферм
+	ShipDate *time.Time `json:"ship_date,omitempty" db:"ship_date"`
+	// DeliveryDate запрошенная дата доставки клиенту
+	DeliveryDate *time.Time `json:"delivery_date,omitempty" db:"delivery_date"`
+	// Currency валюта счета клиента; TotalAmount указан в ней
+	Currency    string `json:"currency" db:"currency"`
+	TotalAmount Money  `json:"total_amount" db:"total_amount"`
+	// SourceTotals суммы позиций в исходных валютах цен ферм
+	SourceTotals map[string]Money `json:"source_totals,omitempty"`
+	// ExchangeRates курсы, зафиксированные на момент создания заказа
+	ExchangeRates []ExchangeRate `json:"exchange_rates,omitempty"`
+	// Adjustments скидки, наценки и доставка по позициям и заказу
+	Adjustments []Adjustment `json:"adjustments,omitempty"`
+	// Totals разбивка TotalAmount
+	Totals OrderTotals `json:"totals"`
+	// ReverseCharge налог не начислен по правилу reverse charge (B2B с VAT ID)
+	ReverseCharge bool `json:"reverse_charge" db:"reverse_charge"`
+	// Version увеличивается при каждом изменении заказа (оптимистичная блокировка)
+	Version   int       `json:"version" db:"version"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // Item представляет позицию в заказе
@@ -39,19 +59,35 @@ type Item struct {
 	PackRate   int     `json:"pack_rate" db:"pack_rate"`
 	TotalStems int     `json:"total_stems" db:"total_stems"`
 	FarmName   string  `json:"farm_name" db:"farm_name"`
-	TruckName  string  `json:"truck_name" db:"truck_name"`
-	Comments   string  `json:"comments,omitempty" db:"comments"`
-	Price      float64 `json:"price,omitempty" db:"price"`
+	// FarmID ферма из реестра; FarmName сохраняет название на момент заказа
+	FarmID    string `json:"farm_id,omitempty" db:"farm_id"`
+	TruckName string `json:"truck_name" db:"truck_name"`
+	Comments  string `json:"comments,omitempty" db:"comments"`
+	Price     Money  `json:"price" db:"price"`
+	// Currency валюта цены позиции
+	Currency string `json:"currency,omitempty" db:"currency"`
+	// Category категория товара для налоговых правил (по умолчанию flowers)
+	Category string `json:"category,omitempty" db:"category"`
+	// TaxRate ставка налога в процентах, зафиксированная в заказе
+	TaxRate Rate `json:"tax_rate" db:"tax_rate"`
+	// TaxAmount налог в валюте заказа
+	TaxAmount Money `json:"tax_amount" db:"tax_amount"`
 }
 
 // Customer представляет клиента
 type Customer struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Phone     string    `json:"phone,omitempty"`
-	Company   string    `json:"company,omitempty"`
-	Address   Address   `json:"address,omitempty"`
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Email   string  `json:"email"`
+	Phone   string  `json:"phone,omitempty"`
+	Company string  `json:"company,omitempty"`
+	Address Address `json:"address,omitempty"`
+	// Tier уровень клиента для цен прайс-листа (например, wholesale)
+	Tier string `json:"tier,omitempty"`
+	// VATID налоговый номер плательщика НДС (для reverse charge)
+	VATID string `json:"vat_id,omitempty"`
+	// Currency валюта, в которой клиенту выставляются счета
+	Currency  string    `json:"currency,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -77,8 +113,11 @@ const (
 
 // FarmOrder представляет заказ для фермы
 type FarmOrder struct {
-	ID        string          `json:"id"`
-	OrderID   string          `json:"order_id"`
+	ID      string `json:"id"`
+	OrderID string `json:"order_id"`
+	// FarmName ферма, которой отправлены позиции заказа
+	FarmName  string          `json:"farm_name"`
+	MarkBox   string          `json:"mark_box"`
 	Items     []Item          `json:"items"`
 	Status    FarmOrderStatus `json:"status"`
 	CreatedAt time.Time       `json:"created_at"`
@@ -86,14 +125,55 @@ type FarmOrder struct {
 	Notes     string          `json:"notes,omitempty"`
 }
 
-// CalculateTotal вычисляет общую сумму заказа
-func (o *Order) CalculateTotal() float64 {
-	total := 0.0
-	for _, item := range o.Items {
-		total += item.Price * float64(item.TotalStems)
+// CalculateTotal вычисляет суммы заказа в исходных валютах позиций,
+// корректировки, налог по зафиксированным ставкам и итоговую сумму
+// в валюте заказа по зафиксированным курсам
+func (o *Order) CalculateTotal() (Money, error) {
+	var total Money
+	sourceTotals := make(map[string]Money)
+	lines := make(map[string]Money, len(o.Items))
+	for i := range o.Items {
+		item := &o.Items[i]
+		currency := o.itemCurrency(*item)
+		sourceTotals[currency] = sourceTotals[currency].Add(item.Price.Mul(item.TotalStems))
+		amount, err := o.LineAmount(*item)
+		if err != nil {
+			return Money{}, err
+		}
+		item.TaxAmount = amount.Percent(item.TaxRate)
+		lines[item.ID] = amount
+		total = total.Add(amount)
+	}
+
+	totals, err := o.applyAdjustments(lines, total)
+	if err != nil {
+		return Money{}, err
+	}
+	o.SourceTotals = sourceTotals
+	o.Totals = totals
+	o.TotalAmount = totals.Total
+	return totals.Total, nil
+}
+
+// LineAmount возвращает сумму позиции в валюте заказа по зафиксированному курсу
+func (o *Order) LineAmount(item Item) (Money, error) {
+	amount := item.Price.Mul(item.TotalStems)
+	currency := o.itemCurrency(item)
+	if currency == o.Currency {
+		return amount, nil
+	}
+	rate, err := o.rateFor(currency)
+	if err != nil {
+		return Money{}, err
 	}
-	o.TotalAmount = total
-	return total
+	return amount.Convert(rate), nil
+}
+
+func (o *Order) itemCurrency(item Item) string {
+	if item.Currency == "" {
+		return o.Currency
+	}
+	return item.Currency
 }
 
 // IsValidStatus проверяет валидность статуса заказа
@@ -143,3 +223,16 @@ func (o *Order) CanTransitionTo(newStatus OrderStatus) bool {
 	}
 	return false
 }
+
+// CheckFinancialEdit проверяет, можно ли менять позиции, цены и корректировки заказа.
+// Выполненный или выставленный в счет заказ не изменяется: исправления оформляются
+// кредит-нотой и новым счетом.
+func (o *Order) CheckFinancialEdit(invoiced bool) error {
+	if o.Status == OrderStatusCompleted {
+		return fmt.Errorf("%w: order %s is completed, correct it with a credit note", ErrConflict, o.ID)
+	}
+	if invoiced {
+		return fmt.Errorf("%w: order %s is invoiced, correct it with a credit note", ErrConflict, o.ID)
+	}
+	return nil
+}
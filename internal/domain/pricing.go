@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PriceSource показывает, какое правило прайс-листа определило цену
+type PriceSource string
+
+const (
+	PriceSourceCustomer PriceSource = "customer"
+	PriceSourceTier     PriceSource = "tier"
+	PriceSourceBase     PriceSource = "base"
+	// PriceSourceCatalog цена из каталога цветов, если в прайс-листе цены нет
+	PriceSourceCatalog PriceSource = "catalog"
+)
+
+// PriceEntry цена за стебель для сорта, длины и фермы в интервале дат.
+// Базовая цена не содержит ни CustomerTier, ни CustomerID; переопределение
+// задает ровно одно из этих полей.
+type PriceEntry struct {
+	ID           string     `json:"id"`
+	Variety      string     `json:"variety"`
+	Length       int        `json:"length"`
+	FarmName     string     `json:"farm_name"`
+	Price        Money      `json:"price"`
+	Currency     string     `json:"currency"`
+	CustomerTier string     `json:"customer_tier,omitempty"`
+	CustomerID   string     `json:"customer_id,omitempty"`
+	ValidFrom    time.Time  `json:"valid_from"`
+	ValidTo      *time.Time `json:"valid_to,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Source возвращает вид правила прайс-листа
+func (p *PriceEntry) Source() PriceSource {
+	switch {
+	case p.ID == "":
+		return PriceSourceCatalog
+	case p.CustomerID != "":
+		return PriceSourceCustomer
+	case p.CustomerTier != "":
+		return PriceSourceTier
+	default:
+		return PriceSourceBase
+	}
+}
+
+// PriceQuery параметры поиска применимой цены
+type PriceQuery struct {
+	Variety      string
+	Length       int
+	FarmName     string
+	CustomerID   string
+	CustomerTier string
+	Date         time.Time
+}
+
+// PriceFilter фильтр списка прайс-листа; пустые поля не учитываются
+type PriceFilter struct {
+	Variety      string
+	FarmName     string
+	CustomerID   string
+	CustomerTier string
+	ActiveOn     *time.Time
+}
+
+// PriceRepository хранилище прайс-листа
+type PriceRepository interface {
+	CreatePrice(ctx context.Context, entry *PriceEntry) error
+	ListPrices(ctx context.Context, filter PriceFilter) ([]PriceEntry, error)
+	DeletePrice(ctx context.Context, id string) error
+	// FindPrice возвращает самую специфичную действующую цену:
+	// цену клиента, затем цену его уровня, затем базовую. Среди цен
+	// одного вида выбирается начавшая действовать позже всех.
+	FindPrice(ctx context.Context, query PriceQuery) (*PriceEntry, error)
+	// FindCatalogPrice возвращает ненулевую цену позиции из каталога цветов
+	// (без ID в прайс-листе) или ErrNotFound
+	FindCatalogPrice(ctx context.Context, variety string, length int, farmName string) (*PriceEntry, error)
+}
+
+// CustomerRepository хранилище клиентов
+type CustomerRepository interface {
+	GetCustomer(ctx context.Context, id string) (*Customer, error)
+	SaveCustomer(ctx context.Context, customer *Customer) error
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BoxScanStatus результат сканирования коробки при приемке
+type BoxScanStatus string
+
+const (
+	// BoxScanReceived коробка ожидалась и принята
+	BoxScanReceived BoxScanStatus = "received"
+	// BoxScanDuplicate коробка уже была отсканирована
+	BoxScanDuplicate BoxScanStatus = "duplicate"
+	// BoxScanUnexpected код не относится к позициям заказа фермы или номер коробки лишний
+	BoxScanUnexpected BoxScanStatus = "unexpected"
+)
+
+// BoxScan сканирование коробки при приемке заказа фермы
+type BoxScan struct {
+	ID          string        `json:"id"`
+	FarmOrderID string        `json:"farm_order_id"`
+	Code        string        `json:"code"`
+	ItemID      string        `json:"item_id,omitempty"`
+	Box         int           `json:"box,omitempty"`
+	Status      BoxScanStatus `json:"status"`
+	ScannedAt   time.Time     `json:"scanned_at"`
+}
+
+// FarmOrderRepository хранилище заказов ферм и сканирований приемки
+type FarmOrderRepository interface {
+	// CreateFarmOrders создает заказы ферм; ферма, для которой заказ уже есть, пропускается
+	CreateFarmOrders(ctx context.Context, farmOrders []FarmOrder) error
+	GetFarmOrder(ctx context.Context, id string) (*FarmOrder, error)
+	ListOrderFarmOrders(ctx context.Context, orderID string) ([]FarmOrder, error)
+	ListBoxScans(ctx context.Context, farmOrderID string) ([]BoxScan, error)
+	// RecordBoxScan сохраняет сканирование и переводит заказ фермы в delivered,
+	// когда приняты все ожидаемые коробки
+	RecordBoxScan(ctx context.Context, farmOrderID, code string, scannedAt time.Time) (*BoxScan, *ReceivingReport, error)
+}
+
+// SplitByFarm разбивает позиции заказа на заказы ферм
+func (o *Order) SplitByFarm() []FarmOrder {
+	var farmOrders []FarmOrder
+	index := make(map[string]int)
+	for _, item := range o.Items {
+		i, ok := index[item.FarmName]
+		if !ok {
+			i = len(farmOrders)
+			index[item.FarmName] = i
+			farmOrders = append(farmOrders, FarmOrder{
+				OrderID:  o.ID,
+				FarmName: item.FarmName,
+				MarkBox:  o.MarkBox,
+				Status:   FarmOrderStatusSent,
+			})
+		}
+		farmOrders[i].Items = append(farmOrders[i].Items, item)
+	}
+	return farmOrders
+}
+
+// ExpectedBoxes возвращает число коробок, которые должна поставить ферма
+func (f *FarmOrder) ExpectedBoxes() int {
+	var boxes int
+	for i := range f.Items {
+		boxes += f.Items[i].Boxes()
+	}
+	return boxes
+}
+
+// ClassifyScan определяет результат сканирования кода с учетом предыдущих сканирований
+func (f *FarmOrder) ClassifyScan(code string, previous []BoxScan) (BoxScan, error) {
+	if f.Status == FarmOrderStatusCancelled {
+		return BoxScan{}, fmt.Errorf("%w: farm order %s is cancelled", ErrConflict, f.ID)
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	scan := BoxScan{FarmOrderID: f.ID, Code: code, Status: BoxScanUnexpected}
+
+	prefix, box, err := ParseBoxLabelCode(code)
+	if err != nil {
+		return scan, nil
+	}
+	for i := range f.Items {
+		item := &f.Items[i]
+		if !strings.HasPrefix(strings.ReplaceAll(item.ID, "-", ""), prefix) {
+			continue
+		}
+		scan.ItemID = item.ID
+		scan.Box = box
+		if box > item.Boxes() {
+			return scan, nil
+		}
+		scan.Status = BoxScanReceived
+		for _, prev := range previous {
+			if prev.Status == BoxScanReceived && prev.ItemID == item.ID && prev.Box == box {
+				scan.Status = BoxScanDuplicate
+				break
+			}
+		}
+		return scan, nil
+	}
+	return scan, nil
+}
+
+// ReceivingReport сверка принятых коробок с ожидаемыми
+type ReceivingReport struct {
+	FarmOrderID   string          `json:"farm_order_id"`
+	Status        FarmOrderStatus `json:"status"`
+	ExpectedBoxes int             `json:"expected_boxes"`
+	ReceivedBoxes int             `json:"received_boxes"`
+	// Shortage число еще не принятых коробок
+	Shortage int `json:"shortage"`
+	// Overage число лишних сканирований: повторы и чужие коробки
+	Overage int             `json:"overage"`
+	Items   []ReceivingItem `json:"items"`
+	// Unexpected коды, не относящиеся к заказу фермы
+	Unexpected []string `json:"unexpected"`
+	Complete   bool     `json:"complete"`
+}
+
+// ReceivingItem сверка коробок одной позиции
+type ReceivingItem struct {
+	ItemID   string `json:"item_id"`
+	Variety  string `json:"variety"`
+	Length   int    `json:"length"`
+	Expected int    `json:"expected"`
+	Received int    `json:"received"`
+	// Missing номера коробок, которые еще не отсканированы
+	Missing    []int `json:"missing"`
+	Duplicates int   `json:"duplicates"`
+}
+
+// Reconcile сверяет сканирования с ожидаемыми коробками заказа фермы
+func (f *FarmOrder) Reconcile(scans []BoxScan) ReceivingReport {
+	report := ReceivingReport{
+		FarmOrderID:   f.ID,
+		Status:        f.Status,
+		ExpectedBoxes: f.ExpectedBoxes(),
+		Items:         []ReceivingItem{},
+		Unexpected:    []string{},
+	}
+
+	received := make(map[string]map[int]bool)
+	duplicates := make(map[string]int)
+	for _, scan := range scans {
+		switch scan.Status {
+		case BoxScanReceived:
+			if received[scan.ItemID] == nil {
+				received[scan.ItemID] = make(map[int]bool)
+			}
+			received[scan.ItemID][scan.Box] = true
+		case BoxScanDuplicate:
+			duplicates[scan.ItemID]++
+			report.Overage++
+		case BoxScanUnexpected:
+			report.Unexpected = append(report.Unexpected, scan.Code)
+			report.Overage++
+		}
+	}
+
+	for i := range f.Items {
+		item := &f.Items[i]
+		line := ReceivingItem{
+			ItemID:     item.ID,
+			Variety:    item.Variety,
+			Length:     item.Length,
+			Expected:   item.Boxes(),
+			Received:   len(received[item.ID]),
+			Missing:    []int{},
+			Duplicates: duplicates[item.ID],
+		}
+		for box := 1; box <= line.Expected; box++ {
+			if !received[item.ID][box] {
+				line.Missing = append(line.Missing, box)
+			}
+		}
+		report.ReceivedBoxes += line.Received
+		report.Items = append(report.Items, line)
+	}
+	sort.Strings(report.Unexpected)
+
+	report.Shortage = report.ExpectedBoxes - report.ReceivedBoxes
+	report.Complete = report.Shortage == 0
+	return report
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const (
+	roseItemID  = "3f2a9c1b-4d00-4000-8000-000000000001"
+	tulipItemID = "7b1e0d2c-5a00-4000-8000-000000000002"
+	// позиция другой фермы того же заказа
+	lilyItemID = "9c4d3e2f-6b00-4000-8000-000000000003"
+)
+
+func receivingFarmOrder() *FarmOrder {
+	return &FarmOrder{
+		ID:       "farm-order",
+		OrderID:  "order",
+		FarmName: "Alpha",
+		Status:   FarmOrderStatusSent,
+		Items: []Item{
+			{ID: roseItemID, Variety: "Rose", Length: 50, FarmName: "Alpha", BoxCount: 2.5},
+			{ID: tulipItemID, Variety: "Tulip", Length: 40, FarmName: "Alpha", BoxCount: 1},
+		},
+	}
+}
+
+func TestClassifyScan(t *testing.T) {
+	previous := []BoxScan{{ItemID: roseItemID, Box: 1, Status: BoxScanReceived}}
+
+	tests := []struct {
+		name       string
+		code       string
+		status     FarmOrderStatus
+		wantStatus BoxScanStatus
+		wantItemID string
+		wantBox    int
+		wantErr    error
+	}{
+		{name: "expected box", code: BoxLabelCode(roseItemID, 3), wantStatus: BoxScanReceived, wantItemID: roseItemID, wantBox: 3},
+		{name: "lower case with spaces", code: " 7b1e0d2c5a-001 ", wantStatus: BoxScanReceived, wantItemID: tulipItemID, wantBox: 1},
+		{name: "duplicate", code: BoxLabelCode(roseItemID, 1), wantStatus: BoxScanDuplicate, wantItemID: roseItemID, wantBox: 1},
+		{
+			name: "box number over the item boxes", code: BoxLabelCode(roseItemID, 4),
+			wantStatus: BoxScanUnexpected, wantItemID: roseItemID, wantBox: 4,
+		},
+		{name: "item of another farm order", code: BoxLabelCode(lilyItemID, 1), wantStatus: BoxScanUnexpected},
+		{name: "unknown code", code: "not a box", wantStatus: BoxScanUnexpected},
+		{name: "cancelled farm order", code: BoxLabelCode(roseItemID, 2), status: FarmOrderStatusCancelled, wantErr: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			farmOrder := receivingFarmOrder()
+			if tt.status != "" {
+				farmOrder.Status = tt.status
+			}
+			scan, err := farmOrder.ClassifyScan(tt.code, previous)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ClassifyScan error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ClassifyScan: %v", err)
+			}
+			if scan.Status != tt.wantStatus || scan.ItemID != tt.wantItemID || scan.Box != tt.wantBox {
+				t.Errorf("scan = %s %s box %d, want %s %s box %d",
+					scan.Status, scan.ItemID, scan.Box, tt.wantStatus, tt.wantItemID, tt.wantBox)
+			}
+			if scan.FarmOrderID != farmOrder.ID {
+				t.Errorf("FarmOrderID = %s, want %s", scan.FarmOrderID, farmOrder.ID)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	received := func(itemID string, box int) BoxScan {
+		return BoxScan{Code: BoxLabelCode(itemID, box), ItemID: itemID, Box: box, Status: BoxScanReceived}
+	}
+	duplicate := func(itemID string, box int) BoxScan {
+		return BoxScan{Code: BoxLabelCode(itemID, box), ItemID: itemID, Box: box, Status: BoxScanDuplicate}
+	}
+	unexpected := func(code string) BoxScan {
+		return BoxScan{Code: code, Status: BoxScanUnexpected}
+	}
+
+	tests := []struct {
+		name           string
+		scans          []BoxScan
+		wantReceived   int
+		wantShortage   int
+		wantOverage    int
+		wantComplete   bool
+		wantMissing    string
+		wantDuplicates string
+		wantUnexpected string
+	}{
+		{
+			name:           "nothing scanned",
+			wantShortage:   4,
+			wantMissing:    "[[1 2 3] [1]]",
+			wantDuplicates: "[0 0]",
+			wantUnexpected: "[]",
+		},
+		{
+			name:           "short delivery",
+			scans:          []BoxScan{received(roseItemID, 1), received(roseItemID, 3)},
+			wantReceived:   2,
+			wantShortage:   2,
+			wantMissing:    "[[2] [1]]",
+			wantDuplicates: "[0 0]",
+			wantUnexpected: "[]",
+		},
+		{
+			name: "complete delivery",
+			scans: []BoxScan{
+				received(roseItemID, 1), received(roseItemID, 2), received(roseItemID, 3), received(tulipItemID, 1),
+			},
+			wantReceived:   4,
+			wantComplete:   true,
+			wantMissing:    "[[] []]",
+			wantDuplicates: "[0 0]",
+			wantUnexpected: "[]",
+		},
+		{
+			// повторы и чужие коробки учитываются как излишки и не закрывают недостачу
+			name: "over delivery with duplicates and wrong farm boxes",
+			scans: []BoxScan{
+				received(roseItemID, 1), duplicate(roseItemID, 1), duplicate(roseItemID, 1),
+				received(tulipItemID, 1), unexpected(BoxLabelCode(lilyItemID, 1)), unexpected("JUNK"),
+			},
+			wantReceived:   2,
+			wantShortage:   2,
+			wantOverage:    4,
+			wantMissing:    "[[2 3] []]",
+			wantDuplicates: "[2 0]",
+			wantUnexpected: "[9C4D3E2F6B-001 JUNK]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			farmOrder := receivingFarmOrder()
+			report := farmOrder.Reconcile(tt.scans)
+
+			if report.ExpectedBoxes != 4 {
+				t.Errorf("ExpectedBoxes = %d, want 4", report.ExpectedBoxes)
+			}
+			if report.ReceivedBoxes != tt.wantReceived || report.Shortage != tt.wantShortage ||
+				report.Overage != tt.wantOverage || report.Complete != tt.wantComplete {
+				t.Errorf("received %d, shortage %d, overage %d, complete %v; want %d, %d, %d, %v",
+					report.ReceivedBoxes, report.Shortage, report.Overage, report.Complete,
+					tt.wantReceived, tt.wantShortage, tt.wantOverage, tt.wantComplete)
+			}
+
+			missing := make([][]int, len(report.Items))
+			duplicates := make([]int, len(report.Items))
+			for i, item := range report.Items {
+				missing[i], duplicates[i] = item.Missing, item.Duplicates
+			}
+			if got := fmt.Sprint(missing); got != tt.wantMissing {
+				t.Errorf("missing = %s, want %s", got, tt.wantMissing)
+			}
+			if got := fmt.Sprint(duplicates); got != tt.wantDuplicates {
+				t.Errorf("duplicates = %s, want %s", got, tt.wantDuplicates)
+			}
+			if got := fmt.Sprint(report.Unexpected); got != tt.wantUnexpected {
+				t.Errorf("unexpected = %s, want %s", got, tt.wantUnexpected)
+			}
+		})
+	}
+}
+
+func TestSplitByFarm(t *testing.T) {
+	order := &Order{ID: "order", MarkBox: "MB1", Items: []Item{
+		{ID: "1", FarmName: "Alpha"},
+		{ID: "2", FarmName: "Beta"},
+		{ID: "3", FarmName: "Alpha"},
+	}}
+
+	farmOrders := order.SplitByFarm()
+	got := make([]string, len(farmOrders))
+	for i, f := range farmOrders {
+		got[i] = fmt.Sprintf("%s:%d:%s:%s", f.FarmName, len(f.Items), f.MarkBox, f.Status)
+	}
+	if want := "[Alpha:2:MB1:sent Beta:1:MB1:sent]"; fmt.Sprint(got) != want {
+		t.Errorf("farm orders = %v, want %s", got, want)
+	}
+}
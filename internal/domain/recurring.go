@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecurrenceFrequency правило повторения постоянного заказа
+type RecurrenceFrequency string
+
+const (
+	// RecurrenceWeekly по выбранным дням недели
+	RecurrenceWeekly RecurrenceFrequency = "weekly"
+	// RecurrenceInterval каждые IntervalDays дней начиная с даты начала
+	RecurrenceInterval RecurrenceFrequency = "interval"
+)
+
+// RecurringOrderStatus статус постоянного заказа
+type RecurringOrderStatus string
+
+const (
+	RecurringOrderStatusActive RecurringOrderStatus = "active"
+	RecurringOrderStatusPaused RecurringOrderStatus = "paused"
+)
+
+// weekdays дни недели в правилах повторения
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// RecurringOrder шаблон постоянного заказа клиента. Планировщик создает
+// по нему обычные заказы на каждую дату отправки по правилу повторения.
+type RecurringOrder struct {
+	ID         string              `json:"id"`
+	CustomerID string              `json:"customer_id"`
+	MarkBox    string              `json:"mark_box"`
+	Currency   string              `json:"currency,omitempty"`
+	Notes      string              `json:"notes,omitempty"`
+	Frequency  RecurrenceFrequency `json:"frequency"`
+	// Weekdays дни отправки для weekly: monday, tuesday, ...
+	Weekdays []string `json:"weekdays,omitempty"`
+	// IntervalDays период в днях для interval
+	IntervalDays int        `json:"interval_days,omitempty"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	// SkipDates даты отправки, на которые заказ не создается
+	SkipDates []time.Time          `json:"skip_dates"`
+	Status    RecurringOrderStatus `json:"status"`
+	Items     []RecurringOrderItem `json:"items"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// RecurringOrderItem позиция шаблона; цена определяется при создании заказа
+type RecurringOrderItem struct {
+	ID         string  `json:"id"`
+	Variety    string  `json:"variety"`
+	Length     int     `json:"length"`
+	BoxCount   float64 `json:"box_count"`
+	PackRate   int     `json:"pack_rate"`
+	TotalStems int     `json:"total_stems"`
+	FarmName   string  `json:"farm_name"`
+	TruckName  string  `json:"truck_name"`
+	Comments   string  `json:"comments,omitempty"`
+	Category   string  `json:"category,omitempty"`
+}
+
+// RecurringOrderFilter фильтр списка постоянных заказов; пустые поля не учитываются
+type RecurringOrderFilter struct {
+	CustomerID string
+	Status     RecurringOrderStatus
+}
+
+// RecurringOrderRun создание заказа по шаблону на дату отправки. Дата
+// повторяется, пока заказ не создан, кроме отказов валидации (Failed).
+type RecurringOrderRun struct {
+	RecurringOrderID string    `json:"recurring_order_id"`
+	ShipDate         time.Time `json:"ship_date"`
+	OrderID          string    `json:"order_id,omitempty"`
+	// Error последняя ошибка создания заказа
+	Error string `json:"error,omitempty"`
+	// Failed заказ отклонен валидацией, дата больше не повторяется
+	Failed    bool      `json:"failed,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecurringOccurrence предстоящая дата отправки постоянного заказа
+type RecurringOccurrence struct {
+	ShipDate time.Time `json:"ship_date"`
+	// Skipped дата входит в список пропусков
+	Skipped bool `json:"skipped,omitempty"`
+	// OrderID и Error заполнены, если заказ на эту дату уже создавался
+	OrderID string `json:"order_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Failed заказ отклонен валидацией и повторяться не будет
+	Failed bool `json:"failed,omitempty"`
+}
+
+// RecurringOrderRepository хранилище постоянных заказов и попыток их создания
+type RecurringOrderRepository interface {
+	CreateRecurringOrder(ctx context.Context, order *RecurringOrder) error
+	// UpdateRecurringOrder заменяет правило, даты и позиции шаблона; статус не меняется
+	UpdateRecurringOrder(ctx context.Context, order *RecurringOrder) error
+	SetRecurringOrderStatus(ctx context.Context, id string, status RecurringOrderStatus) error
+	GetRecurringOrder(ctx context.Context, id string) (*RecurringOrder, error)
+	ListRecurringOrders(ctx context.Context, filter RecurringOrderFilter) ([]RecurringOrder, error)
+	// StartRecurringRun занимает дату для попытки. Возвращает false, если заказ
+	// на дату уже создан, отклонен валидацией или другая попытка начата позже staleBefore.
+	StartRecurringRun(ctx context.Context, run *RecurringOrderRun, staleBefore time.Time) (bool, error)
+	FinishRecurringRun(ctx context.Context, run *RecurringOrderRun) error
+	// ListRecurringRuns возвращает попытки с датой отправки не раньше from
+	ListRecurringRuns(ctx context.Context, recurringOrderID string, from time.Time) ([]RecurringOrderRun, error)
+}
+
+// Validate проверяет правило повторения, даты и наличие позиций
+func (r *RecurringOrder) Validate() error {
+	switch r.Frequency {
+	case RecurrenceWeekly:
+		if len(r.Weekdays) == 0 {
+			return fmt.Errorf("%w: weekly recurrence requires weekdays", ErrValidation)
+		}
+		for _, day := range r.Weekdays {
+			if _, ok := weekdays[day]; !ok {
+				return fmt.Errorf("%w: unknown weekday %q", ErrValidation, day)
+			}
+		}
+	case RecurrenceInterval:
+		if r.IntervalDays < 1 {
+			return fmt.Errorf("%w: interval recurrence requires interval_days >= 1", ErrValidation)
+		}
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", ErrValidation, r.Frequency)
+	}
+	if r.EndDate != nil && truncateDate(*r.EndDate).Before(truncateDate(r.StartDate)) {
+		return fmt.Errorf("%w: end date is before start date", ErrValidation)
+	}
+	if len(r.Items) == 0 {
+		return fmt.Errorf("%w: recurring order has no items", ErrValidation)
+	}
+	return nil
+}
+
+// Matches сообщает, приходится ли на дату отправка по правилу и периоду шаблона.
+// Даты из списка пропусков тоже совпадают: их отсеивает IsSkipped.
+func (r *RecurringOrder) Matches(date time.Time) bool {
+	day := truncateDate(date)
+	start := truncateDate(r.StartDate)
+	if day.Before(start) || (r.EndDate != nil && day.After(truncateDate(*r.EndDate))) {
+		return false
+	}
+	switch r.Frequency {
+	case RecurrenceWeekly:
+		for _, name := range r.Weekdays {
+			if weekdays[name] == day.Weekday() {
+				return true
+			}
+		}
+	case RecurrenceInterval:
+		if r.IntervalDays > 0 {
+			return int(day.Sub(start).Hours()/24)%r.IntervalDays == 0
+		}
+	}
+	return false
+}
+
+// IsSkipped сообщает, входит ли дата в список пропусков
+func (r *RecurringOrder) IsSkipped(date time.Time) bool {
+	day := truncateDate(date)
+	for _, skip := range r.SkipDates {
+		if truncateDate(skip).Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Occurrences возвращает даты отправки в [from, to] включительно,
+// включая пропускаемые
+func (r *RecurringOrder) Occurrences(from, to time.Time) []time.Time {
+	var dates []time.Time
+	last := truncateDate(to)
+	for day := truncateDate(from); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if r.Matches(day) {
+			dates = append(dates, day)
+		}
+	}
+	return dates
+}
+
+// DueOccurrences возвращает непропущенные даты отправки, заказы на которые
+// пора создать: от сегодняшней даты до now + leadTime
+func (r *RecurringOrder) DueOccurrences(now time.Time, leadTime time.Duration) []time.Time {
+	if r.Status != RecurringOrderStatusActive {
+		return nil
+	}
+	var due []time.Time
+	for _, date := range r.Occurrences(now, now.Add(leadTime)) {
+		if !r.IsSkipped(date) {
+			due = append(due, date)
+		}
+	}
+	return due
+}
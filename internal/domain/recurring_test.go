@@ -0,0 +1,338 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func datePtr(value string) *time.Time {
+	t := date(value)
+	return &t
+}
+
+func formatDateList(dates []time.Time) []string {
+	formatted := make([]string, len(dates))
+	for i, d := range dates {
+		formatted[i] = d.Format("2006-01-02")
+	}
+	return formatted
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 2024-01-01 - понедельник
+func TestRecurringOrderMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		order RecurringOrder
+		date  time.Time
+		want  bool
+	}{
+		{
+			name:  "weekly matching weekday",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"monday", "thursday"}, StartDate: date("2024-01-01")},
+			date:  date("2024-01-04"),
+			want:  true,
+		},
+		{
+			name:  "weekly other weekday",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"monday", "thursday"}, StartDate: date("2024-01-01")},
+			date:  date("2024-01-03"),
+			want:  false,
+		},
+		{
+			name:  "weekly ignores time of day",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01")},
+			date:  date("2024-01-08").Add(23 * time.Hour),
+			want:  true,
+		},
+		{
+			name:  "before start date",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-08")},
+			date:  date("2024-01-01"),
+			want:  false,
+		},
+		{
+			name: "on end date",
+			order: RecurringOrder{
+				Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01"),
+				EndDate: datePtr("2024-01-15"),
+			},
+			date: date("2024-01-15"),
+			want: true,
+		},
+		{
+			name: "after end date",
+			order: RecurringOrder{
+				Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01"),
+				EndDate: datePtr("2024-01-15"),
+			},
+			date: date("2024-01-22"),
+			want: false,
+		},
+		{
+			name:  "interval on start date",
+			order: RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 3, StartDate: date("2024-01-01")},
+			date:  date("2024-01-01"),
+			want:  true,
+		},
+		{
+			name:  "interval multiple of period",
+			order: RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 3, StartDate: date("2024-01-01")},
+			date:  date("2024-01-10"),
+			want:  true,
+		},
+		{
+			name:  "interval between periods",
+			order: RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 3, StartDate: date("2024-01-01")},
+			date:  date("2024-01-11"),
+			want:  false,
+		},
+		{
+			name:  "interval across month boundary",
+			order: RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 14, StartDate: date("2024-01-25")},
+			date:  date("2024-02-08"),
+			want:  true,
+		},
+		{
+			name:  "interval without period",
+			order: RecurringOrder{Frequency: RecurrenceInterval, StartDate: date("2024-01-01")},
+			date:  date("2024-01-01"),
+			want:  false,
+		},
+		{
+			name: "skip date still matches",
+			order: RecurringOrder{
+				Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01"),
+				SkipDates: []time.Time{date("2024-01-08")},
+			},
+			date: date("2024-01-08"),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.Matches(tt.date); got != tt.want {
+				t.Errorf("Matches(%s) = %v, want %v", tt.date.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringOrderOccurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		order RecurringOrder
+		from  time.Time
+		to    time.Time
+		want  []string
+	}{
+		{
+			name:  "weekly range is inclusive",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"monday", "friday"}, StartDate: date("2024-01-01")},
+			from:  date("2024-01-01"),
+			to:    date("2024-01-12"),
+			want:  []string{"2024-01-01", "2024-01-05", "2024-01-08", "2024-01-12"},
+		},
+		{
+			name:  "interval starts counting from start date",
+			order: RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 4, StartDate: date("2024-01-02")},
+			from:  date("2024-01-01"),
+			to:    date("2024-01-14"),
+			want:  []string{"2024-01-02", "2024-01-06", "2024-01-10", "2024-01-14"},
+		},
+		{
+			name: "stops at end date",
+			order: RecurringOrder{
+				Frequency: RecurrenceInterval, IntervalDays: 2, StartDate: date("2024-01-01"),
+				EndDate: datePtr("2024-01-05"),
+			},
+			from: date("2024-01-01"),
+			to:   date("2024-01-31"),
+			want: []string{"2024-01-01", "2024-01-03", "2024-01-05"},
+		},
+		{
+			name: "includes skip dates",
+			order: RecurringOrder{
+				Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01"),
+				SkipDates: []time.Time{date("2024-01-08")},
+			},
+			from: date("2024-01-01"),
+			to:   date("2024-01-15"),
+			want: []string{"2024-01-01", "2024-01-08", "2024-01-15"},
+		},
+		{
+			name:  "empty range",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"sunday"}, StartDate: date("2024-01-01")},
+			from:  date("2024-01-01"),
+			to:    date("2024-01-06"),
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatDateList(tt.order.Occurrences(tt.from, tt.to))
+			if !equalStrings(got, tt.want) {
+				t.Errorf("Occurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringOrderDueOccurrences(t *testing.T) {
+	weekly := RecurringOrder{
+		Frequency: RecurrenceWeekly,
+		Weekdays:  []string{"monday", "wednesday"},
+		StartDate: date("2024-01-01"),
+		Status:    RecurringOrderStatusActive,
+	}
+
+	tests := []struct {
+		name     string
+		order    RecurringOrder
+		now      time.Time
+		leadTime time.Duration
+		want     []string
+	}{
+		{
+			name:     "lead window includes today",
+			order:    weekly,
+			now:      date("2024-01-01").Add(10 * time.Hour),
+			leadTime: 72 * time.Hour,
+			want:     []string{"2024-01-01", "2024-01-03"},
+		},
+		{
+			name:     "date beyond lead window",
+			order:    weekly,
+			now:      date("2024-01-01").Add(10 * time.Hour),
+			leadTime: 24 * time.Hour,
+			want:     []string{"2024-01-01"},
+		},
+		{
+			name:     "window end counts by date",
+			order:    weekly,
+			now:      date("2024-01-01").Add(23 * time.Hour),
+			leadTime: 26 * time.Hour,
+			want:     []string{"2024-01-01", "2024-01-03"},
+		},
+		{
+			name: "skip dates excluded",
+			order: RecurringOrder{
+				Frequency: RecurrenceWeekly, Weekdays: []string{"monday", "wednesday"}, StartDate: date("2024-01-01"),
+				SkipDates: []time.Time{date("2024-01-03")}, Status: RecurringOrderStatusActive,
+			},
+			now:      date("2024-01-01"),
+			leadTime: 7 * 24 * time.Hour,
+			want:     []string{"2024-01-01", "2024-01-08"},
+		},
+		{
+			name: "end date inside window",
+			order: RecurringOrder{
+				Frequency: RecurrenceInterval, IntervalDays: 1, StartDate: date("2024-01-01"),
+				EndDate: datePtr("2024-01-02"), Status: RecurringOrderStatusActive,
+			},
+			now:      date("2024-01-01"),
+			leadTime: 72 * time.Hour,
+			want:     []string{"2024-01-01", "2024-01-02"},
+		},
+		{
+			name: "paused order",
+			order: RecurringOrder{
+				Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01"),
+				Status: RecurringOrderStatusPaused,
+			},
+			now:      date("2024-01-01"),
+			leadTime: 72 * time.Hour,
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatDateList(tt.order.DueOccurrences(tt.now, tt.leadTime))
+			if !equalStrings(got, tt.want) {
+				t.Errorf("DueOccurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurringOrderValidate(t *testing.T) {
+	items := []RecurringOrderItem{{Variety: "Rose"}}
+	tests := []struct {
+		name    string
+		order   RecurringOrder
+		wantErr bool
+	}{
+		{
+			name:  "valid weekly",
+			order: RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"monday"}, StartDate: date("2024-01-01"), Items: items},
+		},
+		{
+			name:  "valid interval",
+			order: RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 7, StartDate: date("2024-01-01"), Items: items},
+		},
+		{
+			name:    "weekly without weekdays",
+			order:   RecurringOrder{Frequency: RecurrenceWeekly, StartDate: date("2024-01-01"), Items: items},
+			wantErr: true,
+		},
+		{
+			name:    "unknown weekday",
+			order:   RecurringOrder{Frequency: RecurrenceWeekly, Weekdays: []string{"funday"}, StartDate: date("2024-01-01"), Items: items},
+			wantErr: true,
+		},
+		{
+			name:    "interval without period",
+			order:   RecurringOrder{Frequency: RecurrenceInterval, StartDate: date("2024-01-01"), Items: items},
+			wantErr: true,
+		},
+		{
+			name:    "unknown frequency",
+			order:   RecurringOrder{Frequency: "monthly", StartDate: date("2024-01-01"), Items: items},
+			wantErr: true,
+		},
+		{
+			name: "end before start",
+			order: RecurringOrder{
+				Frequency: RecurrenceInterval, IntervalDays: 1, StartDate: date("2024-01-10"),
+				EndDate: datePtr("2024-01-09"), Items: items,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no items",
+			order:   RecurringOrder{Frequency: RecurrenceInterval, IntervalDays: 1, StartDate: date("2024-01-01")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.order.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
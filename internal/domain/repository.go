@@ -9,6 +9,12 @@ type OrderRepository interface {
 	Create(ctx context.Context, order *Order) error
 	GetByID(ctx context.Context, id string) (*Order, error)
 	GetByStatus(ctx context.Context, status OrderStatus) ([]*Order, error)
+	// List возвращает заказы по фильтру, отсортированные по дате отправки
+	List(ctx context.Context, filter OrderFilter) ([]*Order, error)
+	// ListCatalogWindows возвращает окна доступности позиций каталога
+	ListCatalogWindows(ctx context.Context) ([]CatalogWindow, error)
+	// SetCatalogWindow задает окно доступности сорта и длины фермы в каталоге
+	SetCatalogWindow(ctx context.Context, window CatalogWindow) error
 	Update(ctx context.Context, order *Order) error
 	Close() error
 }
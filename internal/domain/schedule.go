@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CatalogWindow период доступности сорта и длины фермы в каталоге.
+// Пустая граница означает отсутствие ограничения.
+type CatalogWindow struct {
+	Variety       string     `json:"variety"`
+	Length        int        `json:"length"`
+	FarmName      string     `json:"farm_name"`
+	AvailableFrom *time.Time `json:"available_from,omitempty"`
+	AvailableTo   *time.Time `json:"available_to,omitempty"`
+}
+
+// Covers сообщает, доступна ли позиция каталога на дату отправки
+func (w *CatalogWindow) Covers(date time.Time) bool {
+	day := truncateDate(date)
+	if w.AvailableFrom != nil && day.Before(truncateDate(*w.AvailableFrom)) {
+		return false
+	}
+	if w.AvailableTo != nil && day.After(truncateDate(*w.AvailableTo)) {
+		return false
+	}
+	return true
+}
+
+// IsAvailable сообщает, доступна ли позиция на дату отправки: позиция
+// без окон в каталоге доступна всегда, иначе ее дату должно покрывать одно из окон
+func IsAvailable(item Item, shipDate time.Time, windows []CatalogWindow) bool {
+	listed := false
+	for i := range windows {
+		window := &windows[i]
+		if window.Variety != item.Variety || window.Length != item.Length || window.FarmName != item.FarmName {
+			continue
+		}
+		if window.Covers(shipDate) {
+			return true
+		}
+		listed = true
+	}
+	return !listed
+}
+
+// OrderFilter фильтр списка заказов; пустые поля не учитываются
+type OrderFilter struct {
+	Status OrderStatus
+	// ShipFrom и ShipTo ограничивают дату отправки включительно
+	ShipFrom *time.Time
+	ShipTo   *time.Time
+}
+
+// CheckSchedule проверяет даты заказа: дата доставки не раньше даты отправки,
+// а позиции, представленные в каталоге, доступны на дату отправки.
+// Позиции, которых нет в каталоге, по окнам не проверяются.
+func (o *Order) CheckSchedule(windows []CatalogWindow) error {
+	if o.DeliveryDate != nil {
+		if o.ShipDate == nil {
+			return fmt.Errorf("%w: delivery date requires a ship date", ErrValidation)
+		}
+		if truncateDate(*o.DeliveryDate).Before(truncateDate(*o.ShipDate)) {
+			return fmt.Errorf("%w: delivery date %s is before ship date %s", ErrValidation,
+				o.DeliveryDate.Format("2006-01-02"), o.ShipDate.Format("2006-01-02"))
+		}
+	}
+	if o.ShipDate == nil {
+		return nil
+	}
+
+	for _, item := range o.Items {
+		if !IsAvailable(item, *o.ShipDate, windows) {
+			return fmt.Errorf("%w: %s %dcm from %s is not available for ship date %s", ErrValidation,
+				item.Variety, item.Length, item.FarmName, o.ShipDate.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// FarmExportItem позиция заказа для выгрузки ферме
+type FarmExportItem struct {
+	OrderID      string     `json:"order_id"`
+	MarkBox      string     `json:"mark_box"`
+	ShipDate     *time.Time `json:"ship_date,omitempty"`
+	DeliveryDate *time.Time `json:"delivery_date,omitempty"`
+	Variety      string     `json:"variety"`
+	Length       int        `json:"length"`
+	BoxCount     float64    `json:"box_count"`
+	PackRate     int        `json:"pack_rate"`
+	TotalStems   int        `json:"total_stems"`
+	TruckName    string     `json:"truck_name"`
+	Comments     string     `json:"comments,omitempty"`
+}
+
+// FarmShipDate позиции фермы на одну дату отправки
+type FarmShipDate struct {
+	// ShipDate пустая для заказов без даты отправки
+	ShipDate   *time.Time       `json:"ship_date"`
+	TotalBoxes float64          `json:"total_boxes"`
+	TotalStems int              `json:"total_stems"`
+	Items      []FarmExportItem `json:"items"`
+}
+
+// FarmExport выгрузка заказов фермы, сгруппированная по датам отправки
+type FarmExport struct {
+	FarmID    string         `json:"farm_id"`
+	FarmName  string         `json:"farm_name"`
+	ShipDates []FarmShipDate `json:"ship_dates"`
+}
+
+// NewFarmExport группирует позиции по датам отправки; заказы без даты идут последними
+func NewFarmExport(farm *Farm, items []FarmExportItem) FarmExport {
+	export := FarmExport{FarmID: farm.ID, FarmName: farm.Name, ShipDates: []FarmShipDate{}}
+	index := make(map[string]int)
+	for _, item := range items {
+		k := ""
+		if item.ShipDate != nil {
+			k = item.ShipDate.Format("2006-01-02")
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(export.ShipDates)
+			index[k] = i
+			export.ShipDates = append(export.ShipDates, FarmShipDate{ShipDate: item.ShipDate})
+		}
+		group := &export.ShipDates[i]
+		group.TotalBoxes += item.BoxCount
+		group.TotalStems += item.TotalStems
+		group.Items = append(group.Items, item)
+	}
+
+	sort.SliceStable(export.ShipDates, func(i, j int) bool {
+		a, b := export.ShipDates[i].ShipDate, export.ShipDates[j].ShipDate
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+	return export
+}
+
+func truncateDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
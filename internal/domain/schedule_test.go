@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSchedule(t *testing.T) {
+	windows := []CatalogWindow{
+		{Variety: "Rose", Length: 50, FarmName: "Alpha", AvailableFrom: datePtr("2024-03-01"), AvailableTo: datePtr("2024-03-31")},
+		// второе окно той же позиции
+		{Variety: "Rose", Length: 50, FarmName: "Alpha", AvailableFrom: datePtr("2024-05-01")},
+		{Variety: "Tulip", Length: 40, FarmName: "Alpha", AvailableTo: datePtr("2024-04-15")},
+	}
+	rose := Item{Variety: "Rose", Length: 50, FarmName: "Alpha"}
+	tulip := Item{Variety: "Tulip", Length: 40, FarmName: "Alpha"}
+	// та же позиция другой фермы в каталоге не ограничена
+	otherFarm := Item{Variety: "Rose", Length: 50, FarmName: "Beta"}
+
+	tests := []struct {
+		name         string
+		shipDate     *time.Time
+		deliveryDate *time.Time
+		items        []Item
+		wantErr      string
+	}{
+		{name: "first day of the window", shipDate: datePtr("2024-03-01"), items: []Item{rose, tulip}},
+		{name: "last day of the window", shipDate: datePtr("2024-03-31"), items: []Item{rose}},
+		{
+			// время внутри дня не выводит дату за окно
+			name:     "late hour of the last day",
+			shipDate: timePtr(time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC)),
+			items:    []Item{rose},
+		},
+		{name: "second window", shipDate: datePtr("2024-06-01"), items: []Item{rose}},
+		{name: "between windows", shipDate: datePtr("2024-04-10"), items: []Item{rose}, wantErr: "Rose 50cm from Alpha"},
+		{name: "before the first window", shipDate: datePtr("2024-02-29"), items: []Item{rose}, wantErr: "Rose 50cm"},
+		{name: "after an open-start window", shipDate: datePtr("2024-04-16"), items: []Item{tulip}, wantErr: "Tulip 40cm"},
+		{name: "item without windows", shipDate: datePtr("2024-04-10"), items: []Item{otherFarm}},
+		{name: "no ship date", items: []Item{rose, tulip}},
+		{
+			name:         "delivery on the ship date",
+			shipDate:     datePtr("2024-03-10"),
+			deliveryDate: datePtr("2024-03-10"),
+			items:        []Item{rose},
+		},
+		{
+			name:         "delivery before ship date",
+			shipDate:     datePtr("2024-03-10"),
+			deliveryDate: datePtr("2024-03-09"),
+			items:        []Item{rose},
+			wantErr:      "delivery date 2024-03-09 is before ship date 2024-03-10",
+		},
+		{
+			name:         "delivery without ship date",
+			deliveryDate: datePtr("2024-03-09"),
+			items:        []Item{rose},
+			wantErr:      "delivery date requires a ship date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{ShipDate: tt.shipDate, DeliveryDate: tt.deliveryDate, Items: tt.items}
+			err := order.CheckSchedule(windows)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckSchedule: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckSchedule error = %v, want ErrValidation with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestNewFarmExport(t *testing.T) {
+	farm := &Farm{ID: "farm", Name: "Alpha"}
+	items := []FarmExportItem{
+		{OrderID: "no-date-1", BoxCount: 1, TotalStems: 100},
+		{OrderID: "later", ShipDate: datePtr("2024-03-12"), BoxCount: 2, TotalStems: 400},
+		{OrderID: "earlier", ShipDate: datePtr("2024-03-10"), BoxCount: 0.5, TotalStems: 125},
+		{OrderID: "no-date-2", BoxCount: 1.5, TotalStems: 300},
+		// тот же день, другое время: одна группа
+		{OrderID: "earlier-2", ShipDate: timePtr(time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)), BoxCount: 1, TotalStems: 250},
+	}
+
+	export := NewFarmExport(farm, items)
+	if export.FarmID != "farm" || export.FarmName != "Alpha" {
+		t.Errorf("export farm = %s %s", export.FarmID, export.FarmName)
+	}
+
+	got := make([]string, len(export.ShipDates))
+	for i, group := range export.ShipDates {
+		day := "none"
+		if group.ShipDate != nil {
+			day = group.ShipDate.Format("2006-01-02")
+		}
+		orders := make([]string, len(group.Items))
+		for j, item := range group.Items {
+			orders[j] = item.OrderID
+		}
+		got[i] = fmt.Sprintf("%s %g boxes %d stems %s", day, group.TotalBoxes, group.TotalStems, strings.Join(orders, "+"))
+	}
+	want := []string{
+		"2024-03-10 1.5 boxes 375 stems earlier+earlier-2",
+		"2024-03-12 2 boxes 400 stems later",
+		"none 2.5 boxes 400 stems no-date-1+no-date-2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ship dates:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNewFarmExportWithoutItems(t *testing.T) {
+	export := NewFarmExport(&Farm{ID: "farm", Name: "Alpha"}, nil)
+	if export.ShipDates == nil || len(export.ShipDates) != 0 {
+		t.Errorf("ShipDates = %v, want an empty list", export.ShipDates)
+	}
+}
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Shipment рейс грузовика с датой отправления и вместимостью в коробках
+type Shipment struct {
+	ID            string    `json:"id"`
+	TruckName     string    `json:"truck_name"`
+	DepartureDate time.Time `json:"departure_date"`
+	CapacityBoxes float64   `json:"capacity_boxes"`
+	Notes         string    `json:"notes,omitempty"`
+	// Items позиции заказов, загруженные в рейс
+	Items     []ShipmentItem `json:"items"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// ShipmentItem позиция заказа в рейсе
+type ShipmentItem struct {
+	ItemID     string  `json:"item_id"`
+	OrderID    string  `json:"order_id"`
+	MarkBox    string  `json:"mark_box"`
+	Variety    string  `json:"variety"`
+	Length     int     `json:"length"`
+	FarmName   string  `json:"farm_name"`
+	TruckName  string  `json:"truck_name"`
+	BoxCount   float64 `json:"box_count"`
+	TotalStems int     `json:"total_stems"`
+}
+
+// ShipmentFilter фильтр списка рейсов; пустые поля не учитываются
+type ShipmentFilter struct {
+	TruckName     string
+	DepartureDate *time.Time
+}
+
+// ShipmentRepository хранилище рейсов
+type ShipmentRepository interface {
+	CreateShipment(ctx context.Context, shipment *Shipment) error
+	GetShipment(ctx context.Context, id string) (*Shipment, error)
+	ListShipments(ctx context.Context, filter ShipmentFilter) ([]Shipment, error)
+	// AssignShipmentItems загружает позиции заказов в рейс с проверкой
+	// вместимости; позиция может быть только в одном рейсе
+	AssignShipmentItems(ctx context.Context, shipmentID string, itemIDs []string) (*Shipment, error)
+	RemoveShipmentItem(ctx context.Context, shipmentID, itemID string) error
+}
+
+// LoadedBoxes возвращает число коробок, уже загруженных в рейс
+func (s *Shipment) LoadedBoxes() float64 {
+	var boxes float64
+	for _, item := range s.Items {
+		boxes += item.BoxCount
+	}
+	return boxes
+}
+
+// Load добавляет позиции в рейс. Позиции должны быть назначены на грузовик рейса
+// и помещаться в оставшуюся вместимость.
+func (s *Shipment) Load(items []ShipmentItem) error {
+	loaded := make(map[string]bool, len(s.Items))
+	for _, item := range s.Items {
+		loaded[item.ItemID] = true
+	}
+
+	boxes := s.LoadedBoxes()
+	for _, item := range items {
+		if loaded[item.ItemID] {
+			return fmt.Errorf("%w: item %s is already in shipment", ErrConflict, item.ItemID)
+		}
+		if item.TruckName != s.TruckName {
+			return fmt.Errorf("%w: item %s is planned for %s, not %s",
+				ErrValidation, item.ItemID, item.TruckName, s.TruckName)
+		}
+		loaded[item.ItemID] = true
+		boxes += item.BoxCount
+	}
+	if boxes > s.CapacityBoxes {
+		return fmt.Errorf("%w: shipment capacity is %g boxes, requested load is %g boxes",
+			ErrConflict, s.CapacityBoxes, boxes)
+	}
+
+	s.Items = append(s.Items, items...)
+	return nil
+}
+
+// ShipmentManifest загрузочная ведомость рейса
+type ShipmentManifest struct {
+	ShipmentID    string            `json:"shipment_id"`
+	TruckName     string            `json:"truck_name"`
+	DepartureDate time.Time         `json:"departure_date"`
+	CapacityBoxes float64           `json:"capacity_boxes"`
+	TotalBoxes    float64           `json:"total_boxes"`
+	TotalStems    int               `json:"total_stems"`
+	MarkBoxes     []ManifestMarkBox `json:"mark_boxes"`
+	Varieties     []ManifestVariety `json:"varieties"`
+	Items         []ShipmentItem    `json:"items"`
+}
+
+// ManifestMarkBox коробки и стебли одного получателя (mark box)
+type ManifestMarkBox struct {
+	MarkBox string `json:"mark_box"`
+	// Consignee получатель из реестра кодов маркировки
+	Consignee *Consignee `json:"consignee,omitempty"`
+	Orders    int        `json:"orders"`
+	Boxes     float64    `json:"boxes"`
+	Stems     int        `json:"stems"`
+}
+
+// ManifestVariety коробки и стебли одного сорта и длины
+type ManifestVariety struct {
+	Variety string  `json:"variety"`
+	Length  int     `json:"length"`
+	Boxes   float64 `json:"boxes"`
+	Stems   int     `json:"stems"`
+}
+
+// Manifest собирает загрузочную ведомость: итоги по mark box и по сортам
+func (s *Shipment) Manifest() ShipmentManifest {
+	manifest := ShipmentManifest{
+		ShipmentID:    s.ID,
+		TruckName:     s.TruckName,
+		DepartureDate: s.DepartureDate,
+		CapacityBoxes: s.CapacityBoxes,
+		MarkBoxes:     []ManifestMarkBox{},
+		Varieties:     []ManifestVariety{},
+		Items:         s.Items,
+	}
+
+	type varietyKey struct {
+		variety string
+		length  int
+	}
+	markBoxes := make(map[string]*ManifestMarkBox)
+	markBoxOrders := make(map[string]map[string]bool)
+	varieties := make(map[varietyKey]*ManifestVariety)
+
+	for _, item := range s.Items {
+		manifest.TotalBoxes += item.BoxCount
+		manifest.TotalStems += item.TotalStems
+
+		markBox, ok := markBoxes[item.MarkBox]
+		if !ok {
+			markBox = &ManifestMarkBox{MarkBox: item.MarkBox}
+			markBoxes[item.MarkBox] = markBox
+			markBoxOrders[item.MarkBox] = make(map[string]bool)
+		}
+		markBox.Boxes += item.BoxCount
+		markBox.Stems += item.TotalStems
+		markBoxOrders[item.MarkBox][item.OrderID] = true
+
+		key := varietyKey{item.Variety, item.Length}
+		variety, ok := varieties[key]
+		if !ok {
+			variety = &ManifestVariety{Variety: item.Variety, Length: item.Length}
+			varieties[key] = variety
+		}
+		variety.Boxes += item.BoxCount
+		variety.Stems += item.TotalStems
+	}
+
+	for name, markBox := range markBoxes {
+		markBox.Orders = len(markBoxOrders[name])
+		manifest.MarkBoxes = append(manifest.MarkBoxes, *markBox)
+	}
+	sort.Slice(manifest.MarkBoxes, func(i, j int) bool {
+		return manifest.MarkBoxes[i].MarkBox < manifest.MarkBoxes[j].MarkBox
+	})
+
+	for _, variety := range varieties {
+		manifest.Varieties = append(manifest.Varieties, *variety)
+	}
+	sort.Slice(manifest.Varieties, func(i, j int) bool {
+		a, b := manifest.Varieties[i], manifest.Varieties[j]
+		if a.Variety != b.Variety {
+			return a.Variety < b.Variety
+		}
+		return a.Length < b.Length
+	})
+
+	return manifest
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestShipmentLoad(t *testing.T) {
+	loaded := []ShipmentItem{{ItemID: "loaded", TruckName: "North", BoxCount: 6}}
+
+	tests := []struct {
+		name       string
+		items      []ShipmentItem
+		wantErr    error
+		wantLoaded float64
+	}{
+		{
+			name:       "fits the remaining capacity",
+			items:      []ShipmentItem{{ItemID: "a", TruckName: "North", BoxCount: 2.5}},
+			wantLoaded: 8.5,
+		},
+		{
+			name: "fills the truck exactly",
+			items: []ShipmentItem{
+				{ItemID: "a", TruckName: "North", BoxCount: 2.5},
+				{ItemID: "b", TruckName: "North", BoxCount: 1.5},
+			},
+			wantLoaded: 10,
+		},
+		{
+			name:    "one item over the box limit",
+			items:   []ShipmentItem{{ItemID: "a", TruckName: "North", BoxCount: 4.25}},
+			wantErr: ErrConflict,
+		},
+		{
+			// каждая позиция помещается, но вместе они превышают вместимость
+			name: "items together over the box limit",
+			items: []ShipmentItem{
+				{ItemID: "a", TruckName: "North", BoxCount: 2},
+				{ItemID: "b", TruckName: "North", BoxCount: 2},
+				{ItemID: "c", TruckName: "North", BoxCount: 0.5},
+			},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "item planned for another truck",
+			items:   []ShipmentItem{{ItemID: "a", TruckName: "South", BoxCount: 1}},
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "item already in the shipment",
+			items:   []ShipmentItem{{ItemID: "loaded", TruckName: "North", BoxCount: 1}},
+			wantErr: ErrConflict,
+		},
+		{
+			name: "item repeated in the request",
+			items: []ShipmentItem{
+				{ItemID: "a", TruckName: "North", BoxCount: 1},
+				{ItemID: "a", TruckName: "North", BoxCount: 1},
+			},
+			wantErr: ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shipment := &Shipment{
+				TruckName:     "North",
+				CapacityBoxes: 10,
+				Items:         append([]ShipmentItem(nil), loaded...),
+			}
+			err := shipment.Load(tt.items)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load error = %v, want %v", err, tt.wantErr)
+				}
+				// отклоненная загрузка не меняет рейс
+				if len(shipment.Items) != len(loaded) {
+					t.Errorf("shipment has %d items after a rejected load, want %d", len(shipment.Items), len(loaded))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := shipment.LoadedBoxes(); got != tt.wantLoaded {
+				t.Errorf("LoadedBoxes = %v, want %v", got, tt.wantLoaded)
+			}
+		})
+	}
+}
+
+func TestShipmentManifest(t *testing.T) {
+	shipment := &Shipment{
+		ID:            "shipment",
+		TruckName:     "North",
+		CapacityBoxes: 20,
+		Items: []ShipmentItem{
+			{ItemID: "1", OrderID: "o1", MarkBox: "MB2", Variety: "Rose", Length: 60, BoxCount: 2, TotalStems: 400},
+			{ItemID: "2", OrderID: "o1", MarkBox: "MB2", Variety: "Rose", Length: 50, BoxCount: 1.5, TotalStems: 300},
+			{ItemID: "3", OrderID: "o2", MarkBox: "MB2", Variety: "Tulip", Length: 40, BoxCount: 1, TotalStems: 250},
+			{ItemID: "4", OrderID: "o3", MarkBox: "MB1", Variety: "Rose", Length: 50, BoxCount: 0.5, TotalStems: 100},
+		},
+	}
+
+	manifest := shipment.Manifest()
+	if manifest.TotalBoxes != 5 || manifest.TotalStems != 1050 || manifest.CapacityBoxes != 20 {
+		t.Errorf("totals = %v boxes, %d stems, capacity %v", manifest.TotalBoxes, manifest.TotalStems, manifest.CapacityBoxes)
+	}
+
+	markBoxes := make([]string, len(manifest.MarkBoxes))
+	for i, m := range manifest.MarkBoxes {
+		markBoxes[i] = fmt.Sprintf("%s:%d orders:%g boxes:%d stems", m.MarkBox, m.Orders, m.Boxes, m.Stems)
+	}
+	wantMarkBoxes := []string{"MB1:1 orders:0.5 boxes:100 stems", "MB2:2 orders:4.5 boxes:950 stems"}
+	if fmt.Sprint(markBoxes) != fmt.Sprint(wantMarkBoxes) {
+		t.Errorf("mark boxes = %v, want %v", markBoxes, wantMarkBoxes)
+	}
+
+	varieties := make([]string, len(manifest.Varieties))
+	for i, v := range manifest.Varieties {
+		varieties[i] = fmt.Sprintf("%s %d:%g boxes:%d stems", v.Variety, v.Length, v.Boxes, v.Stems)
+	}
+	wantVarieties := []string{"Rose 50:2 boxes:400 stems", "Rose 60:2 boxes:400 stems", "Tulip 40:1 boxes:250 stems"}
+	if fmt.Sprint(varieties) != fmt.Sprint(wantVarieties) {
+		t.Errorf("varieties = %v, want %v", varieties, wantVarieties)
+	}
+}
+
+func TestEmptyShipmentManifest(t *testing.T) {
+	manifest := (&Shipment{ID: "shipment", TruckName: "North", CapacityBoxes: 10}).Manifest()
+	if manifest.MarkBoxes == nil || manifest.Varieties == nil {
+		t.Error("empty manifest must have empty lists, not null")
+	}
+	if manifest.TotalBoxes != 0 || manifest.TotalStems != 0 {
+		t.Errorf("totals = %v boxes, %d stems, want zero", manifest.TotalBoxes, manifest.TotalStems)
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Категории товаров и услуг для налоговых правил
+const (
+	TaxCategoryFlowers  = "flowers"
+	TaxCategoryFreight  = "freight"
+	TaxCategoryServices = "services"
+)
+
+// TaxRule ставка налога для страны назначения и категории.
+// Правило без категории действует для всех категорий страны.
+type TaxRule struct {
+	ID       string `json:"id"`
+	Country  string `json:"country"`
+	Category string `json:"category,omitempty"`
+	// Rate ставка в процентах
+	Rate Rate `json:"rate"`
+	// ReverseCharge налог не начисляется, если у клиента есть VAT ID
+	// (B2B-поставки внутри ЕС); налог уплачивает покупатель
+	ReverseCharge bool      `json:"reverse_charge"`
+	Description   string    `json:"description,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TaxRuleRepository хранилище налоговых правил
+type TaxRuleRepository interface {
+	CreateTaxRule(ctx context.Context, rule *TaxRule) error
+	// ListTaxRules возвращает правила страны; пустая страна - все правила
+	ListTaxRules(ctx context.Context, country string) ([]TaxRule, error)
+	DeleteTaxRule(ctx context.Context, id string) error
+}
+
+// findTaxRule выбирает правило для категории: сначала точное, затем общее для страны
+func findTaxRule(rules []TaxRule, category string) *TaxRule {
+	var fallback *TaxRule
+	for i := range rules {
+		switch rules[i].Category {
+		case category:
+			return &rules[i]
+		case "":
+			fallback = &rules[i]
+		}
+	}
+	return fallback
+}
+
+// ApplyTaxRules фиксирует ставки налога для позиций и корректировок заказа
+// по правилам страны клиента. Строки без подходящего правила не облагаются.
+// Если правило допускает reverse charge и у клиента есть VAT ID, ставка равна нулю.
+func (o *Order) ApplyTaxRules(rules []TaxRule, vatID string) {
+	o.ReverseCharge = false
+	for i := range o.Items {
+		o.Items[i].TaxRate = o.taxRate(rules, vatID, o.Items[i].taxCategory())
+	}
+	for i := range o.Adjustments {
+		o.Adjustments[i].TaxRate = o.taxRate(rules, vatID, o.adjustmentTaxCategory(&o.Adjustments[i]))
+	}
+}
+
+// ApplyAdjustmentTaxRules фиксирует ставки только для корректировок с ID из ids,
+// например добавленных вручную после создания заказа. Ставки позиций и остальных
+// корректировок, зафиксированные ранее, не меняются.
+func (o *Order) ApplyAdjustmentTaxRules(rules []TaxRule, vatID string, ids []string) {
+	for _, id := range ids {
+		for i := range o.Adjustments {
+			if o.Adjustments[i].ID == id {
+				o.Adjustments[i].TaxRate = o.taxRate(rules, vatID, o.adjustmentTaxCategory(&o.Adjustments[i]))
+			}
+		}
+	}
+}
+
+// taxRate возвращает ставку для категории; при reverse charge отмечает заказ
+func (o *Order) taxRate(rules []TaxRule, vatID, category string) Rate {
+	rule := findTaxRule(rules, category)
+	if rule == nil {
+		return Rate{}
+	}
+	if rule.ReverseCharge && vatID != "" {
+		o.ReverseCharge = true
+		return Rate{}
+	}
+	return rule.Rate
+}
+
+func (i *Item) taxCategory() string {
+	if i.Category == "" {
+		return TaxCategoryFlowers
+	}
+	return i.Category
+}
+
+// adjustmentTaxCategory возвращает категорию корректировки: доставка и наценки
+// облагаются как услуги, скидка - как позиция, к которой она относится
+func (o *Order) adjustmentTaxCategory(adj *Adjustment) string {
+	switch adj.Kind {
+	case AdjustmentFreight:
+		return TaxCategoryFreight
+	case AdjustmentSurcharge:
+		return TaxCategoryServices
+	}
+	for i := range o.Items {
+		if o.Items[i].ID == adj.ItemID {
+			return o.Items[i].taxCategory()
+		}
+	}
+	return TaxCategoryFlowers
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyAdjustmentTaxRules(t *testing.T) {
+	oldRules := []TaxRule{{Country: "DE", Rate: mustRate(t, "7")}}
+	newRules := []TaxRule{{Country: "DE", Rate: mustRate(t, "19")}}
+
+	order := &Order{
+		Items: []Item{{ID: "item", Category: TaxCategoryFlowers}},
+		Adjustments: []Adjustment{
+			{ID: "freight", ItemID: "item", Kind: AdjustmentFreight, Source: AdjustmentSourceFreight},
+			{ID: "manual", Kind: AdjustmentSurcharge, Source: AdjustmentSourceManual},
+		},
+	}
+	order.ApplyTaxRules(oldRules, "")
+	order.ApplyAdjustmentTaxRules(newRules, "", []string{"manual"})
+
+	if got := order.Items[0].TaxRate.String(); got != "7" {
+		t.Errorf("item tax rate = %s, want 7", got)
+	}
+	if got := order.Adjustments[0].TaxRate.String(); got != "7" {
+		t.Errorf("freight tax rate = %s, want 7", got)
+	}
+	if got := order.Adjustments[1].TaxRate.String(); got != "19" {
+		t.Errorf("manual adjustment tax rate = %s, want 19", got)
+	}
+}
+
+func TestApplyTaxRules(t *testing.T) {
+	domestic := []TaxRule{
+		{Country: "DE", Category: TaxCategoryFlowers, Rate: mustRate(t, "7")},
+		{Country: "DE", Rate: mustRate(t, "19")},
+	}
+	intraEU := []TaxRule{{Country: "NL", Rate: mustRate(t, "21"), ReverseCharge: true}}
+	flowersOnly := []TaxRule{{Country: "AT", Category: TaxCategoryFlowers, Rate: mustRate(t, "10")}}
+
+	tests := []struct {
+		name  string
+		rules []TaxRule
+		vatID string
+		// ставки позиции, скидки на позицию, доставки и наценки на заказ
+		wantRates         []string
+		wantReverseCharge bool
+		wantTax           string
+	}{
+		{
+			name:      "domestic sale",
+			rules:     domestic,
+			wantRates: []string{"7", "7", "19", "19"},
+			// 7% с 90.00 + 19% с 20.00 и 9.00
+			wantTax: "11.81",
+		},
+		{
+			name:              "intra-EU B2B with VAT ID",
+			rules:             intraEU,
+			vatID:             "NL123456789B01",
+			wantRates:         []string{"0", "0", "0", "0"},
+			wantReverseCharge: true,
+			wantTax:           "0.00",
+		},
+		{
+			name:      "intra-EU sale without VAT ID",
+			rules:     intraEU,
+			wantRates: []string{"21", "21", "21", "21"},
+			wantTax:   "24.99",
+		},
+		{
+			name:      "domestic VAT ID does not trigger reverse charge",
+			rules:     domestic,
+			vatID:     "DE123456789",
+			wantRates: []string{"7", "7", "19", "19"},
+			wantTax:   "11.81",
+		},
+		{
+			name:      "no rule for the country",
+			wantRates: []string{"0", "0", "0", "0"},
+			wantTax:   "0.00",
+		},
+		{
+			name:      "no rule for services",
+			rules:     flowersOnly,
+			wantRates: []string{"10", "10", "0", "0"},
+			wantTax:   "9.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{
+				Currency: "EUR",
+				// остается от прошлого расчета и должен сбрасываться
+				ReverseCharge: true,
+				Items:         []Item{{ID: "item", TotalStems: 200, Price: mustMoney(t, "0.50")}},
+				Adjustments: []Adjustment{
+					{ID: "discount", ItemID: "item", Kind: AdjustmentDiscount, Method: AdjustmentPercent, Percent: mustRate(t, "10")},
+					{ID: "freight", Kind: AdjustmentFreight, Method: AdjustmentFixed, Value: mustMoney(t, "20.00")},
+					{ID: "surcharge", Kind: AdjustmentSurcharge, Method: AdjustmentPercent, Percent: mustRate(t, "10")},
+				},
+			}
+			order.ApplyTaxRules(tt.rules, tt.vatID)
+
+			got := []string{order.Items[0].TaxRate.String()}
+			for _, adj := range order.Adjustments {
+				got = append(got, adj.TaxRate.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantRates, ",") {
+				t.Errorf("tax rates = %v, want %v", got, tt.wantRates)
+			}
+			if order.ReverseCharge != tt.wantReverseCharge {
+				t.Errorf("ReverseCharge = %v, want %v", order.ReverseCharge, tt.wantReverseCharge)
+			}
+
+			if _, err := order.CalculateTotal(); err != nil {
+				t.Fatalf("CalculateTotal: %v", err)
+			}
+			if order.Totals.Tax.String() != tt.wantTax {
+				t.Errorf("tax = %s, want %s", order.Totals.Tax, tt.wantTax)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+
+// CreateAdjustmentRuleRequest представляет правило автоматической скидки
+// или наценки, например «5% скидки от 5000 стеблей».
+type CreateAdjustmentRuleRequest struct {
+	Name     string       `json:"name" binding:"required,min=1,max=200"`
+	Kind     string       `json:"kind" binding:"required,oneof=discount surcharge"`
+	Scope    string       `json:"scope,omitempty" binding:"omitempty,oneof=order line"`
+	Method   string       `json:"method" binding:"required,oneof=percent fixed"`
+	Percent  domain.Rate  `json:"percent"`
+	Value    domain.Money `json:"value"`
+	Currency string       `json:"currency,omitempty" binding:"omitempty,len=3"`
+	MinStems int          `json:"min_stems" binding:"min=0"`
+	Active   *bool        `json:"active,omitempty"`
+}
+
+// SaveFreightRateRequest представляет стоимость доставки одной коробки грузовиком.
+type SaveFreightRateRequest struct {
+	PerBox   domain.Money `json:"per_box"`
+	Currency string       `json:"currency,omitempty" binding:"omitempty,len=3"`
+}
+
+// AdjustmentRequest представляет ручную корректировку заказа.
+// Без item_id корректировка применяется ко всему заказу.
+type AdjustmentRequest struct {
+	ItemID      string       `json:"item_id,omitempty"`
+	Kind        string       `json:"kind" binding:"required,oneof=discount surcharge freight"`
+	Method      string       `json:"method" binding:"required,oneof=percent fixed"`
+	Percent     domain.Rate  `json:"percent"`
+	Value       domain.Money `json:"value"`
+	Currency    string       `json:"currency,omitempty" binding:"omitempty,len=3"`
+	Description string       `json:"description,omitempty" binding:"max=200"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+// CreateBoxTypeRequest представляет тип коробки фермы.
+type CreateBoxTypeRequest struct {
+	FarmName string `json:"farm_name" binding:"required,min=1,max=100"`
+	Code     string `json:"code" binding:"required,min=1,max=10"`
+	Name     string `json:"name,omitempty" binding:"max=100"`
+	// Size доля полной коробки: 1, 0.5, 0.25
+	Size          float64 `json:"size" binding:"required,gt=0,lte=1"`
+	LengthCm      float64 `json:"length_cm" binding:"required,gt=0"`
+	WidthCm       float64 `json:"width_cm" binding:"required,gt=0"`
+	HeightCm      float64 `json:"height_cm" binding:"required,gt=0"`
+	TareWeightKg  float64 `json:"tare_weight_kg" binding:"gte=0"`
+	GrossWeightKg float64 `json:"gross_weight_kg" binding:"required,gt=0,gtefield=TareWeightKg"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// CreateClaimRequest представляет рекламацию по позиции заказа.
+type CreateClaimRequest struct {
+	OrderID      string `json:"order_id" binding:"required,uuid"`
+	ItemID       string `json:"item_id" binding:"required,uuid"`
+	Reason       string `json:"reason" binding:"required,oneof=botrytis broken_heads short_length dehydration wrong_variety missing other"`
+	ClaimedStems int    `json:"claimed_stems" binding:"required,gt=0"`
+	Description  string `json:"description,omitempty" binding:"max=2000"`
+}
+
+// UpdateClaimStatusRequest представляет переход рекламации в новый статус.
+// ApprovedStems учитывается при одобрении; без него одобряются все заявленные стебли.
+type UpdateClaimStatusRequest struct {
+	Status        string `json:"status" binding:"required,oneof=under_review approved rejected closed"`
+	ApprovedStems int    `json:"approved_stems,omitempty" binding:"gte=0"`
+	Resolution    string `json:"resolution,omitempty" binding:"max=2000"`
+}
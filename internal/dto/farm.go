@@ -0,0 +1,16 @@
+package dto
+
+// SaveFarmRequest представляет ферму реестра.
+// Время отсечки cutoff_time задается в часовом поясе фермы time_zone (IANA).
+type SaveFarmRequest struct {
+	Name         string `json:"name" binding:"required,min=1,max=100"`
+	Email        string `json:"email,omitempty" binding:"omitempty,email,max=200"`
+	Phone        string `json:"phone,omitempty" binding:"max=50"`
+	Country      string `json:"country,omitempty" binding:"omitempty,len=2,alpha"`
+	TimeZone     string `json:"time_zone,omitempty" binding:"max=64"`
+	CutoffTime   string `json:"cutoff_time,omitempty" binding:"omitempty,datetime=15:04"`
+	LeadTimeDays int    `json:"lead_time_days" binding:"gte=0,lte=60"`
+	Notes        string `json:"notes,omitempty" binding:"max=500"`
+	// Active по умолчанию true; неактивную ферму нельзя указать в новых заказах
+	Active *bool `json:"active,omitempty"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+// CreditNoteRequest представляет запрос на сторнирование счета заказа.
+type CreditNoteRequest struct {
+	Reason string `json:"reason" binding:"required,min=1,max=500"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+// SaveMarkBoxRequest представляет код маркировки и получателя груза.
+// Без customer_id код доступен всем клиентам.
+type SaveMarkBoxRequest struct {
+	CustomerID         string     `json:"customer_id,omitempty"`
+	ConsigneeName      string     `json:"consignee_name" binding:"required,min=1,max=200"`
+	Address            AddressDTO `json:"address,omitempty"`
+	Phone              string     `json:"phone,omitempty" binding:"max=50"`
+	DestinationAirport string     `json:"destination_airport,omitempty" binding:"omitempty,len=3,alpha"`
+	Notes              string     `json:"notes,omitempty" binding:"max=500"`
+	// Active по умолчанию true; неактивный код нельзя указать в новых заказах
+	Active *bool `json:"active,omitempty"`
+}
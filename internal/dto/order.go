@@ -1,14 +1,23 @@
 package dto
 
+import "github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+
 // CreateOrderRequest представляет запрос на создание нового заказа.
 type CreateOrderRequest struct {
 	MarkBox    string                   `json:"mark_box" binding:"required,min=1,max=10"`
 	CustomerID string                   `json:"customer_id" binding:"required,min=1"`
 	Items      []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
-	Notes      string                   `json:"notes,omitempty"`
+	// Currency валюта счета; по умолчанию валюта клиента или USD
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3"`
+	// ShipDate дата отправки; если задана, проверяется время отсечки ферм позиций
+	ShipDate string `json:"ship_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	// DeliveryDate дата доставки клиенту; требует ship_date и не может быть раньше нее
+	DeliveryDate string `json:"delivery_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	Notes        string `json:"notes,omitempty"`
 }
 
 // CreateOrderItemRequest представляет элемент заказа в запросе на создание.
+// Цена не передается клиентом: она берется из прайс-листа.
 type CreateOrderItemRequest struct {
 	Variety    string  `json:"variety" binding:"required,min=1,max=100"`
 	Length     int     `json:"length" binding:"required,min=1,max=200"`
@@ -18,5 +27,62 @@ type CreateOrderItemRequest struct {
 	FarmName   string  `json:"farm_name" binding:"required,min=1,max=100"`
 	TruckName  string  `json:"truck_name" binding:"required,min=1,max=100"`
 	Comments   string  `json:"comments,omitempty"`
-	Price      float64 `json:"price,omitempty" binding:"gte=0"`
+	// Category категория товара для налоговых правил, по умолчанию flowers
+	Category string `json:"category,omitempty" binding:"omitempty,oneof=flowers freight services"`
+}
+
+// UpdateOrderRequest представляет частичное обновление заказа специалистом.
+// Незаданные поля не изменяются.
+type UpdateOrderRequest struct {
+	Status  *string                  `json:"status,omitempty"`
+	MarkBox *string                  `json:"mark_box,omitempty" binding:"omitempty,min=1,max=10"`
+	Notes   *string                  `json:"notes,omitempty"`
+	Items   []UpdateOrderItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+	// Adjustments заменяет ручные корректировки заказа; автоматические сохраняются
+	Adjustments *[]AdjustmentRequest `json:"adjustments,omitempty" binding:"omitempty,dive"`
+}
+
+// UpdateOrderItemRequest представляет изменение позиции заказа.
+type UpdateOrderItemRequest struct {
+	ID       string        `json:"id" binding:"required"`
+	Price    *domain.Money `json:"price,omitempty"`
+	Comments *string       `json:"comments,omitempty"`
+}
+
+// CatalogWindowRequest представляет окно доступности сорта и длины фермы в каталоге.
+// Пустые даты снимают ограничение.
+type CatalogWindowRequest struct {
+	Variety       string `json:"variety" binding:"required,min=1,max=100"`
+	Length        int    `json:"length" binding:"required,min=1,max=200"`
+	FarmName      string `json:"farm_name" binding:"required,min=1,max=100"`
+	AvailableFrom string `json:"available_from,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	AvailableTo   string `json:"available_to,omitempty" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// DuplicateOrderRequest представляет повтор существующего заказа.
+// Незаданные поля берутся из исходного заказа; даты отправки и доставки не копируются.
+type DuplicateOrderRequest struct {
+	MarkBox      *string `json:"mark_box,omitempty" binding:"omitempty,min=1,max=10"`
+	ShipDate     string  `json:"ship_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	DeliveryDate string  `json:"delivery_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	Notes        *string `json:"notes,omitempty"`
+	// Items изменяет количества позиций исходного заказа
+	Items []DuplicateOrderItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+// DuplicateOrderItemRequest представляет новое количество позиции исходного заказа.
+// Если total_stems не задан, число стеблей меняется пропорционально числу коробок.
+type DuplicateOrderItemRequest struct {
+	ID string `json:"id" binding:"required"`
+	// BoxCount новое число коробок; 0 исключает позицию из нового заказа
+	BoxCount   *float64 `json:"box_count" binding:"required,gte=0"`
+	TotalStems *int     `json:"total_stems,omitempty" binding:"omitempty,min=1"`
+}
+
+// DuplicateOrderResponse представляет новый заказ и позиции исходного заказа,
+// которые не перенесены: нет цены, ферма не принимает заказ на дату отправки
+// или позиция недоступна в каталоге.
+type DuplicateOrderResponse struct {
+	Order            *domain.Order `json:"order"`
+	UnavailableItems []domain.Item `json:"unavailable_items"`
 }
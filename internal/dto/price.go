@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// CreatePriceRequest представляет запрос на добавление цены в прайс-лист.
+// Без customer_tier и customer_id цена считается базовой.
+type CreatePriceRequest struct {
+	Variety  string `json:"variety" binding:"required,min=1,max=100"`
+	Length   int    `json:"length" binding:"required,min=1,max=200"`
+	FarmName string `json:"farm_name" binding:"required,min=1,max=100"`
+	// Price цена за стебель, обязательна и должна быть больше нуля
+	Price        *domain.Money `json:"price" binding:"required"`
+	Currency     string        `json:"currency,omitempty" binding:"omitempty,len=3"`
+	CustomerTier string        `json:"customer_tier,omitempty" binding:"max=50,excluded_with=CustomerID"`
+	CustomerID   string        `json:"customer_id,omitempty"`
+	ValidFrom    string        `json:"valid_from" binding:"required,datetime=2006-01-02"`
+	ValidTo      string        `json:"valid_to,omitempty" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// PricePreviewResponse представляет цену, которую получит клиент.
+type PricePreviewResponse struct {
+	CustomerID string       `json:"customer_id,omitempty"`
+	Variety    string       `json:"variety"`
+	Length     int          `json:"length"`
+	FarmName   string       `json:"farm_name"`
+	Date       string       `json:"date"`
+	Price      domain.Money `json:"price"`
+	Currency   string       `json:"currency"`
+	Source     string       `json:"source"`
+	PriceID    string       `json:"price_id"`
+}
+
+// SaveCustomerRequest представляет данные клиента.
+type SaveCustomerRequest struct {
+	Name     string     `json:"name" binding:"required,min=1,max=200"`
+	Email    string     `json:"email,omitempty" binding:"omitempty,email"`
+	Phone    string     `json:"phone,omitempty"`
+	Company  string     `json:"company,omitempty"`
+	Address  AddressDTO `json:"address,omitempty"`
+	Tier     string     `json:"tier,omitempty" binding:"max=50"`
+	Currency string     `json:"currency,omitempty" binding:"omitempty,len=3"`
+	VATID    string     `json:"vat_id,omitempty" binding:"max=20"`
+}
+
+// CreateExchangeRateRequest представляет курс валюты. Без effective_at
+// курс действует с момента добавления.
+type CreateExchangeRateRequest struct {
+	FromCurrency string      `json:"from_currency" binding:"required,len=3"`
+	ToCurrency   string      `json:"to_currency" binding:"required,len=3,nefield=FromCurrency"`
+	Rate         domain.Rate `json:"rate"`
+	EffectiveAt  time.Time   `json:"effective_at,omitempty"`
+}
+
+// AddressDTO представляет адрес клиента.
+type AddressDTO struct {
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty" binding:"omitempty,len=2"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+// BoxScanRequest представляет сканирование кода коробки при приемке.
+type BoxScanRequest struct {
+	Code string `json:"code" binding:"required,min=1,max=64"`
+}
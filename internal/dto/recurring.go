@@ -0,0 +1,17 @@
+package dto
+
+// SaveRecurringOrderRequest представляет шаблон постоянного заказа.
+// Для frequency=weekly нужны дни недели weekdays, для interval - период interval_days.
+type SaveRecurringOrderRequest struct {
+	CustomerID   string                   `json:"customer_id" binding:"required,min=1"`
+	MarkBox      string                   `json:"mark_box" binding:"required,min=1,max=10"`
+	Currency     string                   `json:"currency,omitempty" binding:"omitempty,len=3"`
+	Notes        string                   `json:"notes,omitempty"`
+	Frequency    string                   `json:"frequency" binding:"required,oneof=weekly interval"`
+	Weekdays     []string                 `json:"weekdays,omitempty" binding:"omitempty,dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	IntervalDays int                      `json:"interval_days,omitempty" binding:"omitempty,min=1,max=365"`
+	StartDate    string                   `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate      string                   `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	SkipDates    []string                 `json:"skip_dates,omitempty" binding:"omitempty,dive,datetime=2006-01-02"`
+	Items        []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
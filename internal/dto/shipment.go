@@ -0,0 +1,14 @@
+package dto
+
+// CreateShipmentRequest представляет рейс грузовика.
+type CreateShipmentRequest struct {
+	TruckName     string  `json:"truck_name" binding:"required,min=1,max=100"`
+	DepartureDate string  `json:"departure_date" binding:"required,datetime=2006-01-02"`
+	CapacityBoxes float64 `json:"capacity_boxes" binding:"required,gt=0"`
+	Notes         string  `json:"notes,omitempty" binding:"max=500"`
+}
+
+// AssignShipmentItemsRequest представляет загрузку позиций заказов в рейс.
+type AssignShipmentItemsRequest struct {
+	ItemIDs []string `json:"item_ids" binding:"required,min=1,dive,uuid"`
+}
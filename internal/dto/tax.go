@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+
+// CreateTaxRuleRequest представляет ставку налога для страны назначения.
+// Без category ставка действует для всех категорий страны.
+type CreateTaxRuleRequest struct {
+	Country       string      `json:"country" binding:"required,len=2"`
+	Category      string      `json:"category,omitempty" binding:"omitempty,oneof=flowers freight services"`
+	Rate          domain.Rate `json:"rate"`
+	ReverseCharge bool        `json:"reverse_charge"`
+	Description   string      `json:"description,omitempty" binding:"max=200"`
+}
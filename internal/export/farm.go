@@ -0,0 +1,72 @@
+package export
+
+import (
+	"github.com/xuri/excelize/v2"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// unscheduledSheet лист для заказов без даты отправки
+const unscheduledSheet = "Unscheduled"
+
+// FarmExportXLSX формирует выгрузку заказов фермы: отдельный лист на каждую
+// дату отправки с позициями и итогами
+func FarmExportXLSX(farmExport *domain.FarmExport) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	summary := [][]interface{}{
+		{"Farm", farmExport.FarmName},
+		{},
+		{"Ship date", "Boxes", "Stems", "Lines"},
+	}
+	for _, group := range farmExport.ShipDates {
+		summary = append(summary, []interface{}{
+			shipDateSheet(group), group.TotalBoxes, group.TotalStems, len(group.Items),
+		})
+	}
+	if err := f.SetSheetName("Sheet1", "Summary"); err != nil {
+		return nil, err
+	}
+	if err := writeSheet(f, "Summary", summary); err != nil {
+		return nil, err
+	}
+
+	for _, group := range farmExport.ShipDates {
+		rows := [][]interface{}{{
+			"Mark box", "Order", "Variety", "Length", "Boxes", "Pack rate", "Stems", "Truck", "Delivery", "Comments",
+		}}
+		for _, item := range group.Items {
+			delivery := ""
+			if item.DeliveryDate != nil {
+				delivery = item.DeliveryDate.Format("2006-01-02")
+			}
+			rows = append(rows, []interface{}{
+				item.MarkBox, item.OrderID, item.Variety, item.Length, item.BoxCount, item.PackRate, item.TotalStems,
+				item.TruckName, delivery, item.Comments,
+			})
+		}
+		rows = append(rows, []interface{}{"Total", "", "", "", group.TotalBoxes, "", group.TotalStems})
+
+		sheet := shipDateSheet(group)
+		if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+		if err := writeSheet(f, sheet, rows); err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func shipDateSheet(group domain.FarmShipDate) string {
+	if group.ShipDate == nil {
+		return unscheduledSheet
+	}
+	return group.ShipDate.Format("2006-01-02")
+}
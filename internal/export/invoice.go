@@ -0,0 +1,241 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+const (
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	ContentTypePDF  = "application/pdf"
+)
+
+// reverseChargeNote пометка для счетов без НДС по механизму reverse charge
+const reverseChargeNote = "Reverse charge: VAT to be accounted for by the recipient (Art. 196 Directive 2006/112/EC)"
+
+// invoiceTitle возвращает заголовок документа
+func invoiceTitle(inv *domain.Invoice) string {
+	if inv.Type == domain.InvoiceTypeCreditNote {
+		return "Credit note " + inv.Number
+	}
+	return "Invoice " + inv.Number
+}
+
+// billingLines возвращает адрес плательщика построчно, без пустых строк
+func billingLines(inv *domain.Invoice) []string {
+	return addressLines(inv.CustomerName, inv.BillingAddress)
+}
+
+// consigneeLines возвращает получателя груза построчно: название, адрес,
+// телефон и аэропорт назначения
+func consigneeLines(consignee *domain.Consignee) []string {
+	if consignee == nil {
+		return nil
+	}
+	lines := addressLines(consignee.Name, consignee.Address)
+	if consignee.Phone != "" {
+		lines = append(lines, "Phone: "+consignee.Phone)
+	}
+	if consignee.DestinationAirport != "" {
+		lines = append(lines, "Destination: "+consignee.DestinationAirport)
+	}
+	return lines
+}
+
+func addressLines(name string, address domain.Address) []string {
+	lines := []string{name, address.Street,
+		strings.TrimSpace(strings.Join([]string{address.PostalCode, address.City, address.State}, " ")),
+		address.Country}
+
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// InvoiceXLSX формирует счет в формате Excel
+func InvoiceXLSX(inv *domain.Invoice) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Invoice"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	rows := [][]interface{}{
+		{invoiceTitle(inv)},
+		{"Issued", inv.IssuedAt.Format("2006-01-02")},
+		{"Order", inv.OrderID},
+		{"Mark box", inv.MarkBox},
+		{"Customer", inv.CustomerID},
+	}
+	for _, line := range billingLines(inv) {
+		rows = append(rows, []interface{}{"", line})
+	}
+	if inv.VATID != "" {
+		rows = append(rows, []interface{}{"VAT ID", inv.VATID})
+	}
+	for i, line := range consigneeLines(inv.Consignee) {
+		label := ""
+		if i == 0 {
+			label = "Consignee"
+		}
+		rows = append(rows, []interface{}{label, line})
+	}
+	if inv.Reason != "" {
+		rows = append(rows, []interface{}{"Reason", inv.Reason})
+	}
+	rows = append(rows, []interface{}{}, []interface{}{
+		"#", "Description", "Stems", "Unit price", "Price currency",
+		"Amount " + inv.Currency, "Tax %", "Tax " + inv.Currency, "Total " + inv.Currency,
+	})
+	for _, line := range inv.Lines {
+		row := []interface{}{line.Position, line.Description, nil, nil, line.PriceCurrency,
+			line.Amount.Float64(), line.TaxRate.String(), line.TaxAmount.Float64(), line.Total.Float64()}
+		if line.Stems > 0 {
+			row[2] = line.Stems
+			row[3] = line.UnitPrice.Float64()
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows,
+		[]interface{}{},
+		[]interface{}{"", "Subtotal", nil, nil, nil, inv.Subtotal.Float64()},
+		[]interface{}{"", "Tax", nil, nil, nil, nil, nil, inv.TaxTotal.Float64()},
+		[]interface{}{"", "Total " + inv.Currency, nil, nil, nil, nil, nil, nil, inv.Total.Float64()},
+	)
+	if inv.ReverseCharge {
+		rows = append(rows, []interface{}{}, []interface{}{"", reverseChargeNote})
+	}
+
+	if err := writeSheet(f, sheet, rows); err != nil {
+		return nil, err
+	}
+
+	moneyStyle, err := f.NewStyle(&excelize.Style{NumFmt: 4})
+	if err != nil {
+		return nil, err
+	}
+	if err := f.SetColStyle(sheet, "D", moneyStyle); err != nil {
+		return nil, err
+	}
+	if err := f.SetColStyle(sheet, "F:I", moneyStyle); err != nil {
+		return nil, err
+	}
+	if err := f.SetColWidth(sheet, "B", "B", 40); err != nil {
+		return nil, err
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// InvoicePDF формирует счет в формате PDF. Встроенные шрифты PDF
+// поддерживают только латиницу (cp1252).
+func InvoicePDF(inv *domain.Invoice) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(invoiceTitle(inv), true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr(invoiceTitle(inv)), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	header := []string{
+		"Issued: " + inv.IssuedAt.Format("2006-01-02"),
+		"Order: " + inv.OrderID,
+		"Mark box: " + inv.MarkBox,
+		"Customer: " + inv.CustomerID,
+	}
+	if inv.Reason != "" {
+		header = append(header, "Reason: "+inv.Reason)
+	}
+	for _, line := range header {
+		pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+	for _, line := range billingLines(inv) {
+		pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+	}
+	if inv.VATID != "" {
+		pdf.CellFormat(0, 5, tr("VAT ID: "+inv.VATID), "", 1, "L", false, 0, "")
+	}
+	if lines := consigneeLines(inv.Consignee); len(lines) > 0 {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(0, 5, "Consignee", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		for _, line := range lines {
+			pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+		}
+	}
+	pdf.Ln(5)
+
+	// Ширина области печати A4 с полями по 10 мм - 190 мм
+	widths := []float64{8, 66, 16, 20, 22, 16, 20, 22}
+	pdf.SetFont("Helvetica", "B", 9)
+	titles := []string{"#", "Description", "Stems", "Unit price", "Amount", "Tax %", "Tax", "Total"}
+	for i, title := range titles {
+		pdf.CellFormat(widths[i], 7, title, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range inv.Lines {
+		stems, unitPrice := "", ""
+		if line.Stems > 0 {
+			stems = fmt.Sprint(line.Stems)
+			unitPrice = line.UnitPrice.String() + " " + line.PriceCurrency
+		}
+		cells := []string{fmt.Sprint(line.Position), tr(line.Description), stems, unitPrice,
+			line.Amount.String(), line.TaxRate.String(), line.TaxAmount.String(), line.Total.String()}
+		for i, cell := range cells {
+			align := "R"
+			if i == 1 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 6, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(3)
+	pdf.SetFont("Helvetica", "", 10)
+	totals := [][2]string{
+		{"Subtotal", inv.Subtotal.String()},
+		{"Tax", inv.TaxTotal.String()},
+		{"Total " + inv.Currency, inv.Total.String()},
+	}
+	for i, total := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont("Helvetica", "B", 10)
+		}
+		pdf.CellFormat(148, 6, total[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(42, 6, total[1], "", 1, "R", false, 0, "")
+	}
+	if inv.ReverseCharge {
+		pdf.Ln(5)
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.MultiCell(0, 5, reverseChargeNote, "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
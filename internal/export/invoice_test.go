@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+func testInvoice(t *testing.T) *domain.Invoice {
+	t.Helper()
+	money := func(value string) domain.Money {
+		m, err := domain.ParseMoney(value)
+		if err != nil {
+			t.Fatalf("ParseMoney(%q): %v", value, err)
+		}
+		return m
+	}
+	return &domain.Invoice{
+		Number:         "INV-2025-000001",
+		Type:           domain.InvoiceTypeInvoice,
+		OrderID:        "order",
+		CustomerID:     "customer",
+		CustomerName:   "Blumen GmbH",
+		BillingAddress: domain.Address{Street: "Hauptstraße 1", City: "Berlin", PostalCode: "10115", Country: "DE"},
+		VATID:          "DE123456789",
+		MarkBox:        "MB1",
+		Consignee:      &domain.Consignee{Name: "Blumen Lager", Phone: "+49 30 123", DestinationAirport: "BER"},
+		Currency:       "EUR",
+		ReverseCharge:  true,
+		Lines: []domain.InvoiceLine{
+			{
+				Position: 1, Description: "Rose Explorer 50cm (Alpha)", Stems: 200, UnitPrice: money("0.50"),
+				PriceCurrency: "EUR", Amount: money("100.00"), Total: money("100.00"),
+			},
+			{Position: 2, Description: "Freight North", Amount: money("15.00"), Total: money("15.00")},
+		},
+		Subtotal: money("115.00"),
+		Total:    money("115.00"),
+		IssuedAt: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestInvoicePDF(t *testing.T) {
+	invoice := testInvoice(t)
+	note, err := invoice.CreditNote("wrong price", invoice.IssuedAt)
+	if err != nil {
+		t.Fatalf("CreditNote: %v", err)
+	}
+	note.Number = "CN-2025-000001"
+
+	for _, inv := range []*domain.Invoice{invoice, note} {
+		t.Run(inv.Number, func(t *testing.T) {
+			data, err := InvoicePDF(inv)
+			if err != nil {
+				t.Fatalf("InvoicePDF: %v", err)
+			}
+			if !bytes.HasPrefix(data, []byte("%PDF-")) {
+				t.Errorf("output is not a PDF: %q", data[:min(len(data), 16)])
+			}
+		})
+	}
+}
+
+func TestInvoiceXLSX(t *testing.T) {
+	data, err := InvoiceXLSX(testInvoice(t))
+	if err != nil {
+		t.Fatalf("InvoiceXLSX: %v", err)
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows("Invoice")
+	if err != nil {
+		t.Fatalf("GetRows: %v", err)
+	}
+	if len(rows) == 0 || rows[0][0] != "Invoice INV-2025-000001" {
+		t.Fatalf("first row = %v, want the invoice title", rows[:min(len(rows), 1)])
+	}
+
+	var hasConsignee, hasReverseCharge bool
+	for _, row := range rows {
+		for _, cell := range row {
+			switch cell {
+			case "Blumen Lager":
+				hasConsignee = true
+			case reverseChargeNote:
+				hasReverseCharge = true
+			}
+		}
+	}
+	if !hasConsignee || !hasReverseCharge {
+		t.Errorf("consignee %v, reverse charge note %v, want both", hasConsignee, hasReverseCharge)
+	}
+}
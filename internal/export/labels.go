@@ -0,0 +1,119 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/go-pdf/fpdf/contrib/barcode"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// ContentTypeZPL MIME-тип команд для термопринтеров Zebra
+const ContentTypeZPL = "application/zpl"
+
+// Сетка этикеток на листе A4: 2 x 4 этикетки 105 x 74 мм
+const (
+	labelColumns = 2
+	labelRows    = 4
+	labelWidth   = 105.0
+	labelHeight  = 74.0
+)
+
+// labelTitle возвращает сорт и длину для этикетки
+func labelTitle(label domain.BoxLabel) string {
+	return fmt.Sprintf("%s %dcm", label.Variety, label.Length)
+}
+
+// labelBox возвращает номер коробки, для неполной коробки - с ее размером
+func labelBox(label domain.BoxLabel) string {
+	box := fmt.Sprintf("Box %d/%d", label.Box, label.Boxes)
+	if label.Size < 1 {
+		box += fmt.Sprintf(" (%g)", label.Size)
+	}
+	return box
+}
+
+// LabelsPDF формирует лист этикеток коробок со штрихкодом Code 128
+func LabelsPDF(labels []domain.BoxLabel) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Box labels", true)
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+
+	for i, label := range labels {
+		position := i % (labelColumns * labelRows)
+		if position == 0 {
+			pdf.AddPage()
+		}
+		x := float64(position%labelColumns) * labelWidth
+		y := float64(position/labelColumns) * labelHeight
+
+		pdf.SetDrawColor(180, 180, 180)
+		pdf.Rect(x+2, y+2, labelWidth-4, labelHeight-4, "D")
+
+		pdf.SetXY(x+6, y+6)
+		pdf.SetFont("Helvetica", "B", 22)
+		pdf.CellFormat(60, 10, tr(label.MarkBox), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(labelWidth-72, 10, labelBox(label), "", 1, "R", false, 0, "")
+
+		lines := []string{
+			labelTitle(label),
+			fmt.Sprintf("Stems: %d", label.Stems),
+			"Farm: " + label.FarmName,
+			"Order: " + label.OrderID,
+		}
+		for j, line := range lines {
+			style, size := "", 9.0
+			if j == 0 {
+				style, size = "B", 13
+			}
+			pdf.SetFont("Helvetica", style, size)
+			pdf.SetX(x + 6)
+			pdf.CellFormat(labelWidth-12, 6, tr(line), "", 1, "L", false, 0, "")
+		}
+
+		key := barcode.RegisterCode128(pdf, label.Code)
+		barcode.Barcode(pdf, key, x+6, y+labelHeight-26, labelWidth-12, 14, false)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetXY(x+6, y+labelHeight-11)
+		pdf.CellFormat(labelWidth-12, 4, label.Code, "", 0, "C", false, 0, "")
+	}
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// zplReplacer убирает управляющие символы ZPL из текста полей
+var zplReplacer = strings.NewReplacer("^", " ", "~", " ")
+
+// LabelsZPL формирует этикетки 4x6 дюйма (203 dpi) для термопринтеров Zebra
+func LabelsZPL(labels []domain.BoxLabel) []byte {
+	var b strings.Builder
+	for _, label := range labels {
+		field := func(x, y, height int, text string) {
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", x, y, height, height, zplReplacer.Replace(text))
+		}
+
+		b.WriteString("^XA\n^CI28\n^PW812\n^LL1218\n")
+		field(40, 40, 90, label.MarkBox)
+		field(500, 60, 40, labelBox(label))
+		field(40, 170, 55, labelTitle(label))
+		field(40, 250, 40, fmt.Sprintf("Stems: %d", label.Stems))
+		field(40, 310, 40, "Farm: "+label.FarmName)
+		field(40, 370, 28, "Order: "+label.OrderID)
+		fmt.Fprintf(&b, "^FO60,460^BY3^BCN,220,Y,N,N^FD%s^FS\n", label.Code)
+		b.WriteString("^XZ\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// ManifestXLSX формирует загрузочную ведомость рейса: сводка по mark box,
+// сводка по сортам и список позиций на отдельных листах
+func ManifestXLSX(manifest *domain.ShipmentManifest) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	summary := [][]interface{}{
+		{"Truck", manifest.TruckName},
+		{"Departure", manifest.DepartureDate.Format("2006-01-02")},
+		{"Capacity, boxes", manifest.CapacityBoxes},
+		{"Loaded, boxes", manifest.TotalBoxes},
+		{"Stems", manifest.TotalStems},
+		{},
+		{"Mark box", "Orders", "Boxes", "Stems", "Consignee", "Destination", "Address", "Phone"},
+	}
+	for _, markBox := range manifest.MarkBoxes {
+		row := []interface{}{markBox.MarkBox, markBox.Orders, markBox.Boxes, markBox.Stems}
+		if consignee := markBox.Consignee; consignee != nil {
+			address := addressLines("", consignee.Address)
+			row = append(row, consignee.Name, consignee.DestinationAirport, strings.Join(address, ", "),
+				consignee.Phone)
+		}
+		summary = append(summary, row)
+	}
+
+	varieties := [][]interface{}{{"Variety", "Length", "Boxes", "Stems"}}
+	for _, variety := range manifest.Varieties {
+		varieties = append(varieties, []interface{}{variety.Variety, variety.Length, variety.Boxes, variety.Stems})
+	}
+
+	items := [][]interface{}{{"Mark box", "Order", "Variety", "Length", "Farm", "Boxes", "Stems"}}
+	for _, item := range manifest.Items {
+		items = append(items, []interface{}{
+			item.MarkBox, item.OrderID, item.Variety, item.Length, item.FarmName, item.BoxCount, item.TotalStems,
+		})
+	}
+
+	if err := f.SetSheetName("Sheet1", "Mark boxes"); err != nil {
+		return nil, err
+	}
+	if err := writeSheet(f, "Mark boxes", summary); err != nil {
+		return nil, err
+	}
+	for _, sheet := range []struct {
+		name string
+		rows [][]interface{}
+	}{{"Varieties", varieties}, {"Items", items}} {
+		if _, err := f.NewSheet(sheet.name); err != nil {
+			return nil, err
+		}
+		if err := writeSheet(f, sheet.name, sheet.rows); err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeSheet записывает строки на лист начиная с A1
+func writeSheet(f *excelize.File, sheet string, rows [][]interface{}) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
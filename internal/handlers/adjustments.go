@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type AdjustmentHandler struct {
+	adjustmentService *services.AdjustmentService
+}
+
+func NewAdjustmentHandler(adjustmentService *services.AdjustmentService) *AdjustmentHandler {
+	return &AdjustmentHandler{
+		adjustmentService: adjustmentService,
+	}
+}
+
+func (h *AdjustmentHandler) CreateRule(c *gin.Context) {
+	var req dto.CreateAdjustmentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	rule, err := h.adjustmentService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create adjustment rule: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *AdjustmentHandler) ListRules(c *gin.Context) {
+	rules, err := h.adjustmentService.ListRules(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list adjustment rules: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"rules": rules,
+		},
+	)
+}
+
+func (h *AdjustmentHandler) DeleteRule(c *gin.Context) {
+	if err := h.adjustmentService.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		RespondError(c, statusFromError(err), "Failed to delete adjustment rule: "+err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdjustmentHandler) ListFreightRates(c *gin.Context) {
+	rates, err := h.adjustmentService.ListFreightRates(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list freight rates: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"freight_rates": rates,
+		},
+	)
+}
+
+// SaveFreightRate задает тариф доставки: PUT /freight-rates/:truck
+func (h *AdjustmentHandler) SaveFreightRate(c *gin.Context) {
+	var req dto.SaveFreightRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	rate, err := h.adjustmentService.SaveFreightRate(c.Request.Context(), c.Param("truck"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to save freight rate: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
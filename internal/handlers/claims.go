@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type ClaimHandler struct {
+	claimService *services.ClaimService
+}
+
+func NewClaimHandler(claimService *services.ClaimService) *ClaimHandler {
+	return &ClaimHandler{
+		claimService: claimService,
+	}
+}
+
+func (h *ClaimHandler) CreateClaim(c *gin.Context) {
+	var req dto.CreateClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	claim, err := h.claimService.CreateClaim(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create claim: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, claim)
+}
+
+// ListClaims возвращает рекламации, с фильтрами ?order_id=, ?farm_name=, ?status=
+// и периодом создания ?from=&to=
+func (h *ClaimHandler) ListClaims(c *gin.Context) {
+	from, to, ok := parseClaimPeriod(c)
+	if !ok {
+		return
+	}
+	filter := domain.ClaimFilter{
+		OrderID:  c.Query("order_id"),
+		FarmName: c.Query("farm_name"),
+		Status:   domain.ClaimStatus(c.Query("status")),
+		From:     from,
+		To:       to,
+	}
+
+	claims, err := h.claimService.ListClaims(c.Request.Context(), filter)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list claims: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"claims": claims,
+		},
+	)
+}
+
+func (h *ClaimHandler) GetClaim(c *gin.Context) {
+	claim, err := h.claimService.GetClaim(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get claim: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// UpdateStatus переводит рекламацию в новый статус: POST /claims/:id/status
+func (h *ClaimHandler) UpdateStatus(c *gin.Context) {
+	var req dto.UpdateClaimStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	claim, err := h.claimService.UpdateStatus(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to update claim: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// UploadPhoto принимает фотографию в поле photo формы multipart/form-data:
+// POST /claims/:id/photos
+func (h *ClaimHandler) UploadPhoto(c *gin.Context) {
+	header, err := c.FormFile("photo")
+	if err != nil {
+		respondBindError(c, err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "Failed to read photo: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	photo, err := h.claimService.AddPhoto(c.Request.Context(), c.Param("id"), header.Filename, file)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to store photo: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, photo)
+}
+
+// GetPhoto отдает файл фотографии: GET /claims/:id/photos/:photo_id
+func (h *ClaimHandler) GetPhoto(c *gin.Context) {
+	photo, file, err := h.claimService.OpenPhoto(c.Request.Context(), c.Param("id"), c.Param("photo_id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get photo: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(
+		http.StatusOK, photo.SizeBytes, photo.ContentType, file, map[string]string{
+			"Content-Disposition": mime.FormatMediaType("inline", map[string]string{"filename": photo.FileName}),
+		},
+	)
+}
+
+// GetReport возвращает долю рекламаций по фермам и сортам: GET /claims/report?from=&to=
+func (h *ClaimHandler) GetReport(c *gin.Context) {
+	from, to, ok := parseClaimPeriod(c)
+	if !ok {
+		return
+	}
+
+	rates, err := h.claimService.Report(c.Request.Context(), from, to)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to build claim report: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"claim_rates": rates,
+		},
+	)
+}
+
+// parseClaimPeriod разбирает даты ?from= и ?to= (включительно) в полуинтервал [from, to+1 день)
+func parseClaimPeriod(c *gin.Context) (*time.Time, *time.Time, bool) {
+	from, ok := parseDateQuery(c, "from")
+	if !ok {
+		return nil, nil, false
+	}
+	to, ok := parseDateQuery(c, "to")
+	if !ok {
+		return nil, nil, false
+	}
+	if to != nil {
+		next := to.AddDate(0, 0, 1)
+		to = &next
+	}
+	return from, to, true
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type CustomerHandler struct {
+	customerService *services.CustomerService
+}
+
+func NewCustomerHandler(customerService *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: customerService,
+	}
+}
+
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+	customer, err := h.customerService.GetCustomer(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get customer: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// SaveCustomer создает или обновляет клиента: PUT /customers/:id
+func (h *CustomerHandler) SaveCustomer(c *gin.Context) {
+	var req dto.SaveCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	customer, err := h.customerService.SaveCustomer(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to save customer: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
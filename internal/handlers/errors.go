@@ -1,6 +1,57 @@
 package handlers
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
 // ErrorResponse представляет структуру ответа с ошибкой
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// RespondError прерывает обработку запроса и отправляет ответ с ошибкой,
+// добавляя идентификатор запроса
+func RespondError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(
+		status, ErrorResponse{
+			Error:     message,
+			RequestID: logger.RequestIDFromContext(c.Request.Context()),
+		},
+	)
+}
+
+// respondBindError отвечает на ошибку разбора тела запроса
+func respondBindError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		RespondError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+		return
+	}
+	RespondError(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+}
+
+// statusFromError подбирает HTTP-статус по ошибке сервисного слоя.
+// Конфликт версий (устаревший If-Match) отличается от конфликта состояния.
+func statusFromError(err error) int {
+	var versionErr *domain.VersionConflictError
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &versionErr):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
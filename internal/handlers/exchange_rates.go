@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type ExchangeRateHandler struct {
+	exchangeRateService *services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(exchangeRateService *services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateService: exchangeRateService,
+	}
+}
+
+func (h *ExchangeRateHandler) CreateExchangeRate(c *gin.Context) {
+	var req dto.CreateExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	rate, err := h.exchangeRateService.CreateExchangeRate(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create exchange rate: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// ListExchangeRates возвращает историю курсов: GET /exchange-rates?from=&to=
+func (h *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+	rates, err := h.exchangeRateService.ListExchangeRates(c.Request.Context(), c.Query("from"), c.Query("to"))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list exchange rates: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"exchange_rates": rates,
+		},
+	)
+}
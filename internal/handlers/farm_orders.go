@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type FarmOrderHandler struct {
+	farmOrderService *services.FarmOrderService
+}
+
+func NewFarmOrderHandler(farmOrderService *services.FarmOrderService) *FarmOrderHandler {
+	return &FarmOrderHandler{
+		farmOrderService: farmOrderService,
+	}
+}
+
+// CreateFarmOrders разбивает заказ по фермам: POST /orders/:id/farm-orders
+func (h *FarmOrderHandler) CreateFarmOrders(c *gin.Context) {
+	farmOrders, err := h.farmOrderService.CreateFarmOrders(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create farm orders: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated, gin.H{
+			"farm_orders": farmOrders,
+		},
+	)
+}
+
+func (h *FarmOrderHandler) ListOrderFarmOrders(c *gin.Context) {
+	farmOrders, err := h.farmOrderService.ListOrderFarmOrders(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list farm orders: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"farm_orders": farmOrders,
+		},
+	)
+}
+
+// GetFarmOrder возвращает заказ фермы со сканированиями и сверкой приемки
+func (h *FarmOrderHandler) GetFarmOrder(c *gin.Context) {
+	farmOrder, scans, report, err := h.farmOrderService.GetReceiving(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get farm order: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"farm_order": farmOrder,
+			"scans":      scans,
+			"receiving":  report,
+		},
+	)
+}
+
+// RecordScan принимает отсканированную коробку: POST /farm-orders/:id/scans
+func (h *FarmOrderHandler) RecordScan(c *gin.Context) {
+	var req dto.BoxScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	scan, report, err := h.farmOrderService.RecordScan(c.Request.Context(), c.Param("id"), req.Code)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to record scan: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated, gin.H{
+			"scan":      scan,
+			"receiving": report,
+		},
+	)
+}
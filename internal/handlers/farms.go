@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/export"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type FarmHandler struct {
+	farmService *services.FarmService
+}
+
+func NewFarmHandler(farmService *services.FarmService) *FarmHandler {
+	return &FarmHandler{
+		farmService: farmService,
+	}
+}
+
+func (h *FarmHandler) ListFarms(c *gin.Context) {
+	farms, err := h.farmService.ListFarms(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list farms: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"farms": farms,
+		},
+	)
+}
+
+func (h *FarmHandler) GetFarm(c *gin.Context) {
+	farm, err := h.farmService.GetFarm(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get farm: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, farm)
+}
+
+func (h *FarmHandler) CreateFarm(c *gin.Context) {
+	var req dto.SaveFarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	farm, err := h.farmService.CreateFarm(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create farm: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, farm)
+}
+
+// UpdateFarm заменяет данные фермы: PUT /farms/:id
+func (h *FarmHandler) UpdateFarm(c *gin.Context) {
+	var req dto.SaveFarmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	farm, err := h.farmService.UpdateFarm(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to update farm: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, farm)
+}
+
+// ExportOrders выгружает заказы фермы по датам отправки:
+// GET /farms/:id/export?ship_from=&ship_to=&format=xlsx
+func (h *FarmHandler) ExportOrders(c *gin.Context) {
+	from, ok := parseDateQuery(c, "ship_from")
+	if !ok {
+		return
+	}
+	to, ok := parseDateQuery(c, "ship_to")
+	if !ok {
+		return
+	}
+
+	farmExport, err := h.farmService.Export(c.Request.Context(), c.Param("id"), from, to)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to export farm orders: "+err.Error())
+		return
+	}
+
+	switch c.Query("format") {
+	case "", "json":
+		c.JSON(http.StatusOK, farmExport)
+	case "xlsx":
+		data, err := h.farmService.RenderExport(farmExport)
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, "Failed to render farm export: "+err.Error())
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="farm-orders-%s.xlsx"`, farmExport.FarmID))
+		c.Data(http.StatusOK, export.ContentTypeXLSX, data)
+	default:
+		RespondError(c, http.StatusBadRequest, "Unsupported export format: "+c.Query("format"))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
 )
 
@@ -20,7 +21,7 @@ func NewFlowerHandler(orderService *services.OrderService) *FlowerHandler {
 func (h *FlowerHandler) GetAvailableFlowers(c *gin.Context) {
 	flowers, err := h.orderService.GetAvailableFlowers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get flowers: " + err.Error()})
+		RespondError(c, http.StatusInternalServerError, "Failed to get flowers: "+err.Error())
 		return
 	}
 
@@ -30,3 +31,35 @@ func (h *FlowerHandler) GetAvailableFlowers(c *gin.Context) {
 		},
 	)
 }
+
+// ListAvailability возвращает окна доступности позиций каталога
+func (h *FlowerHandler) ListAvailability(c *gin.Context) {
+	windows, err := h.orderService.ListCatalogWindows(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to get availability: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"availability": windows,
+		},
+	)
+}
+
+// SetAvailability задает окно доступности позиции каталога: PUT /flowers/availability
+func (h *FlowerHandler) SetAvailability(c *gin.Context) {
+	var req dto.CatalogWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	window, err := h.orderService.SetCatalogWindow(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to set availability: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
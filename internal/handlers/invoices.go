@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type InvoiceHandler struct {
+	invoiceService *services.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService: invoiceService,
+	}
+}
+
+// IssueInvoice выставляет счет по выполненному заказу: POST /orders/:id/invoice
+func (h *InvoiceHandler) IssueInvoice(c *gin.Context) {
+	invoice, err := h.invoiceService.IssueInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to issue invoice: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// GetOrderInvoice возвращает действующий счет заказа:
+// GET /orders/:id/invoice?format=pdf|xlsx
+func (h *InvoiceHandler) GetOrderInvoice(c *gin.Context) {
+	invoice, err := h.invoiceService.CurrentInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get invoice: "+err.Error())
+		return
+	}
+
+	h.respondInvoice(c, invoice)
+}
+
+// CreditInvoice сторнирует действующий счет заказа: POST /orders/:id/invoice/credit-note
+func (h *InvoiceHandler) CreditInvoice(c *gin.Context) {
+	var req dto.CreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	note, err := h.invoiceService.CreditInvoice(c.Request.Context(), c.Param("id"), req.Reason)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to credit invoice: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// ListOrderInvoices возвращает все счета и кредит-ноты заказа
+func (h *InvoiceHandler) ListOrderInvoices(c *gin.Context) {
+	invoices, err := h.invoiceService.ListOrderInvoices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list invoices: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"invoices": invoices,
+		},
+	)
+}
+
+// GetInvoice возвращает документ по ID: GET /invoices/:id?format=pdf|xlsx
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.invoiceService.GetInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get invoice: "+err.Error())
+		return
+	}
+
+	h.respondInvoice(c, invoice)
+}
+
+// respondInvoice отдает документ в JSON или файлом в формате из параметра format
+func (h *InvoiceHandler) respondInvoice(c *gin.Context, invoice *domain.Invoice) {
+	format := c.Query("format")
+	if format == "" || format == "json" {
+		c.JSON(http.StatusOK, invoice)
+		return
+	}
+
+	data, contentType, err := h.invoiceService.RenderInvoice(invoice, format)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to render invoice: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, invoice.Number, format))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+func NewLabelHandler(labelService *services.LabelService) *LabelHandler {
+	return &LabelHandler{
+		labelService: labelService,
+	}
+}
+
+// GetOrderLabels отдает этикетки коробок заказа: GET /orders/:id/labels?format=pdf|zpl
+func (h *LabelHandler) GetOrderLabels(c *gin.Context) {
+	orderID := c.Param("id")
+	format := c.DefaultQuery("format", services.LabelFormatPDF)
+
+	data, contentType, err := h.labelService.RenderOrderLabels(c.Request.Context(), orderID, format)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to render labels: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="labels-%s.%s"`, orderID, format))
+	c.Data(http.StatusOK, contentType, data)
+}
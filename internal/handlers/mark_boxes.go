@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type MarkBoxHandler struct {
+	markBoxService *services.MarkBoxService
+}
+
+func NewMarkBoxHandler(markBoxService *services.MarkBoxService) *MarkBoxHandler {
+	return &MarkBoxHandler{
+		markBoxService: markBoxService,
+	}
+}
+
+// ListMarkBoxes возвращает коды маркировки; с ?customer_id= - коды клиента и общие
+func (h *MarkBoxHandler) ListMarkBoxes(c *gin.Context) {
+	markBoxes, err := h.markBoxService.ListMarkBoxes(c.Request.Context(), c.Query("customer_id"))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list mark boxes: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"mark_boxes": markBoxes,
+		},
+	)
+}
+
+func (h *MarkBoxHandler) GetMarkBox(c *gin.Context) {
+	markBox, err := h.markBoxService.GetMarkBox(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get mark box: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, markBox)
+}
+
+// SaveMarkBox создает или обновляет код маркировки: PUT /mark-boxes/:code
+func (h *MarkBoxHandler) SaveMarkBox(c *gin.Context) {
+	var req dto.SaveMarkBoxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	markBox, err := h.markBoxService.SaveMarkBox(c.Request.Context(), c.Param("code"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to save mark box: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, markBox)
+}
+
+func (h *MarkBoxHandler) DeleteMarkBox(c *gin.Context) {
+	if err := h.markBoxService.DeleteMarkBox(c.Request.Context(), c.Param("code")); err != nil {
+		RespondError(c, statusFromError(err), "Failed to delete mark box: "+err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
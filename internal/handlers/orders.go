@@ -1,46 +1,181 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
 )
 
 type OrderHandler struct {
 	orderService *services.OrderService
+	maxItems     int
 }
 
-func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
+func NewOrderHandler(orderService *services.OrderService, maxItems int) *OrderHandler {
 	return &OrderHandler{
 		orderService: orderService,
+		maxItems:     maxItems,
 	}
 }
 
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req dto.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request: " + err.Error()})
+		respondBindError(c, err)
+		return
+	}
+
+	if len(req.Items) > h.maxItems {
+		RespondError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: order exceeds %d items", h.maxItems))
 		return
 	}
 
 	order, err := h.orderService.CreateOrder(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create order: " + err.Error()})
+		RespondError(c, statusFromError(err), "Failed to create order: "+err.Error())
 		return
 	}
 
 	c.JSON(http.StatusCreated, order)
 }
 
+// DuplicateOrder создает новый заказ по существующему: POST /orders/:id/duplicate
+func (h *OrderHandler) DuplicateOrder(c *gin.Context) {
+	var req dto.DuplicateOrderRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	response, err := h.orderService.DuplicateOrder(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to duplicate order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListOrders возвращает заказы с фильтрами ?status=, ?ship_date= или периодом ?ship_from=&ship_to=
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	filter := domain.OrderFilter{Status: domain.OrderStatus(c.Query("status"))}
+	var ok bool
+	if filter.ShipFrom, ok = parseDateQuery(c, "ship_from"); !ok {
+		return
+	}
+	if filter.ShipTo, ok = parseDateQuery(c, "ship_to"); !ok {
+		return
+	}
+	shipDate, ok := parseDateQuery(c, "ship_date")
+	if !ok {
+		return
+	}
+	if shipDate != nil {
+		filter.ShipFrom, filter.ShipTo = shipDate, shipDate
+	}
+
+	orders, err := h.orderService.ListOrders(c.Request.Context(), filter)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list orders: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"orders": orders,
+		},
+	)
+}
+
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	id := c.Param("id")
 	order, err := h.orderService.GetOrderByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Order not found"})
+		if statusFromError(err) == http.StatusNotFound {
+			RespondError(c, http.StatusNotFound, "Order not found")
+			return
+		}
+		RespondError(c, http.StatusInternalServerError, "Failed to get order: "+err.Error())
 		return
 	}
 
+	c.Header("ETag", formatETag(order.Version))
 	c.JSON(http.StatusOK, order)
 }
+
+// UpdateOrder частично обновляет заказ. Требует заголовок If-Match
+// с ETag, полученным при чтении заказа.
+func (h *OrderHandler) UpdateOrder(c *gin.Context) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		RespondError(c, http.StatusPreconditionRequired, "If-Match header with the order ETag is required")
+		return
+	}
+	version, ok := parseETag(ifMatch)
+	if !ok {
+		RespondError(c, http.StatusBadRequest, "Invalid If-Match header: "+ifMatch)
+		return
+	}
+
+	var req dto.UpdateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	order, err := h.orderService.UpdateOrder(c.Request.Context(), c.Param("id"), version, req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to update order: "+err.Error())
+		return
+	}
+
+	c.Header("ETag", formatETag(order.Version))
+	c.JSON(http.StatusOK, order)
+}
+
+// formatETag формирует ETag из версии заказа
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETag извлекает версию из значения If-Match
+func parseETag(value string) (int, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	value = strings.Trim(value, `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// parseDateQuery разбирает необязательный параметр запроса с датой вида 2006-01-02.
+// При ошибке отвечает 400 и возвращает false.
+func parseDateQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "Invalid "+name+": "+value)
+		return nil, false
+	}
+	return &date, true
+}
+
+// bindOptionalJSON разбирает тело запроса, если оно передано; пустое тело
+// оставляет значения по умолчанию
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	return c.ShouldBindJSON(obj)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type PackingHandler struct {
+	packingService *services.PackingService
+}
+
+func NewPackingHandler(packingService *services.PackingService) *PackingHandler {
+	return &PackingHandler{
+		packingService: packingService,
+	}
+}
+
+func (h *PackingHandler) CreateBoxType(c *gin.Context) {
+	var req dto.CreateBoxTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	boxType, err := h.packingService.CreateBoxType(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create box type: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, boxType)
+}
+
+// ListBoxTypes возвращает типы коробок, с фильтром ?farm_name=
+func (h *PackingHandler) ListBoxTypes(c *gin.Context) {
+	boxTypes, err := h.packingService.ListBoxTypes(c.Request.Context(), c.Query("farm_name"))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list box types: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"box_types": boxTypes,
+		},
+	)
+}
+
+func (h *PackingHandler) DeleteBoxType(c *gin.Context) {
+	if err := h.packingService.DeleteBoxType(c.Request.Context(), c.Param("id")); err != nil {
+		RespondError(c, statusFromError(err), "Failed to delete box type: "+err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetOrderPacking возвращает раскладку заказа по коробкам: GET /orders/:id/packing
+func (h *PackingHandler) GetOrderPacking(c *gin.Context) {
+	plan, err := h.packingService.PackOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to pack order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+const dateLayout = "2006-01-02"
+
+type PriceHandler struct {
+	priceService *services.PriceService
+}
+
+func NewPriceHandler(priceService *services.PriceService) *PriceHandler {
+	return &PriceHandler{
+		priceService: priceService,
+	}
+}
+
+func (h *PriceHandler) CreatePrice(c *gin.Context) {
+	var req dto.CreatePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	entry, err := h.priceService.CreatePrice(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create price: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *PriceHandler) ListPrices(c *gin.Context) {
+	filter := domain.PriceFilter{
+		Variety:      c.Query("variety"),
+		FarmName:     c.Query("farm_name"),
+		CustomerID:   c.Query("customer_id"),
+		CustomerTier: c.Query("customer_tier"),
+	}
+	if activeOn := c.Query("active_on"); activeOn != "" {
+		date, err := time.Parse(dateLayout, activeOn)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "Invalid active_on date: "+activeOn)
+			return
+		}
+		filter.ActiveOn = &date
+	}
+
+	prices, err := h.priceService.ListPrices(c.Request.Context(), filter)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list prices: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"prices": prices,
+		},
+	)
+}
+
+func (h *PriceHandler) DeletePrice(c *gin.Context) {
+	if err := h.priceService.DeletePrice(c.Request.Context(), c.Param("id")); err != nil {
+		RespondError(c, statusFromError(err), "Failed to delete price: "+err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PreviewPrice показывает цену для клиента:
+// GET /prices/preview?customer_id=&variety=&length=&farm_name=&date=
+func (h *PriceHandler) PreviewPrice(c *gin.Context) {
+	variety := c.Query("variety")
+	farmName := c.Query("farm_name")
+	length, err := strconv.Atoi(c.Query("length"))
+	if variety == "" || farmName == "" || err != nil {
+		RespondError(c, http.StatusBadRequest, "variety, length and farm_name are required")
+		return
+	}
+
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		date, err = time.Parse(dateLayout, value)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "Invalid date: "+value)
+			return
+		}
+	}
+
+	preview, err := h.priceService.PreviewPrice(c.Request.Context(), c.Query("customer_id"), variety, length, farmName, date)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to preview price: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type RecurringOrderHandler struct {
+	recurringService *services.RecurringOrderService
+}
+
+func NewRecurringOrderHandler(recurringService *services.RecurringOrderService) *RecurringOrderHandler {
+	return &RecurringOrderHandler{
+		recurringService: recurringService,
+	}
+}
+
+func (h *RecurringOrderHandler) CreateRecurringOrder(c *gin.Context) {
+	var req dto.SaveRecurringOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	order, err := h.recurringService.Create(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create recurring order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+func (h *RecurringOrderHandler) UpdateRecurringOrder(c *gin.Context) {
+	var req dto.SaveRecurringOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	order, err := h.recurringService.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to update recurring order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ListRecurringOrders возвращает постоянные заказы с фильтрами ?customer_id= и ?status=
+func (h *RecurringOrderHandler) ListRecurringOrders(c *gin.Context) {
+	filter := domain.RecurringOrderFilter{
+		CustomerID: c.Query("customer_id"),
+		Status:     domain.RecurringOrderStatus(c.Query("status")),
+	}
+
+	orders, err := h.recurringService.List(c.Request.Context(), filter)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list recurring orders: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"recurring_orders": orders,
+		},
+	)
+}
+
+func (h *RecurringOrderHandler) GetRecurringOrder(c *gin.Context) {
+	order, err := h.recurringService.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get recurring order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Pause приостанавливает постоянный заказ: POST /recurring-orders/:id/pause
+func (h *RecurringOrderHandler) Pause(c *gin.Context) {
+	order, err := h.recurringService.Pause(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to pause recurring order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Resume возобновляет постоянный заказ: POST /recurring-orders/:id/resume
+func (h *RecurringOrderHandler) Resume(c *gin.Context) {
+	order, err := h.recurringService.Resume(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to resume recurring order: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// Preview возвращает ближайшие даты отправки: GET /recurring-orders/:id/preview?count=
+func (h *RecurringOrderHandler) Preview(c *gin.Context) {
+	count := 0
+	if value := c.Query("count"); value != "" {
+		var err error
+		if count, err = strconv.Atoi(value); err != nil || count < 1 {
+			RespondError(c, http.StatusBadRequest, "Invalid count: "+value)
+			return
+		}
+	}
+
+	occurrences, err := h.recurringService.Preview(c.Request.Context(), c.Param("id"), count)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to preview recurring order: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"occurrences": occurrences,
+		},
+	)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/export"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type ShipmentHandler struct {
+	shipmentService *services.ShipmentService
+}
+
+func NewShipmentHandler(shipmentService *services.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{
+		shipmentService: shipmentService,
+	}
+}
+
+func (h *ShipmentHandler) CreateShipment(c *gin.Context) {
+	var req dto.CreateShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	shipment, err := h.shipmentService.CreateShipment(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create shipment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// ListShipments возвращает рейсы, с фильтрами ?truck_name= и ?departure_date=
+func (h *ShipmentHandler) ListShipments(c *gin.Context) {
+	filter := domain.ShipmentFilter{
+		TruckName: c.Query("truck_name"),
+	}
+	if departure := c.Query("departure_date"); departure != "" {
+		date, err := time.Parse(dateLayout, departure)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "Invalid departure_date: "+departure)
+			return
+		}
+		filter.DepartureDate = &date
+	}
+
+	shipments, err := h.shipmentService.ListShipments(c.Request.Context(), filter)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list shipments: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"shipments": shipments,
+		},
+	)
+}
+
+func (h *ShipmentHandler) GetShipment(c *gin.Context) {
+	shipment, err := h.shipmentService.GetShipment(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get shipment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// AssignItems загружает позиции заказов в рейс: POST /shipments/:id/items
+func (h *ShipmentHandler) AssignItems(c *gin.Context) {
+	var req dto.AssignShipmentItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	shipment, err := h.shipmentService.AssignItems(c.Request.Context(), c.Param("id"), req.ItemIDs)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to assign shipment items: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+func (h *ShipmentHandler) RemoveItem(c *gin.Context) {
+	err := h.shipmentService.RemoveItem(c.Request.Context(), c.Param("id"), c.Param("item_id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to remove shipment item: "+err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetManifest возвращает загрузочную ведомость рейса:
+// GET /shipments/:id/manifest?format=xlsx
+func (h *ShipmentHandler) GetManifest(c *gin.Context) {
+	manifest, err := h.shipmentService.Manifest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to get manifest: "+err.Error())
+		return
+	}
+
+	switch c.Query("format") {
+	case "", "json":
+		c.JSON(http.StatusOK, manifest)
+	case "xlsx":
+		data, err := h.shipmentService.RenderManifest(manifest)
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, "Failed to render manifest: "+err.Error())
+			return
+		}
+		filename := fmt.Sprintf("manifest-%s-%s.xlsx", manifest.TruckName, manifest.DepartureDate.Format(dateLayout))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Data(http.StatusOK, export.ContentTypeXLSX, data)
+	default:
+		RespondError(c, http.StatusBadRequest, "Unsupported manifest format: "+c.Query("format"))
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/services"
+)
+
+type TaxHandler struct {
+	taxService *services.TaxService
+}
+
+func NewTaxHandler(taxService *services.TaxService) *TaxHandler {
+	return &TaxHandler{
+		taxService: taxService,
+	}
+}
+
+func (h *TaxHandler) CreateRule(c *gin.Context) {
+	var req dto.CreateTaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	rule, err := h.taxService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		RespondError(c, statusFromError(err), "Failed to create tax rule: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// ListRules возвращает налоговые правила, с фильтром ?country=
+func (h *TaxHandler) ListRules(c *gin.Context) {
+	rules, err := h.taxService.ListRules(c.Request.Context(), c.Query("country"))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "Failed to list tax rules: "+err.Error())
+		return
+	}
+
+	c.JSON(
+		http.StatusOK, gin.H{
+			"rules": rules,
+		},
+	)
+}
+
+func (h *TaxHandler) DeleteRule(c *gin.Context) {
+	if err := h.taxService.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		RespondError(c, statusFromError(err), "Failed to delete tax rule: "+err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
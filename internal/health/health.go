@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc проверяет одну зависимость приложения
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// CheckResult результат проверки одной зависимости
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report итоговый отчет проверки готовности
+type Report struct {
+	Status    string                 `json:"status"`
+	Timestamp time.Time              `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker выполняет проверки готовности приложения к приему трафика
+type Checker struct {
+	timeout      time.Duration
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker создает проверку готовности с общим таймаутом на каждую зависимость
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку зависимости
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// SetShuttingDown переводит приложение в состояние остановки:
+// проверка готовности начинает возвращать отказ, чтобы балансировщик
+// перестал направлять новые запросы
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// IsShuttingDown сообщает, началась ли остановка приложения
+func (c *Checker) IsShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Ready параллельно выполняет все проверки и возвращает отчет
+func (c *Checker) Ready(ctx context.Context) (Report, bool) {
+	report := Report{
+		Status:    StatusOK,
+		Timestamp: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult, len(c.checks)),
+	}
+
+	if c.IsShuttingDown() {
+		report.Status = StatusShuttingDown
+		return report, false
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			result := c.run(ctx, ch)
+
+			mu.Lock()
+			report.Checks[ch.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+
+	return report, report.Status == StatusOK
+}
+
+func (c *Checker) run(ctx context.Context, ch check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package logger
+
+import "context"
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+)
+
+// WithRequestID добавляет идентификатор запроса к логгеру
+func (l *Logger) WithRequestID(requestID string) *Logger {
+	return &Logger{
+		logger: l.logger.With().Str("request_id", requestID).Logger(),
+	}
+}
+
+// ToContext сохраняет логгер в контексте запроса
+func (l *Logger) ToContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext возвращает логгер запроса из контекста.
+// Если логгер в контексте не найден, используется глобальный логгер.
+func FromContext(ctx context.Context) *Logger {
+	return GetLogger().WithContext(ctx)
+}
+
+// ContextWithRequestID сохраняет идентификатор запроса в контексте
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из контекста
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
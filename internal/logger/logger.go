@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,10 @@ import (
 // Logger представляет кастомный логгер
 type Logger struct {
 	logger zerolog.Logger
+
+	// Файловые выходы и канал сигнала переоткрытия есть только у корневого логгера
+	files    []*rotatingFile
+	reopenCh chan os.Signal
 }
 
 var (
@@ -40,28 +46,10 @@ func (l *Logger) Initialize(cfg *config.Config) error {
 	}
 	zerolog.SetGlobalLevel(level)
 
-	// Настраиваем вывод
-	var output io.Writer
-	switch cfg.Logger.Output {
-	case "stdout":
-		output = os.Stdout
-	case "stderr":
-		output = os.Stderr
-	default:
-		file, err := os.OpenFile(cfg.Logger.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
-		if err != nil {
-			return fmt.Errorf("failed to open log file: %w", err)
-		}
-		output = file
-	}
-
-	// Настраиваем формат
-	if cfg.Logger.Format == "console" {
-		output = zerolog.ConsoleWriter{
-			Out:        output,
-			TimeFormat: "15:04:05",
-			NoColor:    false,
-		}
+	// Настраиваем выходы
+	output, err := l.openSinks(cfg.Logger)
+	if err != nil {
+		return err
 	}
 
 	// Создаем логгер с контекстом
@@ -71,18 +59,111 @@ func (l *Logger) Initialize(cfg *config.Config) error {
 		Timestamp().
 		Caller().
 		Str("service", "dolina-flower-order").
-		Logger()
+		Logger().
+		Hook(traceHook{})
 
 	// Устанавливаем глобальный логгер
 	log.Logger = l.logger
 
+	l.watchReopen()
+
 	return nil
 }
 
-// WithContext добавляет контекст к логгеру
+// openSinks открывает все настроенные выходы и объединяет их в один writer
+func (l *Logger) openSinks(cfg config.LoggerConfig) (io.Writer, error) {
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = []string{cfg.Format + ":" + cfg.Output}
+	}
+
+	writers := make([]io.Writer, 0, len(sinks))
+	for _, sink := range sinks {
+		format, target, _ := strings.Cut(sink, ":")
+
+		var output io.Writer
+		console := true
+		switch target {
+		case "stdout":
+			output = os.Stdout
+		case "stderr":
+			output = os.Stderr
+		default:
+			file, err := newRotatingFile(target, cfg.MaxSizeMB, cfg.MaxAge, cfg.MaxBackups, cfg.Compress)
+			if err != nil {
+				return nil, err
+			}
+			l.files = append(l.files, file)
+			output = file
+			console = false
+		}
+
+		// Настраиваем формат
+		if format == "console" {
+			output = zerolog.ConsoleWriter{
+				Out:        output,
+				TimeFormat: "15:04:05",
+				NoColor:    !console,
+			}
+		}
+
+		writers = append(writers, output)
+	}
+
+	if len(writers) == 1 {
+		return writers[0], nil
+	}
+	return zerolog.MultiLevelWriter(writers...), nil
+}
+
+// watchReopen переоткрывает файлы логов по сигналу SIGUSR1 (для внешнего logrotate)
+func (l *Logger) watchReopen() {
+	if len(l.files) == 0 {
+		return
+	}
+
+	l.reopenCh = make(chan os.Signal, 1)
+	notifyReopen(l.reopenCh)
+
+	go func() {
+		for range l.reopenCh {
+			for _, file := range l.files {
+				if err := file.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "logger: failed to reopen %s: %v\n", file.path, err)
+				}
+			}
+			l.Info("Log files reopened")
+		}
+	}()
+}
+
+// Close останавливает обработку сигналов и закрывает файловые выходы
+func (l *Logger) Close() error {
+	if l.reopenCh != nil {
+		signal.Stop(l.reopenCh)
+		close(l.reopenCh)
+		l.reopenCh = nil
+	}
+
+	var firstErr error
+	for _, file := range l.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.files = nil
+	return firstErr
+}
+
+// WithContext добавляет контекст к логгеру. Если в контексте сохранен
+// логгер запроса (см. ToContext), поля берутся из него.
 func (l *Logger) WithContext(ctx context.Context) *Logger {
+	base := l
+	if scoped, ok := ctx.Value(loggerKey).(*Logger); ok {
+		base = scoped
+	}
 	return &Logger{
-		logger: l.logger.With().Ctx(ctx).Logger(),
+		logger: base.logger.With().Ctx(ctx).Logger(),
 	}
 }
 
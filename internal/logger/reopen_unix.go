@@ -0,0 +1,14 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen подписывает канал на сигнал переоткрытия файлов логов
+func notifyReopen(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGUSR1)
+}
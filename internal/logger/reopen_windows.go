@@ -0,0 +1,8 @@
+//go:build windows
+
+package logger
+
+import "os"
+
+// notifyReopen ничего не делает: в Windows нет сигнала SIGUSR1
+func notifyReopen(chan<- os.Signal) {}
@@ -0,0 +1,238 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+// rotatingFile пишет логи в файл с ротацией по размеру и возрасту,
+// сжатием старых файлов и ограничением их количества
+type rotatingFile struct {
+	mu         sync.Mutex
+	wg         sync.WaitGroup
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// closed устанавливается в Close: после него запись и Reopen не открывают файл заново
+	closed bool
+}
+
+// newRotatingFile открывает файл лога на дозапись
+func newRotatingFile(path string, maxSizeMB int, maxAge time.Duration, maxBackups int, compress bool) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		compress:   compress,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Write записывает данные, предварительно выполняя ротацию при необходимости
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Reopen закрывает и заново открывает файл по исходному пути.
+// Используется после того, как внешний logrotate переместил файл.
+func (rf *rotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if err := rf.closeFile(); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+// Close закрывает файл и дожидается завершения фонового сжатия.
+// Последующие Write и Reopen возвращают os.ErrClosed.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	rf.closed = true
+	err := rf.closeFile()
+	rf.mu.Unlock()
+
+	rf.wg.Wait()
+	return err
+}
+
+func (rf *rotatingFile) shouldRotate(next int64) bool {
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+next > rf.maxSize {
+		return true
+	}
+	return rf.maxAge > 0 && time.Since(rf.openedAt) >= rf.maxAge
+}
+
+func (rf *rotatingFile) open() error {
+	if dir := filepath.Dir(rf.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.openedAt = time.Now()
+	return nil
+}
+
+func (rf *rotatingFile) closeFile() error {
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// rotate переименовывает текущий файл в резервную копию и открывает новый
+func (rf *rotatingFile) rotate() error {
+	if err := rf.closeFile(); err != nil {
+		return err
+	}
+
+	backup := rf.backupName(time.Now())
+	if err := os.Rename(rf.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	rf.wg.Add(1)
+	go func() {
+		defer rf.wg.Done()
+		if rf.compress {
+			if err := compressFile(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "logger: failed to compress %s: %v\n", backup, err)
+			}
+		}
+		rf.removeOldBackups()
+	}()
+
+	return nil
+}
+
+func (rf *rotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(rf.path)
+	prefix := strings.TrimSuffix(rf.path, ext)
+	return fmt.Sprintf("%s-%s%s", prefix, t.Format(backupTimeFormat), ext)
+}
+
+// removeOldBackups удаляет резервные копии сверх лимита maxBackups
+func (rf *rotatingFile) removeOldBackups() {
+	if rf.maxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(rf.path)
+	prefix := strings.TrimSuffix(rf.path, ext) + "-"
+
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return
+	}
+
+	var backups []string
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimSuffix(match, ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(name, prefix)); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	if len(backups) <= rf.maxBackups {
+		return
+	}
+
+	// Имена содержат время ротации, поэтому лексикографический порядок совпадает с хронологическим
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-rf.maxBackups] {
+		_ = os.Remove(old)
+	}
+}
+
+// compressFile сжимает файл в gzip и удаляет оригинал
+func compressFile(path string) error {
+	src, err := os.Open(path) // #nosec G304 - путь формируется логгером
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = gz.Close()
+		_ = dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rf, err := newRotatingFile(path, 1, 0, 0, false)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	if _, err := rf.Write([]byte("before close\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// файл удален после закрытия: запись не должна создать его заново
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := rf.Write([]byte("after close\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close error = %v, want os.ErrClosed", err)
+	}
+	if err := rf.Reopen(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Reopen after Close error = %v, want os.ErrClosed", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("log file was reopened after Close: %v", err)
+	}
+	if err := rf.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rf, err := newRotatingFile(path, 1, 0, 1, false)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer rf.Close()
+
+	chunk := make([]byte, 600*1024)
+	for i := 0; i < 2; i++ {
+		if _, err := rf.Write(chunk); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("backups = %v, want one", matches)
+	}
+}
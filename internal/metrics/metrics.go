@@ -0,0 +1,209 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+const namespace = "dolina"
+
+// statusCountTimeout ограничивает время запроса к БД при сборе метрик
+const statusCountTimeout = 5 * time.Second
+
+// unknownFarm метка для позиций без фермы из реестра
+const unknownFarm = "unknown"
+
+// OrderStatusCounter возвращает текущее количество заказов по статусам
+type OrderStatusCounter interface {
+	CountByStatus(ctx context.Context) (map[domain.OrderStatus]int, error)
+}
+
+// Metrics содержит все метрики приложения в отдельном реестре Prometheus.
+// Методы безопасно вызывать на nil-значении: тогда метрики не собираются.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	ordersCreated *prometheus.CounterVec
+	stemsOrdered  *prometheus.CounterVec
+	excelExports  *prometheus.CounterVec
+}
+
+// New создает реестр и регистрирует в нем метрики приложения
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_total",
+				Help:      "Total number of HTTP requests by route, method and status code.",
+			},
+			[]string{"method", "route", "status"},
+		),
+		httpDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "http_request_duration_seconds",
+				Help:      "HTTP request latency by route, method and status code.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"method", "route", "status"},
+		),
+		ordersCreated: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "orders_created_total",
+				Help:      "Total number of orders created.",
+			},
+			[]string{"mark_box"},
+		),
+		stemsOrdered: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "stems_ordered_total",
+				Help:      "Total number of stems ordered per registered farm.",
+			},
+			[]string{"farm_id"},
+		),
+		excelExports: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "excel_exports_total",
+				Help:      "Total number of generated Excel exports by kind.",
+			},
+			[]string{"kind"},
+		),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.ordersCreated,
+		m.stemsOrdered,
+		m.excelExports,
+	)
+
+	return m
+}
+
+// RegisterDB добавляет метрики пула соединений из sql.DB.Stats()
+func (m *Metrics) RegisterDB(db *sql.DB, dbName string) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RegisterOrderStatuses добавляет метрику количества заказов по статусам,
+// которая вычисляется запросом к хранилищу при каждом сборе
+func (m *Metrics) RegisterOrderStatuses(counter OrderStatusCounter) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(&orderStatusCollector{
+		counter: counter,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "orders_by_status"),
+			"Current number of orders by status.",
+			[]string{"status"}, nil,
+		),
+	})
+}
+
+// Handler возвращает HTTP-обработчик в текстовом формате Prometheus.
+// Ошибка одного сборщика не должна скрывать остальные метрики, поэтому
+// отдается все, что удалось собрать.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog:      promErrorLog{},
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// promErrorLog передает ошибки сбора метрик в логгер приложения
+type promErrorLog struct{}
+
+func (promErrorLog) Println(v ...interface{}) {
+	logger.GetLogger().Warn(fmt.Sprint(v...))
+}
+
+// ObserveHTTPRequest учитывает обработанный HTTP-запрос
+func (m *Metrics) ObserveHTTPRequest(method, route string, statusCode int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(statusCode)
+	m.httpRequests.WithLabelValues(method, route, status).Inc()
+	m.httpDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// OrderCreated учитывает созданный заказ и заказанные стебли по фермам.
+// Стебли учитываются по ID фермы из реестра, а не по названию из запроса,
+// чтобы число рядов метрики не зависело от ввода клиентов.
+func (m *Metrics) OrderCreated(order *domain.Order) {
+	if m == nil {
+		return
+	}
+	m.ordersCreated.WithLabelValues(order.MarkBox).Inc()
+	for _, item := range order.Items {
+		farmID := item.FarmID
+		if farmID == "" {
+			farmID = unknownFarm
+		}
+		m.stemsOrdered.WithLabelValues(farmID).Add(float64(item.TotalStems))
+	}
+}
+
+// ExcelExportGenerated учитывает сформированный Excel-файл
+func (m *Metrics) ExcelExportGenerated(kind string) {
+	if m == nil {
+		return
+	}
+	m.excelExports.WithLabelValues(kind).Inc()
+}
+
+// orderStatusCollector собирает количество заказов по статусам из хранилища
+type orderStatusCollector struct {
+	counter OrderStatusCounter
+	desc    *prometheus.Desc
+}
+
+func (c *orderStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *orderStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusCountTimeout)
+	defer cancel()
+
+	// при недоступной БД метрика пропускается, а не ломает весь сбор:
+	// HTTP-метрики и метрики пула нужнее всего именно во время сбоя
+	counts, err := c.counter.CountByStatus(ctx)
+	if err != nil {
+		logger.GetLogger().WithError(err).Warn("Failed to count orders by status for metrics")
+		return
+	}
+
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), string(status))
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+type statusCounter struct {
+	counts map[domain.OrderStatus]int
+	err    error
+}
+
+func (c statusCounter) CountByStatus(context.Context) (map[domain.OrderStatus]int, error) {
+	return c.counts, c.err
+}
+
+func TestHandlerOrderStatuses(t *testing.T) {
+	tests := []struct {
+		name        string
+		counter     statusCounter
+		wantStatus  bool
+		wantHTTPRow bool
+	}{
+		{
+			name:        "counts available",
+			counter:     statusCounter{counts: map[domain.OrderStatus]int{domain.OrderStatusPending: 3}},
+			wantStatus:  true,
+			wantHTTPRow: true,
+		},
+		{
+			name:        "database unavailable",
+			counter:     statusCounter{err: errors.New("connection refused")},
+			wantStatus:  false,
+			wantHTTPRow: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.RegisterOrderStatuses(tt.counter)
+			m.ObserveHTTPRequest(http.MethodGet, "/health", http.StatusOK, time.Millisecond)
+
+			recorder := httptest.NewRecorder()
+			m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", recorder.Code)
+			}
+			body := recorder.Body.String()
+			if got := strings.Contains(body, `dolina_orders_by_status{status="pending"} 3`); got != tt.wantStatus {
+				t.Errorf("orders_by_status present = %v, want %v", got, tt.wantStatus)
+			}
+			if got := strings.Contains(body, "dolina_http_requests_total"); got != tt.wantHTTPRow {
+				t.Errorf("http_requests_total present = %v, want %v", got, tt.wantHTTPRow)
+			}
+		})
+	}
+}
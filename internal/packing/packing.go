@@ -0,0 +1,222 @@
+// Package packing раскладывает позиции заказа по физическим коробкам ферм
+// и считает вес груза для расчета доставки.
+package packing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// epsilon допуск при сравнении долей коробок
+const epsilon = 1e-6
+
+// Content часть позиции заказа в коробке
+type Content struct {
+	ItemID  string `json:"item_id"`
+	Variety string `json:"variety"`
+	Length  int    `json:"length"`
+	Stems   int    `json:"stems"`
+	// Boxes занятая доля полной коробки
+	Boxes float64 `json:"boxes"`
+}
+
+// Box физическая коробка
+type Box struct {
+	Number   int       `json:"number"`
+	FarmName string    `json:"farm_name"`
+	BoxType  string    `json:"box_type"`
+	Size     float64   `json:"size"`
+	Mixed    bool      `json:"mixed"`
+	Fill     float64   `json:"fill"`
+	Contents []Content `json:"contents"`
+	// GrossWeightKg вес коробки с учетом заполнения
+	GrossWeightKg      float64 `json:"gross_weight_kg"`
+	VolumetricWeightKg float64 `json:"volumetric_weight_kg"`
+}
+
+// Plan раскладка заказа по коробкам
+type Plan struct {
+	OrderID    string `json:"order_id"`
+	Boxes      []Box  `json:"boxes"`
+	TotalBoxes int    `json:"total_boxes"`
+	// FullBoxEquivalent объем груза в полных коробках
+	FullBoxEquivalent  float64 `json:"full_box_equivalent"`
+	GrossWeightKg      float64 `json:"gross_weight_kg"`
+	VolumetricWeightKg float64 `json:"volumetric_weight_kg"`
+	// ChargeableWeightKg больший из фактического и объемного веса
+	ChargeableWeightKg float64 `json:"chargeable_weight_kg"`
+	// Underfilled в заказе есть неполные коробки
+	Underfilled bool     `json:"underfilled"`
+	Warnings    []string `json:"warnings"`
+}
+
+// piece доля позиции, которую нужно уложить
+type piece struct {
+	item  *domain.Item
+	boxes float64
+	stems int
+}
+
+// Pack раскладывает позиции заказа по коробкам. Позиции каждой фермы
+// укладываются в коробки ее типов: целые коробки одного сорта берутся от
+// больших к меньшим, а остатки объединяются в смешанные коробки.
+// Для ферм без типов коробок используются domain.DefaultBoxTypes.
+func Pack(order *domain.Order, boxTypes []domain.BoxType) *Plan {
+	plan := &Plan{OrderID: order.ID, Boxes: []Box{}, Warnings: []string{}}
+
+	byFarm := make(map[string][]domain.BoxType)
+	for _, boxType := range boxTypes {
+		byFarm[boxType.FarmName] = append(byFarm[boxType.FarmName], boxType)
+	}
+
+	var farms []string
+	itemsByFarm := make(map[string][]*domain.Item)
+	for i := range order.Items {
+		item := &order.Items[i]
+		if _, ok := itemsByFarm[item.FarmName]; !ok {
+			farms = append(farms, item.FarmName)
+		}
+		itemsByFarm[item.FarmName] = append(itemsByFarm[item.FarmName], item)
+	}
+
+	for _, farm := range farms {
+		types := byFarm[farm]
+		if len(types) == 0 {
+			types = domain.DefaultBoxTypes()
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("farm %s has no box types, standard boxes are used", farm))
+		}
+		sort.Slice(types, func(i, j int) bool { return types[i].Size > types[j].Size })
+
+		var remainders []piece
+		for _, item := range itemsByFarm[farm] {
+			remainder := packItem(plan, farm, item, types)
+			if remainder.boxes > epsilon {
+				remainders = append(remainders, remainder)
+			}
+		}
+		packMixed(plan, farm, remainders, types)
+	}
+
+	for i := range plan.Boxes {
+		box := &plan.Boxes[i]
+		box.Number = i + 1
+		plan.FullBoxEquivalent += box.Fill * box.Size
+		plan.GrossWeightKg += box.GrossWeightKg
+		plan.VolumetricWeightKg += box.VolumetricWeightKg
+		if box.Fill < 1-epsilon {
+			plan.Underfilled = true
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("box %d (%s, %s) is filled by %.0f%%",
+				box.Number, box.FarmName, box.BoxType, box.Fill*100))
+		}
+	}
+	plan.TotalBoxes = len(plan.Boxes)
+	plan.FullBoxEquivalent = round(plan.FullBoxEquivalent, 4)
+	plan.GrossWeightKg = round(plan.GrossWeightKg, 2)
+	plan.VolumetricWeightKg = round(plan.VolumetricWeightKg, 2)
+	plan.ChargeableWeightKg = math.Max(plan.GrossWeightKg, plan.VolumetricWeightKg)
+	return plan
+}
+
+// packItem укладывает целые коробки одного сорта и возвращает остаток позиции.
+// Стебли распределяются пропорционально объему.
+func packItem(plan *Plan, farm string, item *domain.Item, types []domain.BoxType) piece {
+	remaining := piece{item: item, boxes: item.BoxCount, stems: item.TotalStems}
+	if item.BoxCount <= 0 {
+		return piece{}
+	}
+
+	for _, boxType := range types {
+		for remaining.boxes+epsilon >= boxType.Size {
+			stems := int(math.Round(float64(item.TotalStems) * boxType.Size / item.BoxCount))
+			if stems > remaining.stems {
+				stems = remaining.stems
+			}
+			remaining.boxes -= boxType.Size
+			remaining.stems -= stems
+			if remaining.boxes < epsilon {
+				// последняя коробка забирает стебли, оставшиеся после округления
+				stems += remaining.stems
+				remaining.stems = 0
+			}
+			plan.Boxes = append(plan.Boxes, newBox(farm, boxType, []Content{content(item, boxType.Size, stems)}))
+		}
+	}
+	return remaining
+}
+
+// packMixed объединяет остатки позиций в смешанные коробки. Каждая новая коробка
+// берется наименьшего типа, вмещающего все неуложенные остатки, иначе наибольшего.
+func packMixed(plan *Plan, farm string, pieces []piece, types []domain.BoxType) {
+	sort.SliceStable(pieces, func(i, j int) bool { return pieces[i].boxes > pieces[j].boxes })
+
+	for len(pieces) > 0 {
+		var total float64
+		for _, p := range pieces {
+			total += p.boxes
+		}
+		boxType := types[0]
+		for _, candidate := range types {
+			if candidate.Size+epsilon >= total {
+				boxType = candidate
+			}
+		}
+
+		var contents []Content
+		var rest []piece
+		free := boxType.Size
+		for _, p := range pieces {
+			if p.boxes <= free+epsilon {
+				contents = append(contents, content(p.item, p.boxes, p.stems))
+				free -= p.boxes
+			} else {
+				rest = append(rest, p)
+			}
+		}
+		if len(contents) == 0 {
+			// остаток больше любой коробки невозможен: целые коробки уже уложены
+			p := pieces[0]
+			contents = append(contents, content(p.item, p.boxes, p.stems))
+			rest = pieces[1:]
+		}
+
+		box := newBox(farm, boxType, contents)
+		box.Mixed = len(contents) > 1
+		plan.Boxes = append(plan.Boxes, box)
+		pieces = rest
+	}
+}
+
+func newBox(farm string, boxType domain.BoxType, contents []Content) Box {
+	var used float64
+	for _, c := range contents {
+		used += c.Boxes
+	}
+	fill := math.Min(used/boxType.Size, 1)
+	return Box{
+		FarmName:           farm,
+		BoxType:            boxType.Code,
+		Size:               boxType.Size,
+		Fill:               round(fill, 4),
+		Contents:           contents,
+		GrossWeightKg:      round(boxType.TareWeightKg+fill*(boxType.GrossWeightKg-boxType.TareWeightKg), 2),
+		VolumetricWeightKg: round(boxType.VolumetricWeightKg(), 2),
+	}
+}
+
+func content(item *domain.Item, boxes float64, stems int) Content {
+	return Content{
+		ItemID:  item.ID,
+		Variety: item.Variety,
+		Length:  item.Length,
+		Stems:   stems,
+		Boxes:   round(boxes, 4),
+	}
+}
+
+func round(value float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(value*factor) / factor
+}
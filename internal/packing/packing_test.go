@@ -0,0 +1,197 @@
+package packing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+func fullAndHalfBoxes(farm string) []domain.BoxType {
+	return []domain.BoxType{
+		{FarmName: farm, Code: "HB", Size: 0.5, LengthCm: 100, WidthCm: 25, HeightCm: 30, TareWeightKg: 1.5, GrossWeightKg: 14},
+		{FarmName: farm, Code: "FB", Size: 1, LengthCm: 100, WidthCm: 50, HeightCm: 30, TareWeightKg: 3, GrossWeightKg: 28},
+	}
+}
+
+func boxCodes(plan *Plan) []string {
+	codes := make([]string, len(plan.Boxes))
+	for i, box := range plan.Boxes {
+		codes[i] = box.FarmName + ":" + box.BoxType
+		if box.Mixed {
+			codes[i] += "*"
+		}
+	}
+	return codes
+}
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name            string
+		items           []domain.Item
+		boxTypes        []domain.BoxType
+		wantBoxes       []string
+		wantEquivalent  float64
+		wantUnderfilled bool
+		wantWarning     string
+	}{
+		{
+			name:     "ten and a half boxes",
+			items:    []domain.Item{{ID: "1", Variety: "Rose", BoxCount: 10.5, TotalStems: 2625, FarmName: "Alpha"}},
+			boxTypes: fullAndHalfBoxes("Alpha"),
+			wantBoxes: []string{
+				"Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB",
+				"Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:HB",
+			},
+			wantEquivalent: 10.5,
+		},
+		{
+			name:           "six and a half boxes",
+			items:          []domain.Item{{ID: "1", Variety: "Rose", BoxCount: 6.5, TotalStems: 1300, FarmName: "Alpha"}},
+			boxTypes:       fullAndHalfBoxes("Alpha"),
+			wantBoxes:      []string{"Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:FB", "Alpha:HB"},
+			wantEquivalent: 6.5,
+		},
+		{
+			name:           "quarter and half boxes",
+			items:          []domain.Item{{ID: "1", Variety: "Rose", BoxCount: 1.75, TotalStems: 350, FarmName: "Beta"}},
+			boxTypes:       nil,
+			wantBoxes:      []string{"Beta:FB", "Beta:HB", "Beta:QB"},
+			wantEquivalent: 1.75,
+			wantWarning:    "farm Beta has no box types",
+		},
+		{
+			name: "remainders share a mixed box",
+			items: []domain.Item{
+				{ID: "1", Variety: "Rose", BoxCount: 1.25, TotalStems: 250, FarmName: "Alpha"},
+				{ID: "2", Variety: "Tulip", BoxCount: 0.25, TotalStems: 50, FarmName: "Alpha"},
+			},
+			boxTypes:       fullAndHalfBoxes("Alpha"),
+			wantBoxes:      []string{"Alpha:FB", "Alpha:HB*"},
+			wantEquivalent: 1.5,
+		},
+		{
+			name: "remainders of different farms are not mixed",
+			items: []domain.Item{
+				{ID: "1", Variety: "Rose", BoxCount: 0.25, TotalStems: 50, FarmName: "Alpha"},
+				{ID: "2", Variety: "Tulip", BoxCount: 0.25, TotalStems: 50, FarmName: "Beta"},
+			},
+			boxTypes:        append(fullAndHalfBoxes("Alpha"), fullAndHalfBoxes("Beta")...),
+			wantBoxes:       []string{"Alpha:HB", "Beta:HB"},
+			wantEquivalent:  0.5,
+			wantUnderfilled: true,
+			wantWarning:     "is filled by 50%",
+		},
+		{
+			name: "mixed remainders exceed one box",
+			items: []domain.Item{
+				{ID: "1", Variety: "Rose", BoxCount: 0.25, TotalStems: 50, FarmName: "Gamma"},
+				{ID: "2", Variety: "Tulip", BoxCount: 0.25, TotalStems: 50, FarmName: "Gamma"},
+				{ID: "3", Variety: "Lily", BoxCount: 0.25, TotalStems: 40, FarmName: "Gamma"},
+				{ID: "4", Variety: "Carnation", BoxCount: 0.25, TotalStems: 60, FarmName: "Gamma"},
+				{ID: "5", Variety: "Gerbera", BoxCount: 0.25, TotalStems: 30, FarmName: "Gamma"},
+			},
+			boxTypes:        fullAndHalfBoxes("Gamma"),
+			wantBoxes:       []string{"Gamma:FB*", "Gamma:HB"},
+			wantEquivalent:  1.25,
+			wantUnderfilled: true,
+			wantWarning:     "box 2 (Gamma, HB) is filled by 50%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.Order{ID: "order", Items: tt.items}
+			plan := Pack(order, tt.boxTypes)
+
+			got := boxCodes(plan)
+			if strings.Join(got, ",") != strings.Join(tt.wantBoxes, ",") {
+				t.Errorf("boxes = %v, want %v", got, tt.wantBoxes)
+			}
+			if plan.TotalBoxes != len(tt.wantBoxes) {
+				t.Errorf("TotalBoxes = %d, want %d", plan.TotalBoxes, len(tt.wantBoxes))
+			}
+			if plan.FullBoxEquivalent != tt.wantEquivalent {
+				t.Errorf("FullBoxEquivalent = %v, want %v", plan.FullBoxEquivalent, tt.wantEquivalent)
+			}
+			if plan.Underfilled != tt.wantUnderfilled {
+				t.Errorf("Underfilled = %v, want %v", plan.Underfilled, tt.wantUnderfilled)
+			}
+			if tt.wantWarning != "" && !strings.Contains(strings.Join(plan.Warnings, "\n"), tt.wantWarning) {
+				t.Errorf("warnings %v do not contain %q", plan.Warnings, tt.wantWarning)
+			}
+			for i, box := range plan.Boxes {
+				if box.Number != i+1 {
+					t.Errorf("box %d has number %d", i, box.Number)
+				}
+			}
+
+			stems := make(map[string]int)
+			for _, box := range plan.Boxes {
+				for _, c := range box.Contents {
+					stems[c.ItemID] += c.Stems
+				}
+			}
+			for _, item := range tt.items {
+				if stems[item.ID] != item.TotalStems {
+					t.Errorf("item %s packed %d stems, want %d", item.ID, stems[item.ID], item.TotalStems)
+				}
+			}
+		})
+	}
+}
+
+func TestPackStemsWithRounding(t *testing.T) {
+	tests := []struct {
+		name       string
+		boxCount   float64
+		totalStems int
+	}{
+		{name: "uneven stems per box", boxCount: 3, totalStems: 100},
+		{name: "fractional box count", boxCount: 2.75, totalStems: 333},
+		{name: "fewer stems than boxes", boxCount: 4, totalStems: 3},
+		{name: "only a remainder", boxCount: 0.75, totalStems: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.Order{
+				ID:    "order",
+				Items: []domain.Item{{ID: "1", BoxCount: tt.boxCount, TotalStems: tt.totalStems, FarmName: "Alpha"}},
+			}
+			plan := Pack(order, nil)
+
+			var stems int
+			for _, box := range plan.Boxes {
+				for _, c := range box.Contents {
+					if c.Stems < 0 {
+						t.Errorf("box %d has %d stems", box.Number, c.Stems)
+					}
+					stems += c.Stems
+				}
+			}
+			if stems != tt.totalStems {
+				t.Errorf("packed %d stems, want %d", stems, tt.totalStems)
+			}
+		})
+	}
+}
+
+func TestPackWeights(t *testing.T) {
+	order := &domain.Order{
+		ID:    "order",
+		Items: []domain.Item{{ID: "1", BoxCount: 1.25, TotalStems: 250, FarmName: "Alpha"}},
+	}
+	plan := Pack(order, nil)
+
+	// FB 30 кг и QB 8 кг; объемный вес 105*50*30/6000 + 105*25*15/6000
+	if plan.GrossWeightKg != 38 {
+		t.Errorf("GrossWeightKg = %v, want 38", plan.GrossWeightKg)
+	}
+	if plan.VolumetricWeightKg != 32.81 {
+		t.Errorf("VolumetricWeightKg = %v, want 32.81", plan.VolumetricWeightKg)
+	}
+	if plan.ChargeableWeightKg != 38 {
+		t.Errorf("ChargeableWeightKg = %v, want 38", plan.ChargeableWeightKg)
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Rate описывает пропускную способность корзины токенов
+type Rate struct {
+	// RequestsPerMinute скорость пополнения корзины
+	RequestsPerMinute int
+	// Burst емкость корзины: сколько запросов можно выполнить подряд
+	Burst int
+}
+
+// Decision результат проверки лимита
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter решает, можно ли пропустить запрос с указанным ключом.
+// Реализация в памяти подходит для одного экземпляра сервиса;
+// для нескольких экземпляров достаточно реализовать интерфейс
+// поверх общего хранилища.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Decision, error)
+}
+
+// idleTTL время, после которого неиспользуемая корзина удаляется
+const idleTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryLimiter реализует алгоритм token bucket в памяти процесса
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	rate      Rate
+	perSecond float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+var _ Limiter = (*MemoryLimiter)(nil)
+
+// NewMemoryLimiter создает лимитер с одинаковой скоростью для всех ключей
+func NewMemoryLimiter(rate Rate) *MemoryLimiter {
+	return &MemoryLimiter{
+		rate:      rate,
+		perSecond: float64(rate.RequestsPerMinute) / 60,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow списывает токен из корзины ключа, если он есть
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	burst := float64(l.rate.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+	b.last = now
+
+	decision := Decision{Limit: l.rate.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+		decision.Remaining = int(b.tokens)
+		return decision, nil
+	}
+
+	if l.perSecond > 0 {
+		seconds := (1 - b.tokens) / l.perSecond
+		decision.RetryAfter = time.Duration(math.Ceil(seconds * float64(time.Second)))
+	} else {
+		decision.RetryAfter = time.Minute
+	}
+	return decision, nil
+}
+
+// sweep удаляет давно не используемые корзины, чтобы карта не росла бесконечно
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.AdjustmentRepository = (*Repository)(nil)
+
+func (r *Repository) CreateAdjustmentRule(ctx context.Context, rule *domain.AdjustmentRule) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO adjustment_rules (id, name, kind, scope, method, percent, value, currency, min_stems, active,
+			created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, rule.ID, rule.Name, rule.Kind, rule.Scope, rule.Method, rule.Percent, rule.Value, nullString(rule.Currency),
+		rule.MinStems, rule.Active, rule.CreatedAt,
+	)
+	return err
+}
+
+func (r *Repository) ListAdjustmentRules(ctx context.Context, activeOnly bool) ([]domain.AdjustmentRule, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT id, name, kind, scope, method, percent, value, currency, min_stems, active, created_at
+		FROM adjustment_rules
+		WHERE active OR NOT $1
+		ORDER BY created_at
+	`, activeOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.AdjustmentRule
+	for rows.Next() {
+		var rule domain.AdjustmentRule
+		var currency sql.NullString
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Name,
+			&rule.Kind,
+			&rule.Scope,
+			&rule.Method,
+			&rule.Percent,
+			&rule.Value,
+			&currency,
+			&rule.MinStems,
+			&rule.Active,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rule.Currency = currency.String
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *Repository) DeleteAdjustmentRule(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM adjustment_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("adjustment rule %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// SaveFreightRate создает или заменяет тариф доставки грузовика
+func (r *Repository) SaveFreightRate(ctx context.Context, rate *domain.FreightRate) error {
+	return r.db.QueryRowContext(
+		ctx, `
+		INSERT INTO freight_rates (truck_name, per_box, currency)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (truck_name) DO UPDATE
+		SET per_box = EXCLUDED.per_box, currency = EXCLUDED.currency, updated_at = NOW()
+		RETURNING updated_at
+	`, rate.TruckName, rate.PerBox, rate.Currency,
+	).Scan(&rate.UpdatedAt)
+}
+
+func (r *Repository) ListFreightRates(ctx context.Context) ([]domain.FreightRate, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT truck_name, per_box, currency, updated_at FROM freight_rates ORDER BY truck_name
+	`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []domain.FreightRate
+	for rows.Next() {
+		var rate domain.FreightRate
+		if err := rows.Scan(&rate.TruckName, &rate.PerBox, &rate.Currency, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// saveOrderAdjustments заменяет корректировки заказа в рамках транзакции
+func saveOrderAdjustments(ctx context.Context, tx *sql.Tx, order *domain.Order) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_adjustments WHERE order_id = $1`, order.ID); err != nil {
+		return err
+	}
+	for _, adj := range order.Adjustments {
+		_, err := tx.ExecContext(
+			ctx, `
+			INSERT INTO order_adjustments (id, order_id, item_id, kind, method, source, description, rule_id, percent,
+				value, currency, amount, tax_rate, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, adj.ID, order.ID, nullString(adj.ItemID), adj.Kind, adj.Method, adj.Source, adj.Description,
+			nullString(adj.RuleID), adj.Percent, adj.Value, nullString(adj.Currency), adj.Amount, adj.TaxRate,
+			adj.TaxAmount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadOrderAdjustments загружает корректировки заказа
+func (r *Repository) loadOrderAdjustments(ctx context.Context, order *domain.Order) error {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT id, item_id, kind, method, source, description, rule_id, percent, value, currency, amount, tax_rate,
+			tax_amount
+		FROM order_adjustments WHERE order_id = $1
+		ORDER BY item_id NULLS LAST, id
+	`, order.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var adj domain.Adjustment
+		var itemID, description, ruleID, currency sql.NullString
+		err := rows.Scan(
+			&adj.ID,
+			&itemID,
+			&adj.Kind,
+			&adj.Method,
+			&adj.Source,
+			&description,
+			&ruleID,
+			&adj.Percent,
+			&adj.Value,
+			&currency,
+			&adj.Amount,
+			&adj.TaxRate,
+			&adj.TaxAmount,
+		)
+		if err != nil {
+			return err
+		}
+		adj.ItemID = itemID.String
+		adj.Description = description.String
+		adj.RuleID = ruleID.String
+		adj.Currency = currency.String
+		order.Adjustments = append(order.Adjustments, adj)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.BoxTypeRepository = (*Repository)(nil)
+
+func (r *Repository) CreateBoxType(ctx context.Context, boxType *domain.BoxType) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO box_types (id, farm_name, code, name, size, length_cm, width_cm, height_cm, tare_weight_kg,
+			gross_weight_kg, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, boxType.ID, boxType.FarmName, boxType.Code, nullString(boxType.Name), boxType.Size, boxType.LengthCm,
+		boxType.WidthCm, boxType.HeightCm, boxType.TareWeightKg, boxType.GrossWeightKg, boxType.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: box type %s already exists for farm %s", domain.ErrConflict, boxType.Code,
+			boxType.FarmName)
+	}
+	return err
+}
+
+func (r *Repository) ListBoxTypes(ctx context.Context, farmName string) ([]domain.BoxType, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT id, farm_name, code, name, size, length_cm, width_cm, height_cm, tare_weight_kg, gross_weight_kg,
+			created_at
+		FROM box_types
+		WHERE $1 = '' OR farm_name = $1
+		ORDER BY farm_name, size DESC
+	`, farmName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	boxTypes := []domain.BoxType{}
+	for rows.Next() {
+		var boxType domain.BoxType
+		var name sql.NullString
+		err := rows.Scan(
+			&boxType.ID,
+			&boxType.FarmName,
+			&boxType.Code,
+			&name,
+			&boxType.Size,
+			&boxType.LengthCm,
+			&boxType.WidthCm,
+			&boxType.HeightCm,
+			&boxType.TareWeightKg,
+			&boxType.GrossWeightKg,
+			&boxType.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		boxType.Name = name.String
+		boxTypes = append(boxTypes, boxType)
+	}
+
+	return boxTypes, rows.Err()
+}
+
+func (r *Repository) DeleteBoxType(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM box_types WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("box type %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
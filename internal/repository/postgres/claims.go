@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.ClaimRepository = (*Repository)(nil)
+
+const claimColumns = `id, order_id, item_id, farm_name, variety, length, reason, claimed_stems, approved_stems, status,
+	currency, credit, description, resolution, created_at, updated_at`
+
+const claimPhotoColumns = `id, claim_id, file_name, content_type, size_bytes, storage_path, created_at`
+
+// CreateClaim сохраняет рекламацию в одной транзакции с проверкой заявленных стеблей.
+// Строка позиции блокируется, чтобы параллельные рекламации не превысили число стеблей.
+func (r *Repository) CreateClaim(ctx context.Context, claim *domain.Claim) error {
+	log := logger.FromContext(ctx).WithField("item_id", claim.ItemID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var itemStems int
+	err = tx.QueryRowContext(
+		ctx, `SELECT total_stems FROM order_items WHERE id = $1 AND order_id = $2 FOR UPDATE`,
+		claim.ItemID, claim.OrderID,
+	).Scan(&itemStems)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("item %s in order %s: %w", claim.ItemID, claim.OrderID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	var claimed int
+	err = tx.QueryRowContext(
+		ctx, `SELECT COALESCE(SUM(claimed_stems), 0) FROM claims WHERE item_id = $1 AND status <> $2`,
+		claim.ItemID, domain.ClaimStatusRejected,
+	).Scan(&claimed)
+	if err != nil {
+		return err
+	}
+	if err := claim.CheckClaimedStems(itemStems, claimed); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx, `
+		INSERT INTO claims (`+claimColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, claim.ID, claim.OrderID, claim.ItemID, claim.FarmName, claim.Variety, claim.Length, claim.Reason,
+		claim.ClaimedStems, claim.ApprovedStems, claim.Status, claim.Currency, claim.Credit,
+		nullString(claim.Description), nullString(claim.Resolution), claim.CreatedAt, claim.UpdatedAt,
+	)
+	if err != nil {
+		log.WithError(err).Error("Failed to insert claim")
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetClaim(ctx context.Context, id string) (*domain.Claim, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+claimColumns+` FROM claims WHERE id = $1`, id)
+	claim, err := scanClaim(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("claim %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if claim.Photos, err = r.listClaimPhotos(ctx, id); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+func (r *Repository) ListClaims(ctx context.Context, filter domain.ClaimFilter) ([]domain.Claim, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrderID != "" {
+		addCondition("order_id = $%d", filter.OrderID)
+	}
+	if filter.FarmName != "" {
+		addCondition("farm_name = $%d", filter.FarmName)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	if filter.OrderFrom != nil {
+		addCondition("order_id IN (SELECT id FROM orders WHERE created_at >= $%d)", *filter.OrderFrom)
+	}
+	if filter.OrderTo != nil {
+		addCondition("order_id IN (SELECT id FROM orders WHERE created_at < $%d)", *filter.OrderTo)
+	}
+
+	query := `SELECT ` + claimColumns + ` FROM claims`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claims := []domain.Claim{}
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, *claim)
+	}
+	return claims, rows.Err()
+}
+
+func (r *Repository) UpdateClaim(ctx context.Context, claim *domain.Claim, previous domain.ClaimStatus) error {
+	result, err := r.db.ExecContext(
+		ctx, `
+		UPDATE claims
+		SET status = $1, approved_stems = $2, credit = $3, resolution = $4, updated_at = $5
+		WHERE id = $6 AND status = $7
+	`, claim.Status, claim.ApprovedStems, claim.Credit, nullString(claim.Resolution), claim.UpdatedAt,
+		claim.ID, previous,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: claim %s is no longer %s", domain.ErrConflict, claim.ID, previous)
+	}
+	return nil
+}
+
+func (r *Repository) AddClaimPhoto(ctx context.Context, photo *domain.ClaimPhoto) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO claim_photos (`+claimPhotoColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, photo.ID, photo.ClaimID, photo.FileName, photo.ContentType, photo.SizeBytes, photo.StoragePath,
+		photo.CreatedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetClaimPhoto(ctx context.Context, claimID, photoID string) (*domain.ClaimPhoto, error) {
+	row := r.db.QueryRowContext(
+		ctx, `SELECT `+claimPhotoColumns+` FROM claim_photos WHERE id = $1 AND claim_id = $2`, photoID, claimID,
+	)
+	photo, err := scanClaimPhoto(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("photo %s of claim %s: %w", photoID, claimID, domain.ErrNotFound)
+	}
+	return photo, err
+}
+
+// ListDeliveredStems суммирует стебли позиций заказов, отправленных фермам или завершенных
+func (r *Repository) ListDeliveredStems(ctx context.Context, from, to *time.Time) ([]domain.DeliveredStems, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT i.farm_name, i.variety, SUM(i.total_stems)
+		FROM order_items i
+		JOIN orders o ON o.id = i.order_id
+		LEFT JOIN farm_orders f ON f.order_id = i.order_id AND f.farm_name = i.farm_name
+		WHERE (o.status = $1 OR f.status = $2)
+			AND ($3::timestamptz IS NULL OR o.created_at >= $3)
+			AND ($4::timestamptz IS NULL OR o.created_at < $4)
+		GROUP BY i.farm_name, i.variety
+	`, domain.OrderStatusCompleted, domain.FarmOrderStatusDelivered, nullTime(from), nullTime(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var delivered []domain.DeliveredStems
+	for rows.Next() {
+		var d domain.DeliveredStems
+		if err := rows.Scan(&d.FarmName, &d.Variety, &d.Stems); err != nil {
+			return nil, err
+		}
+		delivered = append(delivered, d)
+	}
+	return delivered, rows.Err()
+}
+
+func (r *Repository) listClaimPhotos(ctx context.Context, claimID string) ([]domain.ClaimPhoto, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `SELECT `+claimPhotoColumns+` FROM claim_photos WHERE claim_id = $1 ORDER BY created_at`, claimID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []domain.ClaimPhoto{}
+	for rows.Next() {
+		photo, err := scanClaimPhoto(rows)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, *photo)
+	}
+	return photos, rows.Err()
+}
+
+func scanClaim(row rowScanner) (*domain.Claim, error) {
+	var claim domain.Claim
+	var description, resolution sql.NullString
+	err := row.Scan(
+		&claim.ID,
+		&claim.OrderID,
+		&claim.ItemID,
+		&claim.FarmName,
+		&claim.Variety,
+		&claim.Length,
+		&claim.Reason,
+		&claim.ClaimedStems,
+		&claim.ApprovedStems,
+		&claim.Status,
+		&claim.Currency,
+		&claim.Credit,
+		&description,
+		&resolution,
+		&claim.CreatedAt,
+		&claim.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	claim.Description = description.String
+	claim.Resolution = resolution.String
+	claim.Photos = []domain.ClaimPhoto{}
+	return &claim, nil
+}
+
+func scanClaimPhoto(row rowScanner) (*domain.ClaimPhoto, error) {
+	var photo domain.ClaimPhoto
+	err := row.Scan(
+		&photo.ID,
+		&photo.ClaimID,
+		&photo.FileName,
+		&photo.ContentType,
+		&photo.SizeBytes,
+		&photo.StoragePath,
+		&photo.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// nullTime преобразует необязательное время в значение для запроса
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
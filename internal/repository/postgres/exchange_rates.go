@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.ExchangeRateRepository = (*Repository)(nil)
+
+func (r *Repository) CreateExchangeRate(ctx context.Context, rate *domain.ExchangeRate) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO exchange_rates (from_currency, to_currency, rate, effective_at)
+		VALUES ($1, $2, $3, $4)
+	`, rate.FromCurrency, rate.ToCurrency, rate.Rate, rate.EffectiveAt,
+	)
+	return err
+}
+
+// ListExchangeRates возвращает историю курсов, новые первыми.
+// Пустые from и to не ограничивают выборку.
+func (r *Repository) ListExchangeRates(ctx context.Context, from, to string) ([]domain.ExchangeRate, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT from_currency, to_currency, rate, effective_at
+		FROM exchange_rates
+		WHERE ($1 = '' OR from_currency = $1) AND ($2 = '' OR to_currency = $2)
+		ORDER BY from_currency, to_currency, effective_at DESC
+	`, from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []domain.ExchangeRate
+	for rows.Next() {
+		var rate domain.ExchangeRate
+		if err := rows.Scan(&rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &rate.EffectiveAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *Repository) FindExchangeRate(ctx context.Context, from, to string, at time.Time) (*domain.ExchangeRate, error) {
+	var rate domain.ExchangeRate
+	err := r.db.QueryRowContext(
+		ctx, `
+		SELECT from_currency, to_currency, rate, effective_at
+		FROM exchange_rates
+		WHERE from_currency = $1 AND to_currency = $2 AND effective_at <= $3
+		ORDER BY effective_at DESC, id DESC
+		LIMIT 1
+	`, from, to, at,
+	).Scan(&rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &rate.EffectiveAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("exchange rate %s->%s: %w", from, to, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// loadOrderExchangeRates загружает курсы, зафиксированные в заказе
+func (r *Repository) loadOrderExchangeRates(ctx context.Context, order *domain.Order) error {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT from_currency, to_currency, rate, effective_at
+		FROM order_exchange_rates WHERE order_id = $1
+		ORDER BY from_currency
+	`, order.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate domain.ExchangeRate
+		if err := rows.Scan(&rate.FromCurrency, &rate.ToCurrency, &rate.Rate, &rate.EffectiveAt); err != nil {
+			return err
+		}
+		order.ExchangeRates = append(order.ExchangeRates, rate)
+	}
+
+	return rows.Err()
+}
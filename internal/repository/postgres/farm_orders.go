@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.FarmOrderRepository = (*Repository)(nil)
+
+const farmOrderColumns = `f.id, f.order_id, f.farm_name, o.mark_box, f.status, f.notes, f.created_at, f.updated_at`
+
+func (r *Repository) CreateFarmOrders(ctx context.Context, farmOrders []domain.FarmOrder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, farmOrder := range farmOrders {
+		_, err := tx.ExecContext(
+			ctx, `
+			INSERT INTO farm_orders (id, order_id, farm_name, status, notes, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (order_id, farm_name) DO NOTHING
+		`, farmOrder.ID, farmOrder.OrderID, farmOrder.FarmName, farmOrder.Status, nullString(farmOrder.Notes),
+			farmOrder.CreatedAt, farmOrder.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetFarmOrder(ctx context.Context, id string) (*domain.FarmOrder, error) {
+	return getFarmOrder(ctx, r.db, id, false)
+}
+
+func (r *Repository) ListOrderFarmOrders(ctx context.Context, orderID string) ([]domain.FarmOrder, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT `+farmOrderColumns+`
+		FROM farm_orders f JOIN orders o ON o.id = f.order_id
+		WHERE f.order_id = $1
+		ORDER BY f.farm_name
+	`, orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	farmOrders := []domain.FarmOrder{}
+	for rows.Next() {
+		farmOrder, err := scanFarmOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		farmOrders = append(farmOrders, *farmOrder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range farmOrders {
+		items, err := loadOrderItems(ctx, r.db, farmOrders[i].OrderID, farmOrders[i].FarmName)
+		if err != nil {
+			return nil, err
+		}
+		farmOrders[i].Items = items
+	}
+	return farmOrders, nil
+}
+
+func (r *Repository) ListBoxScans(ctx context.Context, farmOrderID string) ([]domain.BoxScan, error) {
+	return listBoxScans(ctx, r.db, farmOrderID)
+}
+
+// RecordBoxScan сохраняет сканирование в одной транзакции со сверкой.
+// Строка заказа фермы блокируется, чтобы параллельные сканеры не приняли одну коробку дважды.
+func (r *Repository) RecordBoxScan(
+	ctx context.Context,
+	farmOrderID, code string,
+	scannedAt time.Time,
+) (*domain.BoxScan, *domain.ReceivingReport, error) {
+	log := logger.FromContext(ctx).WithField("farm_order_id", farmOrderID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	farmOrder, err := getFarmOrder(ctx, tx, farmOrderID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	scans, err := listBoxScans(ctx, tx, farmOrderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scan, err := farmOrder.ClassifyScan(code, scans)
+	if err != nil {
+		return nil, nil, err
+	}
+	scan.ID = uuid.New().String()
+	scan.ScannedAt = scannedAt
+
+	_, err = tx.ExecContext(
+		ctx, `
+		INSERT INTO box_scans (id, farm_order_id, code, item_id, box, status, scanned_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, scan.ID, scan.FarmOrderID, scan.Code, nullString(scan.ItemID), sql.NullInt64{
+			Int64: int64(scan.Box),
+			Valid: scan.Box > 0,
+		}, scan.Status, scan.ScannedAt,
+	)
+	if err != nil {
+		log.WithError(err).Error("Failed to insert box scan")
+		return nil, nil, err
+	}
+
+	report := farmOrder.Reconcile(append(scans, scan))
+	if report.Complete && farmOrder.Status != domain.FarmOrderStatusDelivered {
+		_, err = tx.ExecContext(
+			ctx, `UPDATE farm_orders SET status = $1, updated_at = NOW() WHERE id = $2`,
+			domain.FarmOrderStatusDelivered, farmOrderID,
+		)
+		if err != nil {
+			log.WithError(err).Error("Failed to mark farm order delivered")
+			return nil, nil, err
+		}
+		report.Status = domain.FarmOrderStatusDelivered
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithError(err).Error("Failed to commit box scan")
+		return nil, nil, err
+	}
+	return &scan, &report, nil
+}
+
+// getFarmOrder читает заказ фермы с позициями; forUpdate блокирует строку до конца транзакции
+func getFarmOrder(ctx context.Context, q queryer, id string, forUpdate bool) (*domain.FarmOrder, error) {
+	query := `SELECT ` + farmOrderColumns + ` FROM farm_orders f JOIN orders o ON o.id = f.order_id WHERE f.id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE OF f`
+	}
+	rows, err := q.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("farm order %s: %w", id, domain.ErrNotFound)
+	}
+	farmOrder, err := scanFarmOrder(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	farmOrder.Items, err = loadOrderItems(ctx, q, farmOrder.OrderID, farmOrder.FarmName)
+	if err != nil {
+		return nil, err
+	}
+	return farmOrder, nil
+}
+
+func listBoxScans(ctx context.Context, q queryer, farmOrderID string) ([]domain.BoxScan, error) {
+	rows, err := q.QueryContext(
+		ctx, `
+		SELECT id, farm_order_id, code, item_id, box, status, scanned_at
+		FROM box_scans WHERE farm_order_id = $1
+		ORDER BY scanned_at
+	`, farmOrderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scans := []domain.BoxScan{}
+	for rows.Next() {
+		var scan domain.BoxScan
+		var itemID sql.NullString
+		var box sql.NullInt64
+		err := rows.Scan(
+			&scan.ID,
+			&scan.FarmOrderID,
+			&scan.Code,
+			&itemID,
+			&box,
+			&scan.Status,
+			&scan.ScannedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		scan.ItemID = itemID.String
+		scan.Box = int(box.Int64)
+		scans = append(scans, scan)
+	}
+
+	return scans, rows.Err()
+}
+
+func scanFarmOrder(row rowScanner) (*domain.FarmOrder, error) {
+	var farmOrder domain.FarmOrder
+	var notes sql.NullString
+	err := row.Scan(
+		&farmOrder.ID,
+		&farmOrder.OrderID,
+		&farmOrder.FarmName,
+		&farmOrder.MarkBox,
+		&farmOrder.Status,
+		&notes,
+		&farmOrder.CreatedAt,
+		&farmOrder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	farmOrder.Notes = notes.String
+	return &farmOrder, nil
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.FarmRepository = (*Repository)(nil)
+
+const farmColumns = `id, name, email, phone, country, time_zone, cutoff_time, lead_time_days, active, notes,
+	created_at, updated_at`
+
+func (r *Repository) CreateFarm(ctx context.Context, farm *domain.Farm) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO farms (`+farmColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, farm.ID, farm.Name, nullString(farm.Email), nullString(farm.Phone), nullString(farm.Country), farm.TimeZone,
+		farm.CutoffTime, farm.LeadTimeDays, farm.Active, nullString(farm.Notes), farm.CreatedAt, farm.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: farm %q already exists", domain.ErrConflict, farm.Name)
+	}
+	return err
+}
+
+func (r *Repository) UpdateFarm(ctx context.Context, farm *domain.Farm) error {
+	err := r.db.QueryRowContext(
+		ctx, `
+		UPDATE farms
+		SET name = $1, email = $2, phone = $3, country = $4, time_zone = $5, cutoff_time = $6, lead_time_days = $7,
+			active = $8, notes = $9, updated_at = NOW()
+		WHERE id = $10
+		RETURNING created_at, updated_at
+	`, farm.Name, nullString(farm.Email), nullString(farm.Phone), nullString(farm.Country), farm.TimeZone,
+		farm.CutoffTime, farm.LeadTimeDays, farm.Active, nullString(farm.Notes), farm.ID,
+	).Scan(&farm.CreatedAt, &farm.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("farm %s: %w", farm.ID, domain.ErrNotFound)
+	}
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: farm %q already exists", domain.ErrConflict, farm.Name)
+	}
+	return err
+}
+
+func (r *Repository) GetFarm(ctx context.Context, id string) (*domain.Farm, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+farmColumns+` FROM farms WHERE id = $1`, id)
+	farm, err := scanFarm(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("farm %s: %w", id, domain.ErrNotFound)
+	}
+	return farm, err
+}
+
+func (r *Repository) GetFarmByName(ctx context.Context, name string) (*domain.Farm, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+farmColumns+` FROM farms WHERE name = $1`, name)
+	farm, err := scanFarm(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("farm %q: %w", name, domain.ErrNotFound)
+	}
+	return farm, err
+}
+
+func (r *Repository) ListFarms(ctx context.Context) ([]domain.Farm, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+farmColumns+` FROM farms ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	farms := []domain.Farm{}
+	for rows.Next() {
+		farm, err := scanFarm(rows)
+		if err != nil {
+			return nil, err
+		}
+		farms = append(farms, *farm)
+	}
+	return farms, rows.Err()
+}
+
+// ListFarmItems выбирает позиции фермы по farm_id; без периода в выборку
+// попадают и заказы без даты отправки
+func (r *Repository) ListFarmItems(
+	ctx context.Context,
+	farmID string,
+	from, to *time.Time,
+) ([]domain.FarmExportItem, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT o.id, o.mark_box, o.ship_date, o.delivery_date, i.variety, i.length, i.box_count, i.pack_rate,
+			i.total_stems, i.truck_name, i.comments
+		FROM order_items i
+		JOIN orders o ON o.id = i.order_id
+		WHERE i.farm_id = $1 AND o.status <> $2
+			AND ($3::date IS NULL OR o.ship_date >= $3::date)
+			AND ($4::date IS NULL OR o.ship_date <= $4::date)
+		ORDER BY o.ship_date NULLS LAST, o.mark_box, i.variety, i.length
+	`, farmID, domain.OrderStatusCancelled, nullDate(from), nullDate(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.FarmExportItem{}
+	for rows.Next() {
+		var item domain.FarmExportItem
+		var shipDate, deliveryDate sql.NullTime
+		var comments sql.NullString
+		err := rows.Scan(
+			&item.OrderID,
+			&item.MarkBox,
+			&shipDate,
+			&deliveryDate,
+			&item.Variety,
+			&item.Length,
+			&item.BoxCount,
+			&item.PackRate,
+			&item.TotalStems,
+			&item.TruckName,
+			&comments,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if shipDate.Valid {
+			item.ShipDate = &shipDate.Time
+		}
+		if deliveryDate.Valid {
+			item.DeliveryDate = &deliveryDate.Time
+		}
+		item.Comments = comments.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func scanFarm(row rowScanner) (*domain.Farm, error) {
+	var farm domain.Farm
+	var email, phone, country, notes sql.NullString
+	err := row.Scan(
+		&farm.ID,
+		&farm.Name,
+		&email,
+		&phone,
+		&country,
+		&farm.TimeZone,
+		&farm.CutoffTime,
+		&farm.LeadTimeDays,
+		&farm.Active,
+		&notes,
+		&farm.CreatedAt,
+		&farm.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	farm.Email = email.String
+	farm.Phone = phone.String
+	farm.Country = country.String
+	farm.Notes = notes.String
+	return &farm, nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.IdempotencyStore = (*Repository)(nil)
+
+// reserveIdempotencyAttempts число попыток занять ключ, если действующая запись
+// исчезла между вставкой и чтением (освобождена или удалена очисткой)
+const reserveIdempotencyAttempts = 3
+
+// ReserveIdempotencyKey занимает ключ до record.ExpiresAt. Истекшая запись, в том числе
+// незавершенная после падения процесса, перезаписывается так же, как отсутствующая.
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	for attempt := 0; attempt < reserveIdempotencyAttempts; attempt++ {
+		var key string
+		err := r.db.QueryRowContext(
+			ctx, `
+			INSERT INTO idempotency_keys (key, request_hash, expires_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_body = NULL,
+				created_at = NOW(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= NOW()
+			RETURNING key
+		`, record.Key, record.RequestHash, record.ExpiresAt,
+		).Scan(&key)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		var existing domain.IdempotencyRecord
+		var statusCode sql.NullInt64
+		err = r.db.QueryRowContext(
+			ctx, `
+			SELECT key, request_hash, status_code, response_body, created_at, expires_at
+			FROM idempotency_keys WHERE key = $1
+		`, record.Key,
+		).Scan(
+			&existing.Key,
+			&existing.RequestHash,
+			&statusCode,
+			&existing.ResponseBody,
+			&existing.CreatedAt,
+			&existing.ExpiresAt,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			// запись освобождена между вставкой и чтением: ключ снова свободен
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing.StatusCode = int(statusCode.Int64)
+
+		return &existing, nil
+	}
+	return nil, fmt.Errorf("%w: idempotency key %s is being reserved concurrently", domain.ErrConflict, record.Key)
+}
+
+func (r *Repository) CompleteIdempotencyKey(
+	ctx context.Context,
+	key string,
+	statusCode int,
+	body []byte,
+	expiresAt time.Time,
+) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		UPDATE idempotency_keys SET status_code = $1, response_body = $2, expires_at = $3 WHERE key = $4
+	`, statusCode, body, expiresAt, key,
+	)
+	return err
+}
+
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL
+	`, key,
+	)
+	return err
+}
+
+func (r *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,300 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.InvoiceRepository = (*Repository)(nil)
+
+const invoiceColumns = `id, number, type, year, sequence, order_id, customer_id, customer_name, street, city, state,
+	postal_code, country, vat_id, mark_box, consignee_name, consignee_street, consignee_city, consignee_state,
+	consignee_postal_code, consignee_country, consignee_phone, destination_airport, currency, reverse_charge,
+	credited_invoice_id, reason, subtotal, tax_total, total, issued_at`
+
+// CreateInvoice сохраняет документ в одной транзакции с выдачей номера.
+// Строка заказа блокируется, чтобы два счета по заказу не выставлялись одновременно.
+func (r *Repository) CreateInvoice(ctx context.Context, invoice *domain.Invoice) error {
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"order_id": invoice.OrderID,
+		"type":     invoice.Type,
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var orderID string
+	err = tx.QueryRowContext(ctx, `SELECT id FROM orders WHERE id = $1 FOR UPDATE`, invoice.OrderID).Scan(&orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("order %s: %w", invoice.OrderID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	if invoice.Type == domain.InvoiceTypeInvoice {
+		var open int
+		err = tx.QueryRowContext(
+			ctx, `
+			SELECT COUNT(*) FROM invoices i
+			WHERE i.order_id = $1 AND i.type = $2
+				AND NOT EXISTS (SELECT 1 FROM invoices c WHERE c.credited_invoice_id = i.id)
+		`, invoice.OrderID, domain.InvoiceTypeInvoice,
+		).Scan(&open)
+		if err != nil {
+			return err
+		}
+		if open > 0 {
+			return fmt.Errorf("%w: order %s already has an issued invoice", domain.ErrConflict, invoice.OrderID)
+		}
+	}
+	if invoice.CreditedInvoiceID != "" {
+		var credited bool
+		err = tx.QueryRowContext(
+			ctx, `SELECT EXISTS (SELECT 1 FROM invoices WHERE credited_invoice_id = $1)`, invoice.CreditedInvoiceID,
+		).Scan(&credited)
+		if err != nil {
+			return err
+		}
+		if credited {
+			return fmt.Errorf("%w: invoice %s is already credited", domain.ErrConflict, invoice.CreditedInvoiceID)
+		}
+	}
+
+	err = tx.QueryRowContext(
+		ctx, `
+		INSERT INTO invoice_sequences (series, year, last_value)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (series, year) DO UPDATE SET last_value = invoice_sequences.last_value + 1
+		RETURNING last_value
+	`, invoice.Type.Series(), invoice.Year,
+	).Scan(&invoice.Sequence)
+	if err != nil {
+		log.WithError(err).Error("Failed to allocate invoice number")
+		return err
+	}
+	invoice.Number = domain.FormatInvoiceNumber(invoice.Type, invoice.Year, invoice.Sequence)
+
+	address := invoice.BillingAddress
+	var consignee domain.Consignee
+	if invoice.Consignee != nil {
+		consignee = *invoice.Consignee
+	}
+	_, err = tx.ExecContext(
+		ctx, `
+		INSERT INTO invoices (`+invoiceColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+			$23, $24, $25, $26, $27, $28, $29, $30, $31)
+	`, invoice.ID, invoice.Number, invoice.Type, invoice.Year, invoice.Sequence, invoice.OrderID, invoice.CustomerID,
+		nullString(invoice.CustomerName), nullString(address.Street), nullString(address.City),
+		nullString(address.State), nullString(address.PostalCode), nullString(address.Country),
+		nullString(invoice.VATID), invoice.MarkBox, nullString(consignee.Name), nullString(consignee.Address.Street),
+		nullString(consignee.Address.City), nullString(consignee.Address.State),
+		nullString(consignee.Address.PostalCode), nullString(consignee.Address.Country), nullString(consignee.Phone),
+		nullString(consignee.DestinationAirport), invoice.Currency, invoice.ReverseCharge,
+		nullString(invoice.CreditedInvoiceID), nullString(invoice.Reason), invoice.Subtotal,
+		invoice.TaxTotal, invoice.Total, invoice.IssuedAt,
+	)
+	if err != nil {
+		log.WithError(err).Error("Failed to insert invoice")
+		return err
+	}
+
+	for _, line := range invoice.Lines {
+		_, err = tx.ExecContext(
+			ctx, `
+			INSERT INTO invoice_lines (invoice_id, position, description, item_id, variety, length, farm_name, stems,
+				unit_price, price_currency, amount, tax_rate, tax_amount, total)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		`, invoice.ID, line.Position, line.Description, nullString(line.ItemID), nullString(line.Variety),
+			line.Length, nullString(line.FarmName), line.Stems, line.UnitPrice, nullString(line.PriceCurrency),
+			line.Amount, line.TaxRate, line.TaxAmount, line.Total,
+		)
+		if err != nil {
+			log.WithError(err).Error("Failed to insert invoice line")
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithError(err).Error("Failed to commit invoice transaction")
+		return err
+	}
+
+	log.WithField("number", invoice.Number).Debug("Invoice stored")
+	return nil
+}
+
+func (r *Repository) GetInvoice(ctx context.Context, id string) (*domain.Invoice, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1`, id)
+	invoice, err := scanInvoice(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("invoice %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadInvoiceLines(ctx, invoice); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (r *Repository) ListOrderInvoices(ctx context.Context, orderID string) ([]domain.Invoice, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT `+invoiceColumns+` FROM invoices WHERE order_id = $1 ORDER BY issued_at DESC, number DESC
+	`, orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []domain.Invoice
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range invoices {
+		if err := r.loadInvoiceLines(ctx, &invoices[i]); err != nil {
+			return nil, err
+		}
+	}
+	return invoices, nil
+}
+
+func (r *Repository) loadInvoiceLines(ctx context.Context, invoice *domain.Invoice) error {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT position, description, item_id, variety, length, farm_name, stems, unit_price, price_currency, amount,
+			tax_rate, tax_amount, total
+		FROM invoice_lines WHERE invoice_id = $1
+		ORDER BY position
+	`, invoice.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line domain.InvoiceLine
+		var itemID, variety, farmName, priceCurrency sql.NullString
+		var length, stems sql.NullInt64
+		err := rows.Scan(
+			&line.Position,
+			&line.Description,
+			&itemID,
+			&variety,
+			&length,
+			&farmName,
+			&stems,
+			&line.UnitPrice,
+			&priceCurrency,
+			&line.Amount,
+			&line.TaxRate,
+			&line.TaxAmount,
+			&line.Total,
+		)
+		if err != nil {
+			return err
+		}
+		line.ItemID = itemID.String
+		line.Variety = variety.String
+		line.Length = int(length.Int64)
+		line.FarmName = farmName.String
+		line.Stems = int(stems.Int64)
+		line.PriceCurrency = priceCurrency.String
+		invoice.Lines = append(invoice.Lines, line)
+	}
+
+	return rows.Err()
+}
+
+func scanInvoice(row rowScanner) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+	var customerName, street, city, state, postalCode, country, vatID, creditedID, reason sql.NullString
+	var consigneeName, consigneeStreet, consigneeCity, consigneeState, consigneePostalCode, consigneeCountry,
+		consigneePhone, destinationAirport sql.NullString
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.Number,
+		&invoice.Type,
+		&invoice.Year,
+		&invoice.Sequence,
+		&invoice.OrderID,
+		&invoice.CustomerID,
+		&customerName,
+		&street,
+		&city,
+		&state,
+		&postalCode,
+		&country,
+		&vatID,
+		&invoice.MarkBox,
+		&consigneeName,
+		&consigneeStreet,
+		&consigneeCity,
+		&consigneeState,
+		&consigneePostalCode,
+		&consigneeCountry,
+		&consigneePhone,
+		&destinationAirport,
+		&invoice.Currency,
+		&invoice.ReverseCharge,
+		&creditedID,
+		&reason,
+		&invoice.Subtotal,
+		&invoice.TaxTotal,
+		&invoice.Total,
+		&invoice.IssuedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	invoice.CustomerName = customerName.String
+	invoice.BillingAddress = domain.Address{
+		Street:     street.String,
+		City:       city.String,
+		State:      state.String,
+		PostalCode: postalCode.String,
+		Country:    country.String,
+	}
+	invoice.VATID = vatID.String
+	if consigneeName.Valid {
+		invoice.Consignee = &domain.Consignee{
+			Name: consigneeName.String,
+			Address: domain.Address{
+				Street:     consigneeStreet.String,
+				City:       consigneeCity.String,
+				State:      consigneeState.String,
+				PostalCode: consigneePostalCode.String,
+				Country:    consigneeCountry.String,
+			},
+			Phone:              consigneePhone.String,
+			DestinationAirport: destinationAirport.String,
+		}
+	}
+	invoice.CreditedInvoiceID = creditedID.String
+	invoice.Reason = reason.String
+	return &invoice, nil
+}
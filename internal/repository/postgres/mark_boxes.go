@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.MarkBoxRepository = (*Repository)(nil)
+
+const markBoxColumns = `code, customer_id, consignee_name, street, city, state, postal_code, country, phone,
+	destination_airport, notes, active, created_at, updated_at`
+
+// SaveMarkBox создает код маркировки или обновляет существующий
+func (r *Repository) SaveMarkBox(ctx context.Context, markBox *domain.MarkBox) error {
+	consignee := markBox.Consignee
+	return r.db.QueryRowContext(
+		ctx, `
+		INSERT INTO mark_boxes (code, customer_id, consignee_name, street, city, state, postal_code, country, phone,
+			destination_airport, notes, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (code) DO UPDATE
+		SET customer_id = EXCLUDED.customer_id, consignee_name = EXCLUDED.consignee_name, street = EXCLUDED.street,
+			city = EXCLUDED.city, state = EXCLUDED.state, postal_code = EXCLUDED.postal_code,
+			country = EXCLUDED.country, phone = EXCLUDED.phone, destination_airport = EXCLUDED.destination_airport,
+			notes = EXCLUDED.notes, active = EXCLUDED.active, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, markBox.Code, nullString(markBox.CustomerID), consignee.Name, nullString(consignee.Address.Street),
+		nullString(consignee.Address.City), nullString(consignee.Address.State),
+		nullString(consignee.Address.PostalCode), nullString(consignee.Address.Country), nullString(consignee.Phone),
+		nullString(consignee.DestinationAirport), nullString(markBox.Notes), markBox.Active,
+	).Scan(&markBox.CreatedAt, &markBox.UpdatedAt)
+}
+
+func (r *Repository) GetMarkBox(ctx context.Context, code string) (*domain.MarkBox, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+markBoxColumns+` FROM mark_boxes WHERE code = $1`, code)
+	markBox, err := scanMarkBox(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("mark box %s: %w", code, domain.ErrNotFound)
+	}
+	return markBox, err
+}
+
+func (r *Repository) ListMarkBoxes(ctx context.Context, customerID string) ([]domain.MarkBox, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT `+markBoxColumns+` FROM mark_boxes
+		WHERE $1 = '' OR customer_id = $1 OR customer_id IS NULL
+		ORDER BY code
+	`, customerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	markBoxes := []domain.MarkBox{}
+	for rows.Next() {
+		markBox, err := scanMarkBox(rows)
+		if err != nil {
+			return nil, err
+		}
+		markBoxes = append(markBoxes, *markBox)
+	}
+
+	return markBoxes, rows.Err()
+}
+
+func (r *Repository) DeleteMarkBox(ctx context.Context, code string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM mark_boxes WHERE code = $1`, code)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("mark box %s: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func scanMarkBox(row rowScanner) (*domain.MarkBox, error) {
+	var markBox domain.MarkBox
+	var customerID, street, city, state, postalCode, country, phone, airport, notes sql.NullString
+	err := row.Scan(
+		&markBox.Code,
+		&customerID,
+		&markBox.Consignee.Name,
+		&street,
+		&city,
+		&state,
+		&postalCode,
+		&country,
+		&phone,
+		&airport,
+		&notes,
+		&markBox.Active,
+		&markBox.CreatedAt,
+		&markBox.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	markBox.CustomerID = customerID.String
+	markBox.Consignee.Address = domain.Address{
+		Street:     street.String,
+		City:       city.String,
+		State:      state.String,
+		PostalCode: postalCode.String,
+		Country:    country.String,
+	}
+	markBox.Consignee.Phone = phone.String
+	markBox.Consignee.DestinationAirport = airport.String
+	markBox.Notes = notes.String
+	return &markBox, nil
+}
@@ -0,0 +1,638 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migration описывает одно изменение схемы БД.
+// Версии должны идти по возрастанию без пропусков.
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "initial_schema",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS flowers (
+				id SERIAL PRIMARY KEY,
+				mark_box TEXT NOT NULL,
+				variety TEXT NOT NULL,
+				length INTEGER NOT NULL,
+				box_count NUMERIC(10, 2) NOT NULL,
+				pack_rate INTEGER NOT NULL,
+				total_stems INTEGER NOT NULL,
+				farm_name TEXT NOT NULL,
+				truck_name TEXT NOT NULL,
+				price NUMERIC(10, 2) DEFAULT 0,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE TABLE IF NOT EXISTS orders (
+				id UUID PRIMARY KEY,
+				mark_box TEXT NOT NULL,
+				customer_id TEXT NOT NULL,
+				status TEXT DEFAULT 'pending',
+				total_amount NUMERIC(10, 2) DEFAULT 0,
+				notes TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				processed_at TIMESTAMPTZ,
+				farm_order_id TEXT
+			)`,
+			`CREATE TABLE IF NOT EXISTS order_items (
+				id UUID PRIMARY KEY,
+				order_id UUID NOT NULL REFERENCES orders(id),
+				variety TEXT NOT NULL,
+				length INTEGER NOT NULL,
+				box_count NUMERIC(10, 2) NOT NULL,
+				pack_rate INTEGER NOT NULL,
+				total_stems INTEGER NOT NULL,
+				farm_name TEXT NOT NULL,
+				truck_name TEXT NOT NULL,
+				comments TEXT,
+				price NUMERIC(10, 2) DEFAULT 0
+			)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "idempotency_keys",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS idempotency_keys (
+				key TEXT PRIMARY KEY,
+				request_hash TEXT NOT NULL,
+				status_code INTEGER,
+				response_body BYTEA,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				expires_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "orders_version",
+		statements: []string{
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1`,
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
+			`UPDATE orders SET updated_at = created_at WHERE created_at IS NOT NULL`,
+		},
+	},
+	{
+		version: 4,
+		name:    "customers_and_prices",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS customers (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				email TEXT NOT NULL DEFAULT '',
+				phone TEXT NOT NULL DEFAULT '',
+				company TEXT NOT NULL DEFAULT '',
+				street TEXT NOT NULL DEFAULT '',
+				city TEXT NOT NULL DEFAULT '',
+				state TEXT NOT NULL DEFAULT '',
+				postal_code TEXT NOT NULL DEFAULT '',
+				country TEXT NOT NULL DEFAULT '',
+				tier TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE TABLE IF NOT EXISTS prices (
+				id UUID PRIMARY KEY,
+				variety TEXT NOT NULL,
+				length INTEGER NOT NULL,
+				farm_name TEXT NOT NULL,
+				price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+				customer_tier TEXT,
+				customer_id TEXT,
+				valid_from DATE NOT NULL,
+				valid_to DATE,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				CHECK (customer_tier IS NULL OR customer_id IS NULL),
+				CHECK (valid_to IS NULL OR valid_to >= valid_from)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_prices_lookup ON prices (variety, length, farm_name, valid_from)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "currencies_and_exchange_rates",
+		statements: []string{
+			`ALTER TABLE prices ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'`,
+			`ALTER TABLE customers ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'`,
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'`,
+			`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'`,
+			`CREATE TABLE IF NOT EXISTS exchange_rates (
+				id SERIAL PRIMARY KEY,
+				from_currency TEXT NOT NULL,
+				to_currency TEXT NOT NULL,
+				rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+				effective_at TIMESTAMPTZ NOT NULL,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_exchange_rates_lookup
+				ON exchange_rates (from_currency, to_currency, effective_at DESC)`,
+			`CREATE TABLE IF NOT EXISTS order_exchange_rates (
+				order_id UUID NOT NULL REFERENCES orders(id),
+				from_currency TEXT NOT NULL,
+				to_currency TEXT NOT NULL,
+				rate NUMERIC(18, 8) NOT NULL,
+				effective_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (order_id, from_currency, to_currency)
+			)`,
+		},
+	},
+	{
+		version: 6,
+		name:    "order_adjustments",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS adjustment_rules (
+				id UUID PRIMARY KEY,
+				name TEXT NOT NULL,
+				kind TEXT NOT NULL,
+				scope TEXT NOT NULL,
+				method TEXT NOT NULL,
+				percent NUMERIC(18, 8) NOT NULL DEFAULT 0,
+				value NUMERIC(10, 2) NOT NULL DEFAULT 0,
+				currency TEXT,
+				min_stems INTEGER NOT NULL DEFAULT 0,
+				active BOOLEAN NOT NULL DEFAULT TRUE,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE TABLE IF NOT EXISTS freight_rates (
+				truck_name TEXT PRIMARY KEY,
+				per_box NUMERIC(10, 2) NOT NULL CHECK (per_box >= 0),
+				currency TEXT NOT NULL DEFAULT 'USD',
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE TABLE IF NOT EXISTS order_adjustments (
+				id UUID PRIMARY KEY,
+				order_id UUID NOT NULL REFERENCES orders(id),
+				item_id UUID REFERENCES order_items(id),
+				kind TEXT NOT NULL,
+				method TEXT NOT NULL,
+				source TEXT NOT NULL,
+				description TEXT,
+				rule_id UUID,
+				percent NUMERIC(18, 8) NOT NULL DEFAULT 0,
+				value NUMERIC(10, 2) NOT NULL DEFAULT 0,
+				currency TEXT,
+				amount NUMERIC(10, 2) NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_order_adjustments_order_id ON order_adjustments (order_id)`,
+		},
+	},
+	{
+		version: 7,
+		name:    "invoices",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS invoice_sequences (
+				series TEXT NOT NULL,
+				year INTEGER NOT NULL,
+				last_value INTEGER NOT NULL,
+				PRIMARY KEY (series, year)
+			)`,
+			`CREATE TABLE IF NOT EXISTS invoices (
+				id UUID PRIMARY KEY,
+				number TEXT NOT NULL UNIQUE,
+				type TEXT NOT NULL,
+				year INTEGER NOT NULL,
+				sequence INTEGER NOT NULL,
+				order_id UUID NOT NULL REFERENCES orders(id),
+				customer_id TEXT NOT NULL,
+				customer_name TEXT,
+				street TEXT,
+				city TEXT,
+				state TEXT,
+				postal_code TEXT,
+				country TEXT,
+				mark_box TEXT NOT NULL,
+				currency TEXT NOT NULL,
+				credited_invoice_id UUID UNIQUE REFERENCES invoices(id),
+				reason TEXT,
+				subtotal NUMERIC(12, 2) NOT NULL,
+				tax_total NUMERIC(12, 2) NOT NULL,
+				total NUMERIC(12, 2) NOT NULL,
+				issued_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_invoices_order_id ON invoices (order_id)`,
+			`CREATE TABLE IF NOT EXISTS invoice_lines (
+				invoice_id UUID NOT NULL REFERENCES invoices(id),
+				position INTEGER NOT NULL,
+				description TEXT NOT NULL,
+				item_id UUID,
+				variety TEXT,
+				length INTEGER,
+				farm_name TEXT,
+				stems INTEGER,
+				unit_price NUMERIC(10, 2) NOT NULL DEFAULT 0,
+				price_currency TEXT,
+				amount NUMERIC(12, 2) NOT NULL,
+				tax_rate NUMERIC(18, 8) NOT NULL DEFAULT 0,
+				tax_amount NUMERIC(12, 2) NOT NULL DEFAULT 0,
+				total NUMERIC(12, 2) NOT NULL,
+				PRIMARY KEY (invoice_id, position)
+			)`,
+			`CREATE OR REPLACE FUNCTION forbid_invoice_change() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'issued invoices are immutable';
+			END;
+			$$ LANGUAGE plpgsql`,
+			`DROP TRIGGER IF EXISTS invoices_immutable ON invoices`,
+			`CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+				FOR EACH ROW EXECUTE FUNCTION forbid_invoice_change()`,
+			`DROP TRIGGER IF EXISTS invoice_lines_immutable ON invoice_lines`,
+			`CREATE TRIGGER invoice_lines_immutable BEFORE UPDATE OR DELETE ON invoice_lines
+				FOR EACH ROW EXECUTE FUNCTION forbid_invoice_change()`,
+		},
+	},
+	{
+		version: 8,
+		name:    "tax_rules",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS tax_rules (
+				id UUID PRIMARY KEY,
+				country CHAR(2) NOT NULL,
+				category TEXT,
+				rate NUMERIC(18, 8) NOT NULL CHECK (rate >= 0),
+				reverse_charge BOOLEAN NOT NULL DEFAULT FALSE,
+				description TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rules_country_category
+				ON tax_rules (country, COALESCE(category, ''))`,
+			`ALTER TABLE customers ADD COLUMN IF NOT EXISTS vat_id TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS reverse_charge BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT 'flowers'`,
+			`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(18, 8) NOT NULL DEFAULT 0`,
+			`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(12, 2) NOT NULL DEFAULT 0`,
+			`ALTER TABLE order_adjustments ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(18, 8) NOT NULL DEFAULT 0`,
+			`ALTER TABLE order_adjustments ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(12, 2) NOT NULL DEFAULT 0`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS vat_id TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS reverse_charge BOOLEAN NOT NULL DEFAULT FALSE`,
+		},
+	},
+	{
+		version: 9,
+		name:    "shipments",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS shipments (
+				id UUID PRIMARY KEY,
+				truck_name TEXT NOT NULL,
+				departure_date DATE NOT NULL,
+				capacity_boxes NUMERIC(10, 2) NOT NULL CHECK (capacity_boxes > 0),
+				notes TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_shipments_departure ON shipments (departure_date, truck_name)`,
+			`CREATE TABLE IF NOT EXISTS shipment_items (
+				item_id UUID PRIMARY KEY REFERENCES order_items(id),
+				shipment_id UUID NOT NULL REFERENCES shipments(id),
+				assigned_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_shipment_items_shipment_id ON shipment_items (shipment_id)`,
+		},
+	},
+	{
+		version: 10,
+		name:    "box_types",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS box_types (
+				id UUID PRIMARY KEY,
+				farm_name TEXT NOT NULL,
+				code TEXT NOT NULL,
+				name TEXT,
+				size NUMERIC(6, 4) NOT NULL CHECK (size > 0),
+				length_cm NUMERIC(6, 1) NOT NULL,
+				width_cm NUMERIC(6, 1) NOT NULL,
+				height_cm NUMERIC(6, 1) NOT NULL,
+				tare_weight_kg NUMERIC(6, 2) NOT NULL,
+				gross_weight_kg NUMERIC(6, 2) NOT NULL,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				UNIQUE (farm_name, code)
+			)`,
+		},
+	},
+	{
+		version: 11,
+		name:    "mark_boxes",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS mark_boxes (
+				code TEXT PRIMARY KEY,
+				customer_id TEXT,
+				consignee_name TEXT NOT NULL,
+				street TEXT,
+				city TEXT,
+				state TEXT,
+				postal_code TEXT,
+				country TEXT,
+				phone TEXT,
+				destination_airport TEXT,
+				notes TEXT,
+				active BOOLEAN NOT NULL DEFAULT TRUE,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_mark_boxes_customer_id ON mark_boxes (customer_id)`,
+			// VVA уже используется в каталоге и существующих заказах
+			`INSERT INTO mark_boxes (code, consignee_name) VALUES ('VVA', 'VVA') ON CONFLICT (code) DO NOTHING`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_name TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_street TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_city TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_state TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_postal_code TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_country TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS consignee_phone TEXT`,
+			`ALTER TABLE invoices ADD COLUMN IF NOT EXISTS destination_airport TEXT`,
+		},
+	},
+	{
+		version: 12,
+		name:    "farm_orders_receiving",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS farm_orders (
+				id UUID PRIMARY KEY,
+				order_id UUID NOT NULL REFERENCES orders(id),
+				farm_name TEXT NOT NULL,
+				status TEXT NOT NULL DEFAULT 'sent',
+				notes TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW(),
+				UNIQUE (order_id, farm_name)
+			)`,
+			`CREATE TABLE IF NOT EXISTS box_scans (
+				id UUID PRIMARY KEY,
+				farm_order_id UUID NOT NULL REFERENCES farm_orders(id),
+				code TEXT NOT NULL,
+				item_id UUID,
+				box INTEGER,
+				status TEXT NOT NULL,
+				scanned_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_box_scans_farm_order_id ON box_scans (farm_order_id, scanned_at)`,
+		},
+	},
+	{
+		version: 13,
+		name:    "claims",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS claims (
+				id UUID PRIMARY KEY,
+				order_id UUID NOT NULL REFERENCES orders(id),
+				item_id UUID NOT NULL REFERENCES order_items(id),
+				farm_name TEXT NOT NULL,
+				variety TEXT NOT NULL,
+				length INTEGER NOT NULL,
+				reason TEXT NOT NULL,
+				claimed_stems INTEGER NOT NULL CHECK (claimed_stems > 0),
+				approved_stems INTEGER NOT NULL DEFAULT 0,
+				status TEXT NOT NULL DEFAULT 'open',
+				currency TEXT NOT NULL,
+				credit NUMERIC(10, 2) NOT NULL DEFAULT 0,
+				description TEXT,
+				resolution TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_claims_item_id ON claims (item_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_claims_farm_created ON claims (farm_name, created_at)`,
+			`CREATE TABLE IF NOT EXISTS claim_photos (
+				id UUID PRIMARY KEY,
+				claim_id UUID NOT NULL REFERENCES claims(id),
+				file_name TEXT NOT NULL,
+				content_type TEXT NOT NULL,
+				size_bytes BIGINT NOT NULL,
+				storage_path TEXT NOT NULL,
+				created_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_claim_photos_claim_id ON claim_photos (claim_id)`,
+		},
+	},
+	{
+		version: 14,
+		name:    "farms",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS farms (
+				id UUID PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				email TEXT,
+				phone TEXT,
+				country TEXT,
+				time_zone TEXT NOT NULL DEFAULT 'UTC',
+				cutoff_time TEXT NOT NULL DEFAULT '00:00',
+				lead_time_days INTEGER NOT NULL DEFAULT 0 CHECK (lead_time_days >= 0),
+				active BOOLEAN NOT NULL DEFAULT TRUE,
+				notes TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			// существующие названия ферм переносятся в реестр с ID из md5 названия
+			`INSERT INTO farms (id, name)
+			SELECT md5(farm_name)::uuid, farm_name FROM (
+				SELECT farm_name FROM flowers
+				UNION SELECT farm_name FROM order_items
+				UNION SELECT farm_name FROM prices
+				UNION SELECT farm_name FROM box_types
+			) names
+			ON CONFLICT (name) DO NOTHING`,
+			`ALTER TABLE flowers ADD COLUMN IF NOT EXISTS farm_id UUID REFERENCES farms(id)`,
+			`ALTER TABLE order_items ADD COLUMN IF NOT EXISTS farm_id UUID REFERENCES farms(id)`,
+			`UPDATE flowers f SET farm_id = farms.id FROM farms WHERE farms.name = f.farm_name`,
+			`UPDATE order_items i SET farm_id = farms.id FROM farms WHERE farms.name = i.farm_name`,
+			`CREATE INDEX IF NOT EXISTS idx_order_items_farm_id ON order_items (farm_id)`,
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS ship_date DATE`,
+		},
+	},
+	{
+		version: 15,
+		name:    "delivery_dates_and_catalog_windows",
+		statements: []string{
+			`ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_date DATE`,
+			`CREATE INDEX IF NOT EXISTS idx_orders_ship_date ON orders (ship_date)`,
+			`ALTER TABLE flowers ADD COLUMN IF NOT EXISTS available_from DATE`,
+			`ALTER TABLE flowers ADD COLUMN IF NOT EXISTS available_to DATE`,
+		},
+	},
+	{
+		version: 16,
+		name:    "recurring_orders",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS recurring_orders (
+				id UUID PRIMARY KEY,
+				customer_id TEXT NOT NULL,
+				mark_box TEXT NOT NULL,
+				currency TEXT,
+				notes TEXT,
+				frequency TEXT NOT NULL,
+				weekdays TEXT[] NOT NULL DEFAULT '{}',
+				interval_days INTEGER NOT NULL DEFAULT 0,
+				start_date DATE NOT NULL,
+				end_date DATE,
+				skip_dates DATE[] NOT NULL DEFAULT '{}',
+				status TEXT NOT NULL DEFAULT 'active',
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				updated_at TIMESTAMPTZ DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_recurring_orders_customer_id ON recurring_orders (customer_id)`,
+			`CREATE TABLE IF NOT EXISTS recurring_order_items (
+				id UUID PRIMARY KEY,
+				recurring_order_id UUID NOT NULL REFERENCES recurring_orders(id) ON DELETE CASCADE,
+				position INTEGER NOT NULL,
+				variety TEXT NOT NULL,
+				length INTEGER NOT NULL,
+				box_count NUMERIC(10, 2) NOT NULL,
+				pack_rate INTEGER NOT NULL,
+				total_stems INTEGER NOT NULL,
+				farm_name TEXT NOT NULL,
+				truck_name TEXT NOT NULL,
+				comments TEXT,
+				category TEXT
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_recurring_order_items_order_id ON recurring_order_items (recurring_order_id)`,
+			`CREATE TABLE IF NOT EXISTS recurring_order_runs (
+				recurring_order_id UUID NOT NULL REFERENCES recurring_orders(id),
+				ship_date DATE NOT NULL,
+				order_id UUID REFERENCES orders(id),
+				error TEXT,
+				created_at TIMESTAMPTZ DEFAULT NOW(),
+				PRIMARY KEY (recurring_order_id, ship_date)
+			)`,
+		},
+	},
+	{
+		version: 17,
+		name:    "recurring_order_run_retries",
+		statements: []string{
+			`ALTER TABLE recurring_order_runs ADD COLUMN IF NOT EXISTS failed BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE recurring_order_runs ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ`,
+		},
+	},
+	{
+		version: 18,
+		name:    "mark_boxes_from_orders",
+		statements: []string{
+			// Реестр mark box изначально содержал только VVA; коды из существующих
+			// заказов регистрируются без клиента, чтобы их можно было повторять и менять
+			`INSERT INTO mark_boxes (code, consignee_name)
+			SELECT DISTINCT mark_box, mark_box FROM orders WHERE mark_box <> ''
+			ON CONFLICT (code) DO NOTHING`,
+		},
+	},
+}
+
+// migrationLockID ключ advisory-блокировки, под которой экземпляры приложения
+// по очереди применяют миграции и начальные данные
+const migrationLockID = 7_340_512_001
+
+// withMigrationLock выполняет fn под сессионной advisory-блокировкой на выделенном
+// соединении. Экземпляры, запущенные одновременно, ждут, пока первый закончит.
+func (r *Repository) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+	}()
+
+	return fn(conn)
+}
+
+// migrate применяет недостающие миграции, каждую в отдельной транзакции.
+// Вызывается под withMigrationLock.
+func (r *Repository) migrate(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(
+		ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ DEFAULT NOW()
+		)
+	`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := r.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, conn, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, statement := range m.statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SchemaVersion возвращает версию последней примененной миграции
+func (r *Repository) SchemaVersion(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// LatestSchemaVersion возвращает версию последней миграции, известной приложению
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// CheckMigrations проверяет, что все миграции приложения применены. Более новая
+// схема допустима: при поэтапном обновлении новая версия мигрирует БД раньше,
+// чем останавливаются экземпляры старой, и они должны оставаться готовыми.
+func (r *Repository) CheckMigrations(ctx context.Context) error {
+	current, err := r.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if latest := LatestSchemaVersion(); current < latest {
+		return fmt.Errorf("schema version %d, expected at least %d", current, latest)
+	}
+	return nil
+}
+
+// Ping проверяет доступность базы данных
+func (r *Repository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсам
+var (
+	_ domain.PriceRepository    = (*Repository)(nil)
+	_ domain.CustomerRepository = (*Repository)(nil)
+)
+
+const dateLayout = "2006-01-02"
+
+const priceColumns = `id, variety, length, farm_name, price, currency, customer_tier, customer_id, valid_from, valid_to,
+	created_at`
+
+func (r *Repository) CreatePrice(ctx context.Context, entry *domain.PriceEntry) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO prices (id, variety, length, farm_name, price, currency, customer_tier, customer_id, valid_from,
+			valid_to, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, entry.ID, entry.Variety, entry.Length, entry.FarmName, entry.Price, entry.Currency, nullString(entry.CustomerTier),
+		nullString(entry.CustomerID), formatDate(entry.ValidFrom), nullDate(entry.ValidTo), entry.CreatedAt,
+	)
+	return err
+}
+
+func (r *Repository) ListPrices(ctx context.Context, filter domain.PriceFilter) ([]domain.PriceEntry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Variety != "" {
+		addCondition("variety = $%d", filter.Variety)
+	}
+	if filter.FarmName != "" {
+		addCondition("farm_name = $%d", filter.FarmName)
+	}
+	if filter.CustomerID != "" {
+		addCondition("customer_id = $%d", filter.CustomerID)
+	}
+	if filter.CustomerTier != "" {
+		addCondition("customer_tier = $%d", filter.CustomerTier)
+	}
+	if filter.ActiveOn != nil {
+		addCondition("valid_from <= $%d::date", formatDate(*filter.ActiveOn))
+		addCondition("(valid_to IS NULL OR valid_to >= $%d::date)", formatDate(*filter.ActiveOn))
+	}
+
+	query := `SELECT ` + priceColumns + ` FROM prices`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY variety, length, farm_name, valid_from`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []domain.PriceEntry
+	for rows.Next() {
+		entry, err := scanPrice(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, *entry)
+	}
+
+	return prices, rows.Err()
+}
+
+func (r *Repository) DeletePrice(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM prices WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("price %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *Repository) FindPrice(ctx context.Context, query domain.PriceQuery) (*domain.PriceEntry, error) {
+	row := r.db.QueryRowContext(
+		ctx, `
+		SELECT `+priceColumns+`
+		FROM prices
+		WHERE variety = $1 AND length = $2 AND farm_name = $3
+			AND valid_from <= $4::date AND (valid_to IS NULL OR valid_to >= $4::date)
+			AND (
+				customer_id = $5
+				OR (customer_tier = $6 AND $6 <> '')
+				OR (customer_id IS NULL AND customer_tier IS NULL)
+			)
+		ORDER BY
+			CASE
+				WHEN customer_id IS NOT NULL THEN 0
+				WHEN customer_tier IS NOT NULL THEN 1
+				ELSE 2
+			END,
+			valid_from DESC,
+			created_at DESC
+		LIMIT 1
+	`, query.Variety, query.Length, query.FarmName, formatDate(query.Date), query.CustomerID, query.CustomerTier,
+	)
+
+	entry, err := scanPrice(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("price for %s %dcm from %s: %w", query.Variety, query.Length, query.FarmName,
+			domain.ErrNotFound)
+	}
+	return entry, err
+}
+
+// FindCatalogPrice берет цену из каталога цветов, как GET /flowers для позиций без цены
+// в прайс-листе. Нулевая цена каталога означает, что цена не задана.
+func (r *Repository) FindCatalogPrice(
+	ctx context.Context,
+	variety string,
+	length int,
+	farmName string,
+) (*domain.PriceEntry, error) {
+	entry := domain.PriceEntry{
+		Variety:  variety,
+		Length:   length,
+		FarmName: farmName,
+		Currency: domain.DefaultCurrency,
+	}
+	err := r.db.QueryRowContext(
+		ctx, `
+		SELECT price FROM flowers
+		WHERE variety = $1 AND length = $2 AND farm_name = $3 AND price > 0
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, variety, length, farmName,
+	).Scan(&entry.Price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("catalog price for %s %dcm from %s: %w", variety, length, farmName,
+			domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *Repository) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	var customer domain.Customer
+	err := r.db.QueryRowContext(
+		ctx, `
+		SELECT id, name, email, phone, company, street, city, state, postal_code, country, tier, currency, vat_id,
+			created_at, updated_at
+		FROM customers WHERE id = $1
+	`, id,
+	).Scan(
+		&customer.ID,
+		&customer.Name,
+		&customer.Email,
+		&customer.Phone,
+		&customer.Company,
+		&customer.Address.Street,
+		&customer.Address.City,
+		&customer.Address.State,
+		&customer.Address.PostalCode,
+		&customer.Address.Country,
+		&customer.Tier,
+		&customer.Currency,
+		&customer.VATID,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("customer %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// SaveCustomer создает клиента или обновляет существующего
+func (r *Repository) SaveCustomer(ctx context.Context, customer *domain.Customer) error {
+	return r.db.QueryRowContext(
+		ctx, `
+		INSERT INTO customers (id, name, email, phone, company, street, city, state, postal_code, country, tier,
+			currency, vat_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE
+		SET name = EXCLUDED.name, email = EXCLUDED.email, phone = EXCLUDED.phone, company = EXCLUDED.company,
+			street = EXCLUDED.street, city = EXCLUDED.city, state = EXCLUDED.state,
+			postal_code = EXCLUDED.postal_code, country = EXCLUDED.country, tier = EXCLUDED.tier,
+			currency = EXCLUDED.currency, vat_id = EXCLUDED.vat_id, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, customer.ID, customer.Name, customer.Email, customer.Phone, customer.Company, customer.Address.Street,
+		customer.Address.City, customer.Address.State, customer.Address.PostalCode, customer.Address.Country,
+		customer.Tier, customer.Currency, customer.VATID,
+	).Scan(&customer.CreatedAt, &customer.UpdatedAt)
+}
+
+// rowScanner общий интерфейс sql.Row и sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPrice(row rowScanner) (*domain.PriceEntry, error) {
+	var entry domain.PriceEntry
+	var tier, customerID sql.NullString
+	var validTo sql.NullTime
+	err := row.Scan(
+		&entry.ID,
+		&entry.Variety,
+		&entry.Length,
+		&entry.FarmName,
+		&entry.Price,
+		&entry.Currency,
+		&tier,
+		&customerID,
+		&entry.ValidFrom,
+		&validTo,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.CustomerTier = tier.String
+	entry.CustomerID = customerID.String
+	if validTo.Valid {
+		entry.ValidTo = &validTo.Time
+	}
+	return &entry, nil
+}
+
+// formatDate передает дату без времени, чтобы сравнение с колонками DATE
+// не зависело от часового пояса сессии
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+func nullDate(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatDate(*t), Valid: true}
+}
+
+// nullString сохраняет пустую строку как NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,359 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.RecurringOrderRepository = (*Repository)(nil)
+
+const recurringOrderColumns = `id, customer_id, mark_box, currency, notes, frequency, weekdays, interval_days,
+	start_date, end_date, skip_dates, status, created_at, updated_at`
+
+// CreateRecurringOrder сохраняет шаблон вместе с позициями в одной транзакции
+func (r *Repository) CreateRecurringOrder(ctx context.Context, order *domain.RecurringOrder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx, `
+		INSERT INTO recurring_orders (`+recurringOrderColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::date[], $12, $13, $14)
+	`, order.ID, order.CustomerID, order.MarkBox, nullString(order.Currency), nullString(order.Notes),
+		order.Frequency, pq.Array(order.Weekdays), order.IntervalDays, formatDate(order.StartDate),
+		nullDate(order.EndDate), pq.Array(formatDates(order.SkipDates)), order.Status, order.CreatedAt,
+		order.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := insertRecurringOrderItems(ctx, tx, order); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) UpdateRecurringOrder(ctx context.Context, order *domain.RecurringOrder) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = tx.QueryRowContext(
+		ctx, `
+		UPDATE recurring_orders
+		SET customer_id = $1, mark_box = $2, currency = $3, notes = $4, frequency = $5, weekdays = $6,
+			interval_days = $7, start_date = $8, end_date = $9, skip_dates = $10::date[], updated_at = NOW()
+		WHERE id = $11
+		RETURNING status, created_at, updated_at
+	`, order.CustomerID, order.MarkBox, nullString(order.Currency), nullString(order.Notes), order.Frequency,
+		pq.Array(order.Weekdays), order.IntervalDays, formatDate(order.StartDate), nullDate(order.EndDate),
+		pq.Array(formatDates(order.SkipDates)), order.ID,
+	).Scan(&order.Status, &order.CreatedAt, &order.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("recurring order %s: %w", order.ID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx, `DELETE FROM recurring_order_items WHERE recurring_order_id = $1`, order.ID,
+	); err != nil {
+		return err
+	}
+	if err := insertRecurringOrderItems(ctx, tx, order); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) SetRecurringOrderStatus(
+	ctx context.Context,
+	id string,
+	status domain.RecurringOrderStatus,
+) error {
+	result, err := r.db.ExecContext(
+		ctx, `UPDATE recurring_orders SET status = $1, updated_at = NOW() WHERE id = $2`, status, id,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("recurring order %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *Repository) GetRecurringOrder(ctx context.Context, id string) (*domain.RecurringOrder, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+recurringOrderColumns+` FROM recurring_orders WHERE id = $1`, id)
+	order, err := scanRecurringOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("recurring order %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Items, err = r.loadRecurringOrderItems(ctx, order.ID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *Repository) ListRecurringOrders(
+	ctx context.Context,
+	filter domain.RecurringOrderFilter,
+) ([]domain.RecurringOrder, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.CustomerID != "" {
+		addCondition("customer_id = $%d", filter.CustomerID)
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	query := `SELECT ` + recurringOrderColumns + ` FROM recurring_orders`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []domain.RecurringOrder{}
+	for rows.Next() {
+		order, err := scanRecurringOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if orders[i].Items, err = r.loadRecurringOrderItems(ctx, orders[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+// StartRecurringRun занимает дату отправки шаблона. Первичный ключ по шаблону
+// и дате не дает нескольким экземплярам планировщика создать заказ дважды.
+// Незавершенная попытка (started_at задан) считается брошенной после staleBefore,
+// например если процесс упал между началом и завершением попытки.
+func (r *Repository) StartRecurringRun(
+	ctx context.Context,
+	run *domain.RecurringOrderRun,
+	staleBefore time.Time,
+) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO recurring_order_runs (recurring_order_id, ship_date, created_at, started_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (recurring_order_id, ship_date) DO UPDATE SET started_at = EXCLUDED.started_at
+		WHERE recurring_order_runs.order_id IS NULL AND NOT recurring_order_runs.failed
+			AND (recurring_order_runs.started_at IS NULL OR recurring_order_runs.started_at <= $4)
+	`, run.RecurringOrderID, formatDate(run.ShipDate), run.CreatedAt, staleBefore,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) FinishRecurringRun(ctx context.Context, run *domain.RecurringOrderRun) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		UPDATE recurring_order_runs SET order_id = $1, error = $2, failed = $3, started_at = NULL
+		WHERE recurring_order_id = $4 AND ship_date = $5
+	`, nullString(run.OrderID), nullString(run.Error), run.Failed, run.RecurringOrderID, formatDate(run.ShipDate),
+	)
+	return err
+}
+
+func (r *Repository) ListRecurringRuns(
+	ctx context.Context,
+	recurringOrderID string,
+	from time.Time,
+) ([]domain.RecurringOrderRun, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT recurring_order_id, ship_date, COALESCE(order_id::text, ''), COALESCE(error, ''), failed, created_at
+		FROM recurring_order_runs
+		WHERE recurring_order_id = $1 AND ship_date >= $2
+		ORDER BY ship_date
+	`, recurringOrderID, formatDate(from),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []domain.RecurringOrderRun{}
+	for rows.Next() {
+		var run domain.RecurringOrderRun
+		err := rows.Scan(&run.RecurringOrderID, &run.ShipDate, &run.OrderID, &run.Error, &run.Failed, &run.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func insertRecurringOrderItems(ctx context.Context, tx *sql.Tx, order *domain.RecurringOrder) error {
+	stmt, err := tx.PrepareContext(
+		ctx, `
+		INSERT INTO recurring_order_items (id, recurring_order_id, position, variety, length, box_count, pack_rate,
+			total_stems, farm_name, truck_name, comments, category)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, item := range order.Items {
+		_, err := stmt.ExecContext(
+			ctx, item.ID, order.ID, i, item.Variety, item.Length, item.BoxCount, item.PackRate, item.TotalStems,
+			item.FarmName, item.TruckName, nullString(item.Comments), nullString(item.Category),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) loadRecurringOrderItems(
+	ctx context.Context,
+	recurringOrderID string,
+) ([]domain.RecurringOrderItem, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT id, variety, length, box_count, pack_rate, total_stems, farm_name, truck_name,
+			COALESCE(comments, ''), COALESCE(category, '')
+		FROM recurring_order_items WHERE recurring_order_id = $1
+		ORDER BY position
+	`, recurringOrderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.RecurringOrderItem{}
+	for rows.Next() {
+		var item domain.RecurringOrderItem
+		err := rows.Scan(
+			&item.ID,
+			&item.Variety,
+			&item.Length,
+			&item.BoxCount,
+			&item.PackRate,
+			&item.TotalStems,
+			&item.FarmName,
+			&item.TruckName,
+			&item.Comments,
+			&item.Category,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func scanRecurringOrder(row rowScanner) (*domain.RecurringOrder, error) {
+	var order domain.RecurringOrder
+	var currency, notes sql.NullString
+	var endDate sql.NullTime
+	var weekdays, skipDates pq.StringArray
+	err := row.Scan(
+		&order.ID,
+		&order.CustomerID,
+		&order.MarkBox,
+		&currency,
+		&notes,
+		&order.Frequency,
+		&weekdays,
+		&order.IntervalDays,
+		&order.StartDate,
+		&endDate,
+		&skipDates,
+		&order.Status,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	order.Currency = currency.String
+	order.Notes = notes.String
+	order.Weekdays = []string(weekdays)
+	if endDate.Valid {
+		order.EndDate = &endDate.Time
+	}
+	order.SkipDates = make([]time.Time, 0, len(skipDates))
+	for _, value := range skipDates {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid skip date %q: %w", value, err)
+		}
+		order.SkipDates = append(order.SkipDates, date)
+	}
+	return &order, nil
+}
+
+func formatDates(dates []time.Time) []string {
+	formatted := make([]string, len(dates))
+	for i, date := range dates {
+		formatted[i] = formatDate(date)
+	}
+	return formatted
+}
@@ -3,12 +3,17 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/XSAM/otelsql"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 
 	"github.com/maxviazov/dolina-flower-order-backend/internal/config"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
 )
 
 type Repository struct {
@@ -22,7 +27,12 @@ func NewRepository(cfg config.DatabaseConfig) (*Repository, error) {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
 
-	db, err := sql.Open("postgres", dsn)
+	// otelsql создает спан на каждый SQL-запрос и транзакцию
+	db, err := otelsql.Open(
+		"postgres", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBNamespace(cfg.Name)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -32,61 +42,17 @@ func NewRepository(cfg config.DatabaseConfig) (*Repository, error) {
 	}
 
 	repo := &Repository{db: db}
-	if err := repo.createTables(); err != nil {
-		return nil, err
-	}
-
-	return repo, nil
-}
-
-func (r *Repository) createTables() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS flowers (
-			id SERIAL PRIMARY KEY,
-			mark_box TEXT NOT NULL,
-			variety TEXT NOT NULL,
-			length INTEGER NOT NULL,
-			box_count NUMERIC(10, 2) NOT NULL,
-			pack_rate INTEGER NOT NULL,
-			total_stems INTEGER NOT NULL,
-			farm_name TEXT NOT NULL,
-			truck_name TEXT NOT NULL,
-			price NUMERIC(10, 2) DEFAULT 0,
-			created_at TIMESTAMPTZ DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS orders (
-			id UUID PRIMARY KEY,
-			mark_box TEXT NOT NULL,
-			customer_id TEXT NOT NULL,
-			status TEXT DEFAULT 'pending',
-			total_amount NUMERIC(10, 2) DEFAULT 0,
-			notes TEXT,
-			created_at TIMESTAMPTZ DEFAULT NOW(),
-			processed_at TIMESTAMPTZ,
-			farm_order_id TEXT
-		)`,
-		`CREATE TABLE IF NOT EXISTS order_items (
-			id UUID PRIMARY KEY,
-			order_id UUID NOT NULL REFERENCES orders(id),
-			variety TEXT NOT NULL,
-			length INTEGER NOT NULL,
-			box_count NUMERIC(10, 2) NOT NULL,
-			pack_rate INTEGER NOT NULL,
-			total_stems INTEGER NOT NULL,
-			farm_name TEXT NOT NULL,
-			truck_name TEXT NOT NULL,
-			comments TEXT,
-			price NUMERIC(10, 2) DEFAULT 0
-		)`,
-	}
-
-	for _, query := range queries {
-		if _, err := r.db.Exec(query); err != nil {
+	err = repo.withMigrationLock(context.Background(), func(conn *sql.Conn) error {
+		if err := repo.migrate(context.Background(), conn); err != nil {
 			return err
 		}
+		return repo.insertTestData()
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return r.insertTestData()
+	return repo, nil
 }
 
 func (r *Repository) insertTestData() error {
@@ -121,8 +87,9 @@ func (r *Repository) insertTestData() error {
 
 	stmt, err := r.db.Prepare(
 		`
-		INSERT INTO flowers (mark_box, variety, length, box_count, pack_rate, total_stems, farm_name, truck_name)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO flowers (mark_box, variety, length, box_count, pack_rate, total_stems, farm_name, truck_name,
+			farm_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (SELECT id FROM farms WHERE name = $7))
 	`,
 	)
 	if err != nil {
@@ -131,7 +98,13 @@ func (r *Repository) insertTestData() error {
 	defer stmt.Close()
 
 	for _, flower := range testFlowers {
-		_, err := stmt.Exec(
+		_, err := r.db.Exec(
+			`INSERT INTO farms (id, name) VALUES (md5($1)::uuid, $1) ON CONFLICT (name) DO NOTHING`, flower.farmName,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = stmt.Exec(
 			flower.markBox,
 			flower.variety,
 			flower.length,
@@ -152,9 +125,18 @@ func (r *Repository) insertTestData() error {
 func (r *Repository) GetAvailableFlowers(ctx context.Context) ([]domain.Item, error) {
 	rows, err := r.db.QueryContext(
 		ctx, `
-		SELECT mark_box, variety, length, box_count, pack_rate, total_stems, farm_name, truck_name, price
-		FROM flowers
-		ORDER BY variety, length
+		SELECT f.mark_box, f.variety, f.length, f.box_count, f.pack_rate, f.total_stems, f.farm_name,
+			COALESCE(f.farm_id::text, ''), f.truck_name, COALESCE(p.price, f.price)
+		FROM flowers f
+		LEFT JOIN LATERAL (
+			SELECT price FROM prices
+			WHERE variety = f.variety AND length = f.length AND farm_name = f.farm_name
+				AND customer_id IS NULL AND customer_tier IS NULL
+				AND valid_from <= CURRENT_DATE AND (valid_to IS NULL OR valid_to >= CURRENT_DATE)
+			ORDER BY valid_from DESC, created_at DESC
+			LIMIT 1
+		) p ON TRUE
+		ORDER BY f.variety, f.length
 	`,
 	)
 	if err != nil {
@@ -174,6 +156,7 @@ func (r *Repository) GetAvailableFlowers(ctx context.Context) ([]domain.Item, er
 			&flower.PackRate,
 			&flower.TotalStems,
 			&flower.FarmName,
+			&flower.FarmID,
 			&flower.TruckName,
 			&flower.Price,
 		)
@@ -187,8 +170,11 @@ func (r *Repository) GetAvailableFlowers(ctx context.Context) ([]domain.Item, er
 }
 
 func (r *Repository) Create(ctx context.Context, order *domain.Order) error {
+	log := logger.FromContext(ctx).WithField("order_id", order.ID)
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
+		log.WithError(err).Error("Failed to begin transaction")
 		return err
 	}
 	defer func() {
@@ -197,38 +183,69 @@ func (r *Repository) Create(ctx context.Context, order *domain.Order) error {
 
 	_, err = tx.ExecContext(
 		ctx, `
-		INSERT INTO orders (id, mark_box, customer_id, status, total_amount, notes, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, order.ID, order.MarkBox, order.CustomerID, order.Status, order.TotalAmount, order.Notes, order.CreatedAt,
+		INSERT INTO orders (id, mark_box, customer_id, status, currency, total_amount, reverse_charge, notes,
+			ship_date, delivery_date, created_at, version, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`, order.ID, order.MarkBox, order.CustomerID, order.Status, order.Currency, order.TotalAmount,
+		order.ReverseCharge, order.Notes, nullDate(order.ShipDate), nullDate(order.DeliveryDate), order.CreatedAt,
+		order.Version, order.UpdatedAt,
 	)
 	if err != nil {
+		log.WithError(err).Error("Failed to insert order")
 		return err
 	}
 
 	for _, item := range order.Items {
 		_, err = tx.ExecContext(
 			ctx, `
-			INSERT INTO order_items (id, order_id, variety, length, box_count, pack_rate, total_stems, farm_name, truck_name, comments, price)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			INSERT INTO order_items (id, order_id, variety, length, box_count, pack_rate, total_stems, farm_name, farm_id, truck_name, comments, price, currency, category, tax_rate, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		`, item.ID, order.ID, item.Variety, item.Length, item.BoxCount, item.PackRate, item.TotalStems, item.FarmName,
-			item.TruckName, item.Comments, item.Price,
+			nullString(item.FarmID), item.TruckName, item.Comments, item.Price, item.Currency, item.Category,
+			item.TaxRate, item.TaxAmount,
 		)
 		if err != nil {
+			log.WithField("item_id", item.ID).WithError(err).Error("Failed to insert order item")
 			return err
 		}
 	}
 
-	return tx.Commit()
+	for _, rate := range order.ExchangeRates {
+		_, err = tx.ExecContext(
+			ctx, `
+			INSERT INTO order_exchange_rates (order_id, from_currency, to_currency, rate, effective_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, order.ID, rate.FromCurrency, rate.ToCurrency, rate.Rate, rate.EffectiveAt,
+		)
+		if err != nil {
+			log.WithError(err).Error("Failed to insert order exchange rate")
+			return err
+		}
+	}
+
+	if err := saveOrderAdjustments(ctx, tx, order); err != nil {
+		log.WithError(err).Error("Failed to insert order adjustments")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithError(err).Error("Failed to commit order transaction")
+		return err
+	}
+
+	log.Debugf("Order stored with %d items", len(order.Items))
+	return nil
 }
 
 func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
 	var order domain.Order
-	var processedAt sql.NullTime
+	var processedAt, shipDate, deliveryDate sql.NullTime
 	var farmOrderID sql.NullString
 
 	err := r.db.QueryRowContext(
 		ctx, `
-		SELECT id, mark_box, customer_id, status, total_amount, notes, created_at, processed_at, farm_order_id
+		SELECT id, mark_box, customer_id, status, currency, total_amount, reverse_charge, notes, ship_date,
+			delivery_date, created_at, processed_at, farm_order_id, version, updated_at
 		FROM orders WHERE id = $1
 	`, id,
 	).Scan(
@@ -236,12 +253,21 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, err
 		&order.MarkBox,
 		&order.CustomerID,
 		&order.Status,
+		&order.Currency,
 		&order.TotalAmount,
+		&order.ReverseCharge,
 		&order.Notes,
+		&shipDate,
+		&deliveryDate,
 		&order.CreatedAt,
 		&processedAt,
 		&farmOrderID,
+		&order.Version,
+		&order.UpdatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("order %s: %w", id, domain.ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -249,40 +275,28 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, err
 	if processedAt.Valid {
 		order.ProcessedAt = &processedAt.Time
 	}
+	if shipDate.Valid {
+		order.ShipDate = &shipDate.Time
+	}
+	if deliveryDate.Valid {
+		order.DeliveryDate = &deliveryDate.Time
+	}
 	if farmOrderID.Valid {
 		order.FarmOrderID = &farmOrderID.String
 	}
 
-	rows, err := r.db.QueryContext(
-		ctx, `
-		SELECT id, variety, length, box_count, pack_rate, total_stems, farm_name, truck_name, comments, price
-		FROM order_items WHERE order_id = $1
-	`, id,
-	)
-	if err != nil {
+	if order.Items, err = loadOrderItems(ctx, r.db, order.ID, ""); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var item domain.Item
-		err := rows.Scan(
-			&item.ID,
-			&item.Variety,
-			&item.Length,
-			&item.BoxCount,
-			&item.PackRate,
-			&item.TotalStems,
-			&item.FarmName,
-			&item.TruckName,
-			&item.Comments,
-			&item.Price,
-		)
-		if err != nil {
-			return nil, err
-		}
-		item.OrderID = order.ID
-		order.Items = append(order.Items, item)
+	if err := r.loadOrderExchangeRates(ctx, &order); err != nil {
+		return nil, err
+	}
+	if err := r.loadOrderAdjustments(ctx, &order); err != nil {
+		return nil, err
+	}
+	if _, err := order.CalculateTotal(); err != nil {
+		return nil, err
 	}
 
 	return &order, nil
@@ -291,7 +305,8 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, err
 func (r *Repository) GetByStatus(ctx context.Context, status domain.OrderStatus) ([]*domain.Order, error) {
 	rows, err := r.db.QueryContext(
 		ctx, `
-		SELECT id, mark_box, customer_id, status, total_amount, notes, created_at, processed_at, farm_order_id
+		SELECT id, mark_box, customer_id, status, currency, total_amount, reverse_charge, notes, ship_date,
+			delivery_date, created_at, processed_at, farm_order_id, version, updated_at
 		FROM orders WHERE status = $1
 		ORDER BY created_at DESC
 	`, status,
@@ -304,18 +319,24 @@ func (r *Repository) GetByStatus(ctx context.Context, status domain.OrderStatus)
 	var orders []*domain.Order
 	for rows.Next() {
 		var order domain.Order
-		var processedAt sql.NullTime
+		var processedAt, shipDate, deliveryDate sql.NullTime
 		var farmOrderID sql.NullString
 		err := rows.Scan(
 			&order.ID,
 			&order.MarkBox,
 			&order.CustomerID,
 			&order.Status,
+			&order.Currency,
 			&order.TotalAmount,
+			&order.ReverseCharge,
 			&order.Notes,
+			&shipDate,
+			&deliveryDate,
 			&order.CreatedAt,
 			&processedAt,
 			&farmOrderID,
+			&order.Version,
+			&order.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -323,6 +344,12 @@ func (r *Repository) GetByStatus(ctx context.Context, status domain.OrderStatus)
 		if processedAt.Valid {
 			order.ProcessedAt = &processedAt.Time
 		}
+		if shipDate.Valid {
+			order.ShipDate = &shipDate.Time
+		}
+		if deliveryDate.Valid {
+			order.DeliveryDate = &deliveryDate.Time
+		}
 		if farmOrderID.Valid {
 			order.FarmOrderID = &farmOrderID.String
 		}
@@ -332,17 +359,167 @@ func (r *Repository) GetByStatus(ctx context.Context, status domain.OrderStatus)
 	return orders, nil
 }
 
+// Update сохраняет заказ, цены позиций и корректировки, если версия в БД
+// совпадает с order.Version. При успехе order.Version и order.UpdatedAt
+// получают новые значения. Если заказ успел измениться, возвращается *domain.VersionConflictError.
 func (r *Repository) Update(ctx context.Context, order *domain.Order) error {
-	_, err := r.db.ExecContext(
+	log := logger.FromContext(ctx).WithField("order_id", order.ID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var version int
+	var updatedAt time.Time
+	err = tx.QueryRowContext(
 		ctx, `
-		UPDATE orders 
-		SET mark_box = $1, status = $2, total_amount = $3, notes = $4, processed_at = $5, farm_order_id = $6
-		WHERE id = $7
-	`, order.MarkBox, order.Status, order.TotalAmount, order.Notes, order.ProcessedAt, order.FarmOrderID, order.ID,
-	)
-	return err
+		UPDATE orders
+		SET mark_box = $1, status = $2, total_amount = $3, notes = $4, processed_at = $5, farm_order_id = $6,
+			reverse_charge = $7, version = version + 1, updated_at = NOW()
+		WHERE id = $8 AND version = $9
+		RETURNING version, updated_at
+	`, order.MarkBox, order.Status, order.TotalAmount, order.Notes, order.ProcessedAt, order.FarmOrderID,
+		order.ReverseCharge, order.ID, order.Version,
+	).Scan(&version, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.versionConflict(ctx, order)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to update order")
+		return err
+	}
+
+	for _, item := range order.Items {
+		_, err = tx.ExecContext(
+			ctx, `
+			UPDATE order_items SET price = $1, comments = $2, tax_rate = $3, tax_amount = $4
+			WHERE id = $5 AND order_id = $6
+		`, item.Price, item.Comments, item.TaxRate, item.TaxAmount, item.ID, order.ID,
+		)
+		if err != nil {
+			log.WithField("item_id", item.ID).WithError(err).Error("Failed to update order item")
+			return err
+		}
+	}
+
+	if err := saveOrderAdjustments(ctx, tx, order); err != nil {
+		log.WithError(err).Error("Failed to update order adjustments")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithError(err).Error("Failed to commit order update")
+		return err
+	}
+
+	order.Version = version
+	order.UpdatedAt = updatedAt
+	return nil
+}
+
+// versionConflict определяет, почему условное обновление не затронуло ни одной строки
+func (r *Repository) versionConflict(ctx context.Context, order *domain.Order) error {
+	var current int
+	err := r.db.QueryRowContext(ctx, `SELECT version FROM orders WHERE id = $1`, order.ID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("order %s: %w", order.ID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	return &domain.VersionConflictError{
+		Entity:          "order",
+		ID:              order.ID,
+		ExpectedVersion: order.Version,
+		CurrentVersion:  current,
+	}
+}
+
+// CountByStatus возвращает количество заказов в каждом статусе
+func (r *Repository) CountByStatus(ctx context.Context) (map[domain.OrderStatus]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM orders GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.OrderStatus]int)
+	for rows.Next() {
+		var status domain.OrderStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// DB возвращает пул соединений для сбора статистики
+func (r *Repository) DB() *sql.DB {
+	return r.db
 }
 
 func (r *Repository) Close() error {
 	return r.db.Close()
 }
+
+// queryer общий интерфейс чтения sql.DB и sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadOrderItems читает позиции заказа; с непустым farmName - только позиции этой фермы
+func loadOrderItems(ctx context.Context, q queryer, orderID, farmName string) ([]domain.Item, error) {
+	rows, err := q.QueryContext(
+		ctx, `
+		SELECT id, variety, length, box_count, pack_rate, total_stems, farm_name, COALESCE(farm_id::text, ''),
+			truck_name, comments, price, currency, category, tax_rate, tax_amount
+		FROM order_items WHERE order_id = $1 AND ($2 = '' OR farm_name = $2)
+	`, orderID, farmName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.Item
+	for rows.Next() {
+		var item domain.Item
+		err := rows.Scan(
+			&item.ID,
+			&item.Variety,
+			&item.Length,
+			&item.BoxCount,
+			&item.PackRate,
+			&item.TotalStems,
+			&item.FarmName,
+			&item.FarmID,
+			&item.TruckName,
+			&item.Comments,
+			&item.Price,
+			&item.Currency,
+			&item.Category,
+			&item.TaxRate,
+			&item.TaxAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.OrderID = orderID
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// isUniqueViolation сообщает, нарушено ли ограничение уникальности
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// List выбирает ID заказов по фильтру и загружает каждый заказ полностью
+func (r *Repository) List(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.ShipFrom != nil {
+		addCondition("ship_date >= $%d::date", formatDate(*filter.ShipFrom))
+	}
+	if filter.ShipTo != nil {
+		addCondition("ship_date <= $%d::date", formatDate(*filter.ShipTo))
+	}
+
+	query := `SELECT id FROM orders`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY ship_date NULLS LAST, created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	orders := []*domain.Order{}
+	for _, id := range ids {
+		order, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func (r *Repository) ListCatalogWindows(ctx context.Context) ([]domain.CatalogWindow, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT DISTINCT variety, length, farm_name, available_from, available_to
+		FROM flowers
+		ORDER BY farm_name, variety, length
+	`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []domain.CatalogWindow{}
+	for rows.Next() {
+		var window domain.CatalogWindow
+		var from, to sql.NullTime
+		if err := rows.Scan(&window.Variety, &window.Length, &window.FarmName, &from, &to); err != nil {
+			return nil, err
+		}
+		if from.Valid {
+			window.AvailableFrom = &from.Time
+		}
+		if to.Valid {
+			window.AvailableTo = &to.Time
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+func (r *Repository) SetCatalogWindow(ctx context.Context, window domain.CatalogWindow) error {
+	result, err := r.db.ExecContext(
+		ctx, `
+		UPDATE flowers SET available_from = $1, available_to = $2
+		WHERE variety = $3 AND length = $4 AND farm_name = $5
+	`, nullDate(window.AvailableFrom), nullDate(window.AvailableTo), window.Variety, window.Length, window.FarmName,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("catalog item %s %dcm from %s: %w", window.Variety, window.Length, window.FarmName,
+			domain.ErrNotFound)
+	}
+	return nil
+}
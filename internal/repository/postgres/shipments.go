@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.ShipmentRepository = (*Repository)(nil)
+
+const shipmentColumns = `id, truck_name, departure_date, capacity_boxes, notes, created_at`
+
+// shipmentItemColumns выбирает позицию заказа вместе с mark box заказа
+const shipmentItemColumns = `i.id, i.order_id, o.mark_box, i.variety, i.length, i.farm_name, i.truck_name, i.box_count,
+	i.total_stems`
+
+func (r *Repository) CreateShipment(ctx context.Context, shipment *domain.Shipment) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO shipments (`+shipmentColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, shipment.ID, shipment.TruckName, formatDate(shipment.DepartureDate), shipment.CapacityBoxes,
+		nullString(shipment.Notes), shipment.CreatedAt,
+	)
+	return err
+}
+
+func (r *Repository) GetShipment(ctx context.Context, id string) (*domain.Shipment, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+shipmentColumns+` FROM shipments WHERE id = $1`, id)
+	shipment, err := scanShipment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("shipment %s: %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, shipmentItemsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.Items, err = scanShipmentItems(rows); err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+func (r *Repository) ListShipments(ctx context.Context, filter domain.ShipmentFilter) ([]domain.Shipment, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.TruckName != "" {
+		addCondition("truck_name = $%d", filter.TruckName)
+	}
+	if filter.DepartureDate != nil {
+		addCondition("departure_date = $%d::date", formatDate(*filter.DepartureDate))
+	}
+
+	query := `SELECT ` + shipmentColumns + ` FROM shipments`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY departure_date, truck_name`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shipments := []domain.Shipment{}
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, *shipment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range shipments {
+		rows, err := r.db.QueryContext(ctx, shipmentItemsQuery, shipments[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		if shipments[i].Items, err = scanShipmentItems(rows); err != nil {
+			return nil, err
+		}
+	}
+	return shipments, nil
+}
+
+// AssignShipmentItems загружает позиции в рейс в одной транзакции.
+// Строка рейса блокируется, чтобы параллельные назначения не превысили вместимость.
+func (r *Repository) AssignShipmentItems(
+	ctx context.Context,
+	shipmentID string,
+	itemIDs []string,
+) (*domain.Shipment, error) {
+	log := logger.FromContext(ctx).WithField("shipment_id", shipmentID)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	row := tx.QueryRowContext(ctx, `SELECT `+shipmentColumns+` FROM shipments WHERE id = $1 FOR UPDATE`, shipmentID)
+	shipment, err := scanShipment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("shipment %s: %w", shipmentID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, shipmentItemsQuery, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment.Items, err = scanShipmentItems(rows); err != nil {
+		return nil, err
+	}
+
+	// Позиции блокируются, чтобы параллельное назначение на другой рейс
+	// дождалось этой транзакции; гонку, которую блокировка не закрывает,
+	// ловит первичный ключ shipment_items
+	rows, err = tx.QueryContext(
+		ctx, `
+		SELECT `+shipmentItemColumns+`
+		FROM order_items i
+		JOIN orders o ON o.id = i.order_id
+		LEFT JOIN shipment_items s ON s.item_id = i.id
+		WHERE i.id = ANY($1::uuid[]) AND o.status <> $2 AND (s.shipment_id IS NULL OR s.shipment_id = $3)
+		ORDER BY o.mark_box, i.variety, i.length
+		FOR UPDATE OF i
+	`, pq.Array(itemIDs), domain.OrderStatusCancelled, shipmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanShipmentItems(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != len(itemIDs) {
+		return nil, fmt.Errorf(
+			"%w: some items do not exist, belong to cancelled orders or are loaded on another shipment",
+			domain.ErrConflict,
+		)
+	}
+
+	if err := shipment.Load(items); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		_, err := tx.ExecContext(
+			ctx, `INSERT INTO shipment_items (item_id, shipment_id) VALUES ($1, $2)`, item.ItemID, shipmentID,
+		)
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("%w: item %s is loaded on another shipment", domain.ErrConflict, item.ItemID)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to assign shipment item")
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithError(err).Error("Failed to commit shipment assignment")
+		return nil, err
+	}
+
+	log.WithField("items", len(items)).Debug("Shipment items assigned")
+	return shipment, nil
+}
+
+func (r *Repository) RemoveShipmentItem(ctx context.Context, shipmentID, itemID string) error {
+	result, err := r.db.ExecContext(
+		ctx, `DELETE FROM shipment_items WHERE shipment_id = $1 AND item_id = $2`, shipmentID, itemID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("item %s in shipment %s: %w", itemID, shipmentID, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// shipmentItemsQuery выбирает позиции, загруженные в рейс
+const shipmentItemsQuery = `
+	SELECT ` + shipmentItemColumns + `
+	FROM shipment_items s
+	JOIN order_items i ON i.id = s.item_id
+	JOIN orders o ON o.id = i.order_id
+	WHERE s.shipment_id = $1
+	ORDER BY o.mark_box, i.variety, i.length`
+
+// scanShipmentItems читает позиции рейса и закрывает rows
+func scanShipmentItems(rows *sql.Rows) ([]domain.ShipmentItem, error) {
+	defer rows.Close()
+
+	items := []domain.ShipmentItem{}
+	for rows.Next() {
+		var item domain.ShipmentItem
+		err := rows.Scan(
+			&item.ItemID,
+			&item.OrderID,
+			&item.MarkBox,
+			&item.Variety,
+			&item.Length,
+			&item.FarmName,
+			&item.TruckName,
+			&item.BoxCount,
+			&item.TotalStems,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func scanShipment(row rowScanner) (*domain.Shipment, error) {
+	var shipment domain.Shipment
+	var notes sql.NullString
+	err := row.Scan(
+		&shipment.ID,
+		&shipment.TruckName,
+		&shipment.DepartureDate,
+		&shipment.CapacityBoxes,
+		&notes,
+		&shipment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	shipment.Notes = notes.String
+	return &shipment, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+// Проверка соответствия интерфейсу
+var _ domain.TaxRuleRepository = (*Repository)(nil)
+
+func (r *Repository) CreateTaxRule(ctx context.Context, rule *domain.TaxRule) error {
+	_, err := r.db.ExecContext(
+		ctx, `
+		INSERT INTO tax_rules (id, country, category, rate, reverse_charge, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, rule.ID, rule.Country, nullString(rule.Category), rule.Rate, rule.ReverseCharge, nullString(rule.Description),
+		rule.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: tax rule for %s/%s already exists", domain.ErrConflict, rule.Country, rule.Category)
+	}
+	return err
+}
+
+func (r *Repository) ListTaxRules(ctx context.Context, country string) ([]domain.TaxRule, error) {
+	rows, err := r.db.QueryContext(
+		ctx, `
+		SELECT id, country, category, rate, reverse_charge, description, created_at
+		FROM tax_rules
+		WHERE $1 = '' OR country = $1
+		ORDER BY country, category NULLS FIRST
+	`, country,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.TaxRule
+	for rows.Next() {
+		var rule domain.TaxRule
+		var category, description sql.NullString
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Country,
+			&category,
+			&rule.Rate,
+			&rule.ReverseCharge,
+			&description,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rule.Category = category.String
+		rule.Description = description.String
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *Repository) DeleteTaxRule(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tax_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("tax rule %s: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
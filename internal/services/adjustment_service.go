@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+type AdjustmentService struct {
+	repo domain.AdjustmentRepository
+}
+
+func NewAdjustmentService(repo domain.AdjustmentRepository) *AdjustmentService {
+	return &AdjustmentService{repo: repo}
+}
+
+func (s *AdjustmentService) CreateRule(
+	ctx context.Context, req dto.CreateAdjustmentRuleRequest,
+) (*domain.AdjustmentRule, error) {
+	rule := &domain.AdjustmentRule{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Kind:      domain.AdjustmentKind(req.Kind),
+		Scope:     domain.AdjustmentScope(req.Scope),
+		Method:    domain.AdjustmentMethod(req.Method),
+		Percent:   req.Percent,
+		Value:     req.Value,
+		MinStems:  req.MinStems,
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: time.Now(),
+	}
+	if rule.Scope == "" {
+		rule.Scope = domain.AdjustmentScopeOrder
+	}
+	if rule.Method == domain.AdjustmentFixed {
+		currency, err := normalizeCurrency(req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		rule.Currency = currency
+	}
+
+	adj := rule.Adjustment("")
+	if err := adj.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateAdjustmentRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to create adjustment rule: %w", err)
+	}
+
+	logger.FromContext(ctx).WithField("rule_id", rule.ID).Info("Adjustment rule created")
+	return rule, nil
+}
+
+func (s *AdjustmentService) ListRules(ctx context.Context) ([]domain.AdjustmentRule, error) {
+	return s.repo.ListAdjustmentRules(ctx, false)
+}
+
+func (s *AdjustmentService) DeleteRule(ctx context.Context, id string) error {
+	return s.repo.DeleteAdjustmentRule(ctx, id)
+}
+
+// SaveFreightRate задает стоимость доставки одной коробки грузовиком
+func (s *AdjustmentService) SaveFreightRate(
+	ctx context.Context, truckName string, req dto.SaveFreightRateRequest,
+) (*domain.FreightRate, error) {
+	if req.PerBox.IsNegative() {
+		return nil, fmt.Errorf("%w: per_box must not be negative", domain.ErrValidation)
+	}
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := &domain.FreightRate{TruckName: truckName, PerBox: req.PerBox, Currency: currency}
+	if err := s.repo.SaveFreightRate(ctx, rate); err != nil {
+		return nil, fmt.Errorf("failed to save freight rate: %w", err)
+	}
+	return rate, nil
+}
+
+func (s *AdjustmentService) ListFreightRates(ctx context.Context) ([]domain.FreightRate, error) {
+	return s.repo.ListFreightRates(ctx)
+}
+
+// ApplyRules добавляет к новому заказу доставку по тарифам грузовиков
+// и корректировки по действующим правилам
+func (s *AdjustmentService) ApplyRules(ctx context.Context, order *domain.Order) error {
+	freightRates, err := s.repo.ListFreightRates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list freight rates: %w", err)
+	}
+	rules, err := s.repo.ListAdjustmentRules(ctx, true)
+	if err != nil {
+		return fmt.Errorf("failed to list adjustment rules: %w", err)
+	}
+
+	freight := make(map[string]domain.FreightRate, len(freightRates))
+	for _, rate := range freightRates {
+		freight[rate.TruckName] = rate
+	}
+
+	var adjustments []domain.Adjustment
+	totalStems := 0
+	for _, item := range order.Items {
+		totalStems += item.TotalStems
+
+		if rate, ok := freight[item.TruckName]; ok && !rate.PerBox.IsZero() {
+			adjustments = append(adjustments, domain.Adjustment{
+				ItemID:   item.ID,
+				Kind:     domain.AdjustmentFreight,
+				Method:   domain.AdjustmentFixed,
+				Source:   domain.AdjustmentSourceFreight,
+				Value:    rate.PerBox.MulQuantity(item.BoxCount),
+				Currency: rate.Currency,
+				Description: fmt.Sprintf("Freight %s: %g boxes x %s %s",
+					item.TruckName, item.BoxCount, rate.PerBox, rate.Currency),
+			})
+		}
+
+		for i := range rules {
+			if rules[i].Scope == domain.AdjustmentScopeLine && rules[i].Applies(item.TotalStems) {
+				adjustments = append(adjustments, rules[i].Adjustment(item.ID))
+			}
+		}
+	}
+	for i := range rules {
+		if rules[i].Scope == domain.AdjustmentScopeOrder && rules[i].Applies(totalStems) {
+			adjustments = append(adjustments, rules[i].Adjustment(""))
+		}
+	}
+
+	for i := range adjustments {
+		adjustments[i].ID = uuid.New().String()
+	}
+	order.Adjustments = append(order.Adjustments, adjustments...)
+	return nil
+}
+
+// replaceManualAdjustments заменяет ручные корректировки заказа, сохраняя
+// автоматические (доставку и правила)
+func replaceManualAdjustments(order *domain.Order, reqs []dto.AdjustmentRequest) error {
+	adjustments := make([]domain.Adjustment, 0, len(order.Adjustments)+len(reqs))
+	for _, adj := range order.Adjustments {
+		if adj.Source != domain.AdjustmentSourceManual {
+			adjustments = append(adjustments, adj)
+		}
+	}
+
+	for _, req := range reqs {
+		adj := domain.Adjustment{
+			ID:          uuid.New().String(),
+			ItemID:      req.ItemID,
+			Kind:        domain.AdjustmentKind(req.Kind),
+			Method:      domain.AdjustmentMethod(req.Method),
+			Source:      domain.AdjustmentSourceManual,
+			Description: req.Description,
+			Percent:     req.Percent,
+			Value:       req.Value,
+		}
+		if adj.Method == domain.AdjustmentFixed {
+			adj.Currency = strings.ToUpper(req.Currency)
+			if adj.Currency == "" {
+				adj.Currency = order.Currency
+			}
+			if !domain.IsValidCurrency(adj.Currency) {
+				return fmt.Errorf("%w: invalid adjustment currency %q", domain.ErrValidation, req.Currency)
+			}
+		}
+		if adj.ItemID != "" && findItem(order, adj.ItemID) == nil {
+			return fmt.Errorf("%w: item %s does not belong to order", domain.ErrValidation, adj.ItemID)
+		}
+		if err := adj.Validate(); err != nil {
+			return err
+		}
+		adjustments = append(adjustments, adj)
+	}
+
+	order.Adjustments = adjustments
+	return nil
+}
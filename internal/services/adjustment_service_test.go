@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+)
+
+func TestReplaceManualAdjustments(t *testing.T) {
+	value, _ := domain.ParseMoney("10.00")
+	tests := []struct {
+		name         string
+		req          dto.AdjustmentRequest
+		wantCurrency string
+		wantErr      bool
+	}{
+		{
+			name:         "order currency by default",
+			req:          dto.AdjustmentRequest{Kind: "surcharge", Method: "fixed", Value: value},
+			wantCurrency: "USD",
+		},
+		{
+			name:         "lower case currency",
+			req:          dto.AdjustmentRequest{Kind: "surcharge", Method: "fixed", Value: value, Currency: "eur"},
+			wantCurrency: "EUR",
+		},
+		{
+			name:    "invalid currency",
+			req:     dto.AdjustmentRequest{Kind: "surcharge", Method: "fixed", Value: value, Currency: "E1R"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown item",
+			req:     dto.AdjustmentRequest{ItemID: "missing", Kind: "discount", Method: "fixed", Value: value},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.Order{
+				Currency: "USD",
+				Items:    []domain.Item{{ID: "item"}},
+				Adjustments: []domain.Adjustment{
+					{ID: "freight", Kind: domain.AdjustmentFreight, Source: domain.AdjustmentSourceFreight},
+					{ID: "old", Kind: domain.AdjustmentDiscount, Source: domain.AdjustmentSourceManual},
+				},
+			}
+			err := replaceManualAdjustments(order, []dto.AdjustmentRequest{tt.req})
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrValidation) {
+					t.Fatalf("error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("replaceManualAdjustments: %v", err)
+			}
+			if len(order.Adjustments) != 2 || order.Adjustments[0].ID != "freight" {
+				t.Fatalf("adjustments = %+v, want freight and the new manual adjustment", order.Adjustments)
+			}
+			if got := order.Adjustments[1].Currency; got != tt.wantCurrency {
+				t.Errorf("currency = %q, want %q", got, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+type fakeAdjustmentRepository struct {
+	domain.AdjustmentRepository
+	rules   []domain.AdjustmentRule
+	freight []domain.FreightRate
+}
+
+func (r *fakeAdjustmentRepository) ListAdjustmentRules(context.Context, bool) ([]domain.AdjustmentRule, error) {
+	return r.rules, nil
+}
+
+func (r *fakeAdjustmentRepository) ListFreightRates(context.Context) ([]domain.FreightRate, error) {
+	return r.freight, nil
+}
+
+func TestApplyRules(t *testing.T) {
+	perBox, _ := domain.ParseMoney("4.00")
+	five, _ := domain.ParseRate("5")
+	two, _ := domain.ParseRate("2")
+
+	service := NewAdjustmentService(&fakeAdjustmentRepository{
+		freight: []domain.FreightRate{
+			{TruckName: "North", PerBox: perBox, Currency: "EUR"},
+			{TruckName: "Free", Currency: "EUR"},
+		},
+		rules: []domain.AdjustmentRule{
+			{
+				ID: "big-line", Kind: domain.AdjustmentDiscount, Scope: domain.AdjustmentScopeLine,
+				Method: domain.AdjustmentPercent, Percent: two, MinStems: 500, Active: true,
+			},
+			{
+				ID: "big-order", Kind: domain.AdjustmentDiscount, Scope: domain.AdjustmentScopeOrder,
+				Method: domain.AdjustmentPercent, Percent: five, MinStems: 700, Active: true,
+			},
+		},
+	})
+
+	order := &domain.Order{Items: []domain.Item{
+		{ID: "rose", TruckName: "North", BoxCount: 2.5, TotalStems: 500},
+		{ID: "tulip", TruckName: "Free", BoxCount: 1, TotalStems: 200},
+		{ID: "lily", BoxCount: 1, TotalStems: 50},
+	}}
+	if err := service.ApplyRules(context.Background(), order); err != nil {
+		t.Fatalf("ApplyRules: %v", err)
+	}
+
+	got := make([]string, len(order.Adjustments))
+	for i, adj := range order.Adjustments {
+		got[i] = fmt.Sprintf("%s/%s/%s", adj.ItemID, adj.Kind, adj.Source)
+		if adj.ID == "" {
+			t.Errorf("adjustment %d has no ID", i)
+		}
+	}
+	want := []string{"rose/freight/freight", "rose/discount/rule", "/discount/rule"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("adjustments = %v, want %v", got, want)
+	}
+
+	// 2.5 коробки по 4.00 EUR
+	freight := order.Adjustments[0]
+	if freight.Value.String() != "10.00" || freight.Currency != "EUR" || freight.Method != domain.AdjustmentFixed {
+		t.Errorf("freight = %s %s (%s), want fixed 10.00 EUR", freight.Value, freight.Currency, freight.Method)
+	}
+}
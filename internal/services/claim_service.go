@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+// claimPhotoExtensions допустимые типы фотографий и расширения файлов
+var claimPhotoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+type ClaimService struct {
+	claims        domain.ClaimRepository
+	orders        domain.OrderRepository
+	farmOrders    domain.FarmOrderRepository
+	photoDir      string
+	maxPhotoBytes int64
+}
+
+func NewClaimService(
+	claims domain.ClaimRepository,
+	orders domain.OrderRepository,
+	farmOrders domain.FarmOrderRepository,
+	photoDir string,
+	maxPhotoBytes int64,
+) *ClaimService {
+	return &ClaimService{
+		claims:        claims,
+		orders:        orders,
+		farmOrders:    farmOrders,
+		photoDir:      photoDir,
+		maxPhotoBytes: maxPhotoBytes,
+	}
+}
+
+// CreateClaim регистрирует рекламацию по позиции доставленного заказа
+func (s *ClaimService) CreateClaim(ctx context.Context, req dto.CreateClaimRequest) (*domain.Claim, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ClaimService.CreateClaim", trace.WithAttributes(
+		attribute.String("order.id", req.OrderID),
+		attribute.String("claim.reason", req.Reason),
+	))
+	defer span.End()
+
+	order, err := s.orders.GetByID(ctx, req.OrderID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	farmOrders, err := s.farmOrders.ListOrderFarmOrders(ctx, order.ID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	claim, err := order.NewClaim(req.ItemID, domain.ClaimReason(req.Reason), req.ClaimedStems, farmOrders)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	claim.ID = uuid.New().String()
+	claim.Description = req.Description
+	claim.CreatedAt = now
+	claim.UpdatedAt = now
+	if err := s.claims.CreateClaim(ctx, claim); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"claim_id":      claim.ID,
+		"order_id":      claim.OrderID,
+		"farm_name":     claim.FarmName,
+		"reason":        claim.Reason,
+		"claimed_stems": claim.ClaimedStems,
+	}).Info("Claim created")
+	return claim, nil
+}
+
+func (s *ClaimService) GetClaim(ctx context.Context, id string) (*domain.Claim, error) {
+	return s.claims.GetClaim(ctx, id)
+}
+
+func (s *ClaimService) ListClaims(ctx context.Context, filter domain.ClaimFilter) ([]domain.Claim, error) {
+	return s.claims.ListClaims(ctx, filter)
+}
+
+// UpdateStatus переводит рекламацию в новый статус; при одобрении
+// компенсация считается по цене позиции заказа
+func (s *ClaimService) UpdateStatus(
+	ctx context.Context,
+	id string,
+	req dto.UpdateClaimStatusRequest,
+) (*domain.Claim, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ClaimService.UpdateStatus", trace.WithAttributes(
+		attribute.String("claim.id", id),
+		attribute.String("claim.status", req.Status),
+	))
+	defer span.End()
+
+	claim, err := s.claims.GetClaim(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	order, err := s.orders.GetByID(ctx, claim.OrderID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	previous := claim.Status
+	if err := claim.Transition(domain.ClaimStatus(req.Status), req.ApprovedStems, order); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if req.Resolution != "" {
+		claim.Resolution = req.Resolution
+	}
+	claim.UpdatedAt = time.Now()
+	if err := s.claims.UpdateClaim(ctx, claim, previous); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"claim_id":       claim.ID,
+		"from":           previous,
+		"to":             claim.Status,
+		"approved_stems": claim.ApprovedStems,
+		"credit":         claim.Credit.String(),
+	}).Info("Claim status updated")
+	return claim, nil
+}
+
+// AddPhoto сохраняет фотографию рекламации на диск и регистрирует ее.
+// Принимаются JPEG, PNG и WebP не больше maxPhotoBytes.
+func (s *ClaimService) AddPhoto(
+	ctx context.Context,
+	claimID, fileName string,
+	content io.Reader,
+) (*domain.ClaimPhoto, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ClaimService.AddPhoto", trace.WithAttributes(
+		attribute.String("claim.id", claimID),
+	))
+	defer span.End()
+
+	claim, err := s.claims.GetClaim(ctx, claimID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if claim.Status == domain.ClaimStatusClosed {
+		err := fmt.Errorf("%w: claim %s is closed", domain.ErrConflict, claimID)
+		recordError(span, err)
+		return nil, err
+	}
+
+	reader := bufio.NewReader(content)
+	head, _ := reader.Peek(512)
+	contentType := http.DetectContentType(head)
+	ext, ok := claimPhotoExtensions[contentType]
+	if !ok {
+		err := fmt.Errorf("%w: unsupported photo type %s", domain.ErrValidation, contentType)
+		recordError(span, err)
+		return nil, err
+	}
+
+	photo := &domain.ClaimPhoto{
+		ID:          uuid.New().String(),
+		ClaimID:     claim.ID,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+	photo.StoragePath = filepath.Join(claim.ID, photo.ID+ext)
+
+	if photo.SizeBytes, err = s.writePhoto(photo.StoragePath, reader); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if err := s.claims.AddClaimPhoto(ctx, photo); err != nil {
+		_ = os.Remove(filepath.Join(s.photoDir, photo.StoragePath))
+		recordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"claim_id": claimID,
+		"photo_id": photo.ID,
+		"bytes":    photo.SizeBytes,
+	}).Info("Claim photo stored")
+	return photo, nil
+}
+
+// writePhoto записывает файл в каталог фотографий; файл больше лимита удаляется
+func (s *ClaimService) writePhoto(storagePath string, content io.Reader) (int64, error) {
+	path := filepath.Join(s.photoDir, storagePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, fmt.Errorf("failed to create photo directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create photo file: %w", err)
+	}
+
+	written, err := io.Copy(file, io.LimitReader(content, s.maxPhotoBytes+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > s.maxPhotoBytes {
+		err = fmt.Errorf("%w: photo exceeds %d bytes", domain.ErrValidation, s.maxPhotoBytes)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return 0, err
+	}
+	return written, nil
+}
+
+// OpenPhoto открывает файл фотографии рекламации; вызывающий закрывает файл
+func (s *ClaimService) OpenPhoto(ctx context.Context, claimID, photoID string) (*domain.ClaimPhoto, *os.File, error) {
+	photo, err := s.claims.GetClaimPhoto(ctx, claimID, photoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(filepath.Join(s.photoDir, photo.StoragePath))
+	if os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("photo file %s: %w", photo.StoragePath, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return photo, file, nil
+}
+
+// Report считает долю рекламаций по фермам и сортам для заказов, созданных
+// в [from, to). Рекламации отбираются по дате заказа, а не по своей дате,
+// чтобы заявленные стебли относились к тем же доставкам.
+func (s *ClaimService) Report(ctx context.Context, from, to *time.Time) ([]domain.ClaimRate, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ClaimService.Report")
+	defer span.End()
+
+	delivered, err := s.claims.ListDeliveredStems(ctx, from, to)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	claims, err := s.claims.ListClaims(ctx, domain.ClaimFilter{OrderFrom: from, OrderTo: to})
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	return domain.ClaimRates(delivered, claims), nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+)
+
+type CustomerService struct {
+	repo domain.CustomerRepository
+}
+
+func NewCustomerService(repo domain.CustomerRepository) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	return s.repo.GetCustomer(ctx, id)
+}
+
+// SaveCustomer создает клиента или обновляет его данные
+func (s *CustomerService) SaveCustomer(ctx context.Context, id string, req dto.SaveCustomerRequest) (*domain.Customer, error) {
+	customer := &domain.Customer{
+		ID:      id,
+		Name:    req.Name,
+		Email:   req.Email,
+		Phone:   req.Phone,
+		Company: req.Company,
+		Address: toDomainAddress(req.Address),
+		Tier:    req.Tier,
+		VATID:   strings.ToUpper(strings.ReplaceAll(req.VATID, " ", "")),
+	}
+
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	customer.Currency = currency
+
+	if err := s.repo.SaveCustomer(ctx, customer); err != nil {
+		return nil, fmt.Errorf("failed to save customer: %w", err)
+	}
+	return customer, nil
+}
+
+// toDomainAddress переносит адрес из запроса, приводя код страны к верхнему регистру
+func toDomainAddress(address dto.AddressDTO) domain.Address {
+	return domain.Address{
+		Street:     address.Street,
+		City:       address.City,
+		State:      address.State,
+		PostalCode: address.PostalCode,
+		Country:    strings.ToUpper(address.Country),
+	}
+}
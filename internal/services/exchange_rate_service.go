@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+type ExchangeRateService struct {
+	repo domain.ExchangeRateRepository
+}
+
+func NewExchangeRateService(repo domain.ExchangeRateRepository) *ExchangeRateService {
+	return &ExchangeRateService{repo: repo}
+}
+
+func (s *ExchangeRateService) CreateExchangeRate(
+	ctx context.Context, req dto.CreateExchangeRateRequest,
+) (*domain.ExchangeRate, error) {
+	rate := &domain.ExchangeRate{
+		FromCurrency: strings.ToUpper(req.FromCurrency),
+		ToCurrency:   strings.ToUpper(req.ToCurrency),
+		Rate:         req.Rate,
+		EffectiveAt:  req.EffectiveAt,
+	}
+	if !domain.IsValidCurrency(rate.FromCurrency) || !domain.IsValidCurrency(rate.ToCurrency) {
+		return nil, fmt.Errorf("%w: invalid currency pair %s->%s", domain.ErrValidation, req.FromCurrency, req.ToCurrency)
+	}
+	if !rate.Rate.IsPositive() {
+		return nil, fmt.Errorf("%w: rate must be positive", domain.ErrValidation)
+	}
+	if rate.EffectiveAt.IsZero() {
+		rate.EffectiveAt = time.Now()
+	}
+
+	if err := s.repo.CreateExchangeRate(ctx, rate); err != nil {
+		return nil, fmt.Errorf("failed to create exchange rate: %w", err)
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"from": rate.FromCurrency,
+		"to":   rate.ToCurrency,
+		"rate": rate.Rate.String(),
+	}).Info("Exchange rate created")
+	return rate, nil
+}
+
+func (s *ExchangeRateService) ListExchangeRates(ctx context.Context, from, to string) ([]domain.ExchangeRate, error) {
+	return s.repo.ListExchangeRates(ctx, strings.ToUpper(from), strings.ToUpper(to))
+}
+
+// SnapshotRates фиксирует в заказе курсы всех валют позиций и корректировок
+// к валюте заказа на момент его создания. Если прямого курса нет, используется обратный.
+func (s *ExchangeRateService) SnapshotRates(ctx context.Context, order *domain.Order) error {
+	order.ExchangeRates = nil
+	return s.SnapshotMissingRates(ctx, order)
+}
+
+// SnapshotMissingRates добавляет в заказ курсы валют, для которых курса еще нет,
+// например у корректировки, добавленной после создания заказа. Курс берется
+// на момент создания заказа; сохраненные курсы не меняются.
+func (s *ExchangeRateService) SnapshotMissingRates(ctx context.Context, order *domain.Order) error {
+	currencies := make([]string, 0, len(order.Items)+len(order.Adjustments))
+	for _, item := range order.Items {
+		currencies = append(currencies, item.Currency)
+	}
+	for _, adj := range order.Adjustments {
+		currencies = append(currencies, adj.Currency)
+	}
+
+	seen := make(map[string]bool)
+	for _, rate := range order.ExchangeRates {
+		if rate.ToCurrency == order.Currency {
+			seen[rate.FromCurrency] = true
+		}
+	}
+	for _, currency := range currencies {
+		if currency == "" || currency == order.Currency || seen[currency] {
+			continue
+		}
+		seen[currency] = true
+
+		rate, err := s.findRate(ctx, currency, order.Currency, order.CreatedAt)
+		if err != nil {
+			return err
+		}
+		order.ExchangeRates = append(order.ExchangeRates, *rate)
+	}
+	return nil
+}
+
+func (s *ExchangeRateService) findRate(ctx context.Context, from, to string, at time.Time) (*domain.ExchangeRate, error) {
+	rate, err := s.repo.FindExchangeRate(ctx, from, to, at)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to find exchange rate: %w", err)
+	}
+
+	inverse, err := s.repo.FindExchangeRate(ctx, to, from, at)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: no exchange rate %s->%s", domain.ErrValidation, from, to)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find exchange rate: %w", err)
+	}
+	rate = new(domain.ExchangeRate)
+	*rate = inverse.Inverse()
+	return rate, nil
+}
+
+// normalizeCurrency приводит код валюты к верхнему регистру; пустой код
+// заменяется валютой по умолчанию
+func normalizeCurrency(code string) (string, error) {
+	currency := strings.ToUpper(code)
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	if !domain.IsValidCurrency(currency) {
+		return "", fmt.Errorf("%w: invalid currency %q", domain.ErrValidation, code)
+	}
+	return currency, nil
+}
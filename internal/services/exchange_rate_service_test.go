@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+type fakeExchangeRateRepository struct {
+	domain.ExchangeRateRepository
+	rates []domain.ExchangeRate
+}
+
+// FindExchangeRate возвращает последний курс, действовавший на момент at
+func (r *fakeExchangeRateRepository) FindExchangeRate(
+	_ context.Context, from, to string, at time.Time,
+) (*domain.ExchangeRate, error) {
+	var found *domain.ExchangeRate
+	for i := range r.rates {
+		rate := &r.rates[i]
+		if rate.FromCurrency != from || rate.ToCurrency != to || rate.EffectiveAt.After(at) {
+			continue
+		}
+		if found == nil || rate.EffectiveAt.After(found.EffectiveAt) {
+			found = rate
+		}
+	}
+	if found == nil {
+		return nil, domain.ErrNotFound
+	}
+	return found, nil
+}
+
+func exchangeRate(t *testing.T, from, to, value string, at time.Time) domain.ExchangeRate {
+	t.Helper()
+	rate, err := domain.ParseRate(value)
+	if err != nil {
+		t.Fatalf("ParseRate(%q): %v", value, err)
+	}
+	return domain.ExchangeRate{FromCurrency: from, ToCurrency: to, Rate: rate, EffectiveAt: at}
+}
+
+func TestSnapshotMissingRates(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeExchangeRateRepository{rates: []domain.ExchangeRate{
+		exchangeRate(t, "EUR", "USD", "1.10", createdAt.Add(-time.Hour)),
+		// курс, появившийся после создания заказа, не используется
+		exchangeRate(t, "EUR", "USD", "1.20", createdAt.Add(time.Hour)),
+		exchangeRate(t, "USD", "COP", "4000", createdAt.Add(-time.Hour)),
+	}}
+	service := NewExchangeRateService(repo)
+
+	order := &domain.Order{
+		Currency:  "USD",
+		CreatedAt: createdAt,
+		Items:     []domain.Item{{ID: "1", Currency: "USD"}},
+		// сохраненный курс EUR не должен меняться
+		ExchangeRates: []domain.ExchangeRate{exchangeRate(t, "EUR", "USD", "1.05", createdAt.Add(-24*time.Hour))},
+		Adjustments: []domain.Adjustment{
+			{ID: "eur", Method: domain.AdjustmentFixed, Currency: "EUR"},
+			{ID: "cop", Method: domain.AdjustmentFixed, Currency: "COP"},
+		},
+	}
+	if err := service.SnapshotMissingRates(context.Background(), order); err != nil {
+		t.Fatalf("SnapshotMissingRates: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, rate := range order.ExchangeRates {
+		got[rate.FromCurrency+"->"+rate.ToCurrency] = rate.Rate.String()
+	}
+	want := map[string]string{"EUR->USD": "1.05", "COP->USD": "0.00025"}
+	if len(got) != len(want) {
+		t.Fatalf("rates = %v, want %v", got, want)
+	}
+	for pair, rate := range want {
+		if got[pair] != rate {
+			t.Errorf("%s = %s, want %s", pair, got[pair], rate)
+		}
+	}
+
+	order.Adjustments = append(order.Adjustments, domain.Adjustment{ID: "gbp", Currency: "GBP"})
+	if err := service.SnapshotMissingRates(context.Background(), order); err == nil {
+		t.Error("SnapshotMissingRates without GBP rate: want error")
+	}
+}
+
+func TestSnapshotRates(t *testing.T) {
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	repo := &fakeExchangeRateRepository{rates: []domain.ExchangeRate{
+		exchangeRate(t, "USD", "EUR", "0.90", march),
+		exchangeRate(t, "USD", "EUR", "0.95", april),
+		// для COP есть только курс EUR->COP
+		exchangeRate(t, "EUR", "COP", "4000", march),
+	}}
+	service := NewExchangeRateService(repo)
+
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		items     []domain.Item
+		stale     []domain.ExchangeRate
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "rate effective at order date",
+			createdAt: march.AddDate(0, 0, 10),
+			items:     []domain.Item{{ID: "1", Currency: "USD"}},
+			want:      map[string]string{"USD->EUR": "0.9"},
+		},
+		{
+			name:      "later rate",
+			createdAt: april.AddDate(0, 0, 1),
+			items:     []domain.Item{{ID: "1", Currency: "USD"}},
+			want:      map[string]string{"USD->EUR": "0.95"},
+		},
+		{
+			name:      "inverse rate",
+			createdAt: april,
+			items:     []domain.Item{{ID: "1", Currency: "COP"}},
+			want:      map[string]string{"COP->EUR": "0.00025"},
+		},
+		{
+			name:      "order currency needs no rate",
+			createdAt: april,
+			items:     []domain.Item{{ID: "1", Currency: "EUR"}, {ID: "2"}},
+			want:      map[string]string{},
+		},
+		{
+			name:      "previous snapshot is replaced",
+			createdAt: april,
+			items:     []domain.Item{{ID: "1", Currency: "USD"}},
+			stale:     []domain.ExchangeRate{exchangeRate(t, "USD", "EUR", "0.80", march)},
+			want:      map[string]string{"USD->EUR": "0.95"},
+		},
+		{
+			name:      "no rate before the first one",
+			createdAt: march.AddDate(0, 0, -1),
+			items:     []domain.Item{{ID: "1", Currency: "USD"}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.Order{Currency: "EUR", CreatedAt: tt.createdAt, Items: tt.items, ExchangeRates: tt.stale}
+			err := service.SnapshotRates(context.Background(), order)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrValidation) {
+					t.Fatalf("SnapshotRates error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SnapshotRates: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, rate := range order.ExchangeRates {
+				got[rate.FromCurrency+"->"+rate.ToCurrency] = rate.Rate.String()
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("rates = %v, want %v", got, tt.want)
+			}
+			for pair, rate := range tt.want {
+				if got[pair] != rate {
+					t.Errorf("%s = %s, want %s", pair, got[pair], rate)
+				}
+			}
+		})
+	}
+}
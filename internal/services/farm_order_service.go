@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+type FarmOrderService struct {
+	farmOrders domain.FarmOrderRepository
+	orders     domain.OrderRepository
+}
+
+func NewFarmOrderService(farmOrders domain.FarmOrderRepository, orders domain.OrderRepository) *FarmOrderService {
+	return &FarmOrderService{farmOrders: farmOrders, orders: orders}
+}
+
+// CreateFarmOrders разбивает заказ по фермам. Повторный вызов создает
+// заказы только для ферм, которым заказ еще не отправлялся.
+func (s *FarmOrderService) CreateFarmOrders(ctx context.Context, orderID string) ([]domain.FarmOrder, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "FarmOrderService.CreateFarmOrders", trace.WithAttributes(
+		attribute.String("order.id", orderID),
+	))
+	defer span.End()
+
+	order, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if order.Status != domain.OrderStatusProcessing && order.Status != domain.OrderStatusFarmOrder {
+		err := fmt.Errorf("%w: order %s is %s, farm orders are created for processing orders",
+			domain.ErrValidation, order.ID, order.Status)
+		recordError(span, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	farmOrders := order.SplitByFarm()
+	for i := range farmOrders {
+		farmOrders[i].ID = uuid.New().String()
+		farmOrders[i].CreatedAt = now
+		farmOrders[i].UpdatedAt = now
+	}
+	if err := s.farmOrders.CreateFarmOrders(ctx, farmOrders); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"order_id": orderID,
+		"farms":    len(farmOrders),
+	}).Info("Farm orders created")
+	return s.farmOrders.ListOrderFarmOrders(ctx, orderID)
+}
+
+func (s *FarmOrderService) ListOrderFarmOrders(ctx context.Context, orderID string) ([]domain.FarmOrder, error) {
+	return s.farmOrders.ListOrderFarmOrders(ctx, orderID)
+}
+
+// GetReceiving возвращает заказ фермы, его сканирования и сверку приемки
+func (s *FarmOrderService) GetReceiving(
+	ctx context.Context,
+	id string,
+) (*domain.FarmOrder, []domain.BoxScan, *domain.ReceivingReport, error) {
+	farmOrder, err := s.farmOrders.GetFarmOrder(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	scans, err := s.farmOrders.ListBoxScans(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	report := farmOrder.Reconcile(scans)
+	return farmOrder, scans, &report, nil
+}
+
+// RecordScan принимает отсканированную коробку. Повторы и чужие коды
+// сохраняются и учитываются как излишки.
+func (s *FarmOrderService) RecordScan(
+	ctx context.Context,
+	farmOrderID, code string,
+) (*domain.BoxScan, *domain.ReceivingReport, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "FarmOrderService.RecordScan", trace.WithAttributes(
+		attribute.String("farm_order.id", farmOrderID),
+	))
+	defer span.End()
+
+	scan, report, err := s.farmOrders.RecordBoxScan(ctx, farmOrderID, code, time.Now())
+	if err != nil {
+		recordError(span, err)
+		return nil, nil, err
+	}
+	span.SetAttributes(attribute.String("scan.status", string(scan.Status)))
+
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"farm_order_id": farmOrderID,
+		"code":          scan.Code,
+		"status":        scan.Status,
+		"received":      report.ReceivedBoxes,
+		"expected":      report.ExpectedBoxes,
+	})
+	if scan.Status != domain.BoxScanReceived {
+		log.Warn("Box scan flagged")
+	} else {
+		log.Debug("Box scanned")
+	}
+	if report.Complete && report.Status == domain.FarmOrderStatusDelivered {
+		log.Info("Farm order fully received")
+	}
+	return scan, report, nil
+}
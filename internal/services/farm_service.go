@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/export"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/metrics"
+)
+
+// Значения по умолчанию для новых ферм
+const (
+	defaultFarmTimeZone   = "UTC"
+	defaultFarmCutoffTime = "00:00"
+)
+
+type FarmService struct {
+	farms   domain.FarmRepository
+	metrics *metrics.Metrics
+}
+
+func NewFarmService(farms domain.FarmRepository, metrics *metrics.Metrics) *FarmService {
+	return &FarmService{farms: farms, metrics: metrics}
+}
+
+func (s *FarmService) CreateFarm(ctx context.Context, req dto.SaveFarmRequest) (*domain.Farm, error) {
+	farm := toDomainFarm(req)
+	if err := farm.Validate(); err != nil {
+		return nil, err
+	}
+	farm.ID = uuid.New().String()
+	farm.CreatedAt = time.Now()
+	farm.UpdatedAt = farm.CreatedAt
+	if err := s.farms.CreateFarm(ctx, farm); err != nil {
+		return nil, fmt.Errorf("failed to create farm: %w", err)
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"farm_id": farm.ID,
+		"farm":    farm.Name,
+	}).Info("Farm created")
+	return farm, nil
+}
+
+// UpdateFarm изменяет ферму. Позиции заказов сохраняют название фермы
+// на момент заказа и связаны с ней через farm_id.
+func (s *FarmService) UpdateFarm(ctx context.Context, id string, req dto.SaveFarmRequest) (*domain.Farm, error) {
+	farm := toDomainFarm(req)
+	if err := farm.Validate(); err != nil {
+		return nil, err
+	}
+	farm.ID = id
+	if err := s.farms.UpdateFarm(ctx, farm); err != nil {
+		return nil, fmt.Errorf("failed to update farm: %w", err)
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"farm_id": farm.ID,
+		"farm":    farm.Name,
+	}).Info("Farm updated")
+	return farm, nil
+}
+
+func (s *FarmService) GetFarm(ctx context.Context, id string) (*domain.Farm, error) {
+	return s.farms.GetFarm(ctx, id)
+}
+
+func (s *FarmService) ListFarms(ctx context.Context) ([]domain.Farm, error) {
+	return s.farms.ListFarms(ctx)
+}
+
+// Export возвращает заказы фермы, сгруппированные по датам отправки в [from, to]
+func (s *FarmService) Export(ctx context.Context, farmID string, from, to *time.Time) (*domain.FarmExport, error) {
+	farm, err := s.farms.GetFarm(ctx, farmID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.farms.ListFarmItems(ctx, farmID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	farmExport := domain.NewFarmExport(farm, items)
+	return &farmExport, nil
+}
+
+// RenderExport формирует выгрузку заказов фермы в формате Excel
+func (s *FarmService) RenderExport(farmExport *domain.FarmExport) ([]byte, error) {
+	data, err := export.FarmExportXLSX(farmExport)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.ExcelExportGenerated("farm_orders")
+	return data, nil
+}
+
+// OrderProblem возвращает причину, по которой позицию фермы нельзя заказать
+// на дату отправки в момент now, или пустую строку; правила те же, что в AssignFarms
+func (s *FarmService) OrderProblem(
+	ctx context.Context, farmName string, shipDate *time.Time, now time.Time,
+) (string, error) {
+	farm, err := s.farms.GetFarmByName(ctx, farmName)
+	if errors.Is(err, domain.ErrNotFound) {
+		return fmt.Sprintf("unknown farm %q", farmName), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get farm: %w", err)
+	}
+	return farm.OrderProblem(shipDate, now)
+}
+
+// AssignFarms связывает позиции заказа с фермами реестра. Фермы должны быть
+// зарегистрированы и активны; если у заказа задана дата отправки, позиции
+// ферм, у которых прошло время отсечки, отклоняются.
+func (s *FarmService) AssignFarms(ctx context.Context, order *domain.Order, now time.Time) error {
+	farms := make(map[string]*domain.Farm)
+	var problems []string
+	for i := range order.Items {
+		item := &order.Items[i]
+		farm, ok := farms[item.FarmName]
+		if !ok {
+			var err error
+			farm, err = s.farms.GetFarmByName(ctx, item.FarmName)
+			if errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("%w: unknown farm %q", domain.ErrValidation, item.FarmName)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get farm: %w", err)
+			}
+			farms[item.FarmName] = farm
+
+			problem, err := farm.OrderProblem(order.ShipDate, now)
+			if err != nil {
+				return err
+			}
+			if problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+		item.FarmID = farm.ID
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", domain.ErrValidation, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func toDomainFarm(req dto.SaveFarmRequest) *domain.Farm {
+	farm := &domain.Farm{
+		Name:         strings.TrimSpace(req.Name),
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Country:      strings.ToUpper(req.Country),
+		TimeZone:     req.TimeZone,
+		CutoffTime:   req.CutoffTime,
+		LeadTimeDays: req.LeadTimeDays,
+		Notes:        req.Notes,
+		Active:       req.Active == nil || *req.Active,
+	}
+	if farm.TimeZone == "" {
+		farm.TimeZone = defaultFarmTimeZone
+	}
+	if farm.CutoffTime == "" {
+		farm.CutoffTime = defaultFarmCutoffTime
+	}
+	return farm
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+func TestAssignFarms(t *testing.T) {
+	// отсечка Alpha 14:00 в Боготе за 2 дня до отправки 10 марта: 8 марта 19:00 UTC
+	deadline := time.Date(2024, 3, 8, 19, 0, 0, 0, time.UTC)
+	shipDate := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	service := NewFarmService(&fakeFarmRepository{farms: map[string]*domain.Farm{
+		"Alpha": {ID: "alpha-id", Name: "Alpha", TimeZone: "America/Bogota", CutoffTime: "14:00", LeadTimeDays: 2, Active: true},
+		"Beta":  {ID: "beta-id", Name: "Beta", TimeZone: "Africa/Nairobi", CutoffTime: "10:00", Active: true},
+		"Old":   {ID: "old-id", Name: "Old", TimeZone: "UTC", CutoffTime: "00:00", Active: false},
+	}}, nil)
+
+	tests := []struct {
+		name         string
+		farms        []string
+		shipDate     *time.Time
+		now          time.Time
+		wantProblems []string
+	}{
+		{
+			name:     "before the deadline",
+			farms:    []string{"Alpha", "Beta", "Alpha"},
+			shipDate: &shipDate,
+			now:      deadline.Add(-time.Second),
+		},
+		{
+			name:         "at the deadline",
+			farms:        []string{"Alpha", "Beta"},
+			shipDate:     &shipDate,
+			now:          deadline,
+			wantProblems: []string{"farm Alpha cutoff"},
+		},
+		{
+			name:         "after the deadline of every farm",
+			farms:        []string{"Alpha", "Beta"},
+			shipDate:     &shipDate,
+			now:          shipDate.Add(12 * time.Hour),
+			wantProblems: []string{"farm Alpha cutoff", "farm Beta cutoff"},
+		},
+		{name: "no ship date", farms: []string{"Alpha"}, now: shipDate.Add(24 * time.Hour)},
+		{name: "inactive farm", farms: []string{"Old"}, now: deadline, wantProblems: []string{"farm Old is inactive"}},
+		{name: "unknown farm", farms: []string{"Nowhere"}, now: deadline, wantProblems: []string{`unknown farm "Nowhere"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.Order{ShipDate: tt.shipDate}
+			for _, farm := range tt.farms {
+				order.Items = append(order.Items, domain.Item{FarmName: farm})
+			}
+
+			err := service.AssignFarms(context.Background(), order, tt.now)
+			if len(tt.wantProblems) > 0 {
+				if !errors.Is(err, domain.ErrValidation) {
+					t.Fatalf("AssignFarms error = %v, want ErrValidation", err)
+				}
+				for _, problem := range tt.wantProblems {
+					if !strings.Contains(err.Error(), problem) {
+						t.Errorf("error %q does not mention %q", err, problem)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AssignFarms: %v", err)
+			}
+			for _, item := range order.Items {
+				if want := strings.ToLower(item.FarmName) + "-id"; item.FarmID != want {
+					t.Errorf("item of %s has FarmID %q, want %q", item.FarmName, item.FarmID, want)
+				}
+			}
+		})
+	}
+}
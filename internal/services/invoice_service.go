@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/export"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/metrics"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+// Форматы выгрузки счетов
+const (
+	InvoiceFormatPDF  = "pdf"
+	InvoiceFormatXLSX = "xlsx"
+)
+
+type InvoiceService struct {
+	invoices  domain.InvoiceRepository
+	orders    domain.OrderRepository
+	customers domain.CustomerRepository
+	markBoxes domain.MarkBoxRepository
+	metrics   *metrics.Metrics
+}
+
+func NewInvoiceService(
+	invoices domain.InvoiceRepository,
+	orders domain.OrderRepository,
+	customers domain.CustomerRepository,
+	markBoxes domain.MarkBoxRepository,
+	metrics *metrics.Metrics,
+) *InvoiceService {
+	return &InvoiceService{
+		invoices:  invoices,
+		orders:    orders,
+		customers: customers,
+		markBoxes: markBoxes,
+		metrics:   metrics,
+	}
+}
+
+// IssueInvoice выставляет счет по выполненному заказу. Если у заказа уже есть
+// действующий счет, возвращается domain.ErrConflict.
+func (s *InvoiceService) IssueInvoice(ctx context.Context, orderID string) (*domain.Invoice, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "InvoiceService.IssueInvoice", trace.WithAttributes(
+		attribute.String("order.id", orderID),
+	))
+	defer span.End()
+
+	order, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	var customer *domain.Customer
+	if order.CustomerID != "" {
+		customer, err = s.customers.GetCustomer(ctx, order.CustomerID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			recordError(span, err)
+			return nil, fmt.Errorf("failed to get customer: %w", err)
+		}
+	}
+
+	invoice, err := domain.NewInvoice(order, customer, time.Now())
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	invoice.ID = uuid.New().String()
+	if invoice.Consignee, err = findConsignee(ctx, s.markBoxes, order.MarkBox); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	if err := s.invoices.CreateInvoice(ctx, invoice); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("invoice.number", invoice.Number))
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"order_id": orderID,
+		"number":   invoice.Number,
+		"total":    invoice.Total.String(),
+	}).Info("Invoice issued")
+	return invoice, nil
+}
+
+// CurrentInvoice возвращает действующий (не сторнированный) счет заказа
+func (s *InvoiceService) CurrentInvoice(ctx context.Context, orderID string) (*domain.Invoice, error) {
+	invoices, err := s.invoices.ListOrderInvoices(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return currentInvoice(orderID, invoices)
+}
+
+// CreditInvoice сторнирует действующий счет заказа кредит-нотой.
+// После этого по заказу можно выставить исправленный счет.
+func (s *InvoiceService) CreditInvoice(ctx context.Context, orderID, reason string) (*domain.Invoice, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "InvoiceService.CreditInvoice", trace.WithAttributes(
+		attribute.String("order.id", orderID),
+	))
+	defer span.End()
+
+	invoice, err := s.CurrentInvoice(ctx, orderID)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	note, err := invoice.CreditNote(reason, time.Now())
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	note.ID = uuid.New().String()
+
+	if err := s.invoices.CreateInvoice(ctx, note); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"order_id": orderID,
+		"number":   note.Number,
+		"credited": invoice.Number,
+	}).Info("Credit note issued")
+	return note, nil
+}
+
+func (s *InvoiceService) ListOrderInvoices(ctx context.Context, orderID string) ([]domain.Invoice, error) {
+	return s.invoices.ListOrderInvoices(ctx, orderID)
+}
+
+func (s *InvoiceService) GetInvoice(ctx context.Context, id string) (*domain.Invoice, error) {
+	return s.invoices.GetInvoice(ctx, id)
+}
+
+// RenderInvoice формирует файл документа и возвращает его содержимое и MIME-тип
+func (s *InvoiceService) RenderInvoice(invoice *domain.Invoice, format string) ([]byte, string, error) {
+	switch format {
+	case InvoiceFormatPDF:
+		data, err := export.InvoicePDF(invoice)
+		return data, export.ContentTypePDF, err
+	case InvoiceFormatXLSX:
+		data, err := export.InvoiceXLSX(invoice)
+		if err != nil {
+			return nil, "", err
+		}
+		s.metrics.ExcelExportGenerated("invoice")
+		return data, export.ContentTypeXLSX, nil
+	default:
+		return nil, "", fmt.Errorf("%w: unsupported invoice format %q", domain.ErrValidation, format)
+	}
+}
+
+// currentInvoice выбирает из документов заказа счет, для которого нет кредит-ноты
+func currentInvoice(orderID string, invoices []domain.Invoice) (*domain.Invoice, error) {
+	credited := make(map[string]bool)
+	for _, invoice := range invoices {
+		if invoice.CreditedInvoiceID != "" {
+			credited[invoice.CreditedInvoiceID] = true
+		}
+	}
+	for i := range invoices {
+		if invoices[i].Type == domain.InvoiceTypeInvoice && !credited[invoices[i].ID] {
+			return &invoices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("invoice for order %s: %w", orderID, domain.ErrNotFound)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/export"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+// Форматы этикеток коробок
+const (
+	LabelFormatPDF = "pdf"
+	LabelFormatZPL = "zpl"
+)
+
+type LabelService struct {
+	orders domain.OrderRepository
+}
+
+func NewLabelService(orders domain.OrderRepository) *LabelService {
+	return &LabelService{orders: orders}
+}
+
+// RenderOrderLabels формирует этикетки всех коробок заказа и возвращает
+// содержимое файла и его MIME-тип
+func (s *LabelService) RenderOrderLabels(ctx context.Context, orderID, format string) ([]byte, string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "LabelService.RenderOrderLabels", trace.WithAttributes(
+		attribute.String("order.id", orderID),
+		attribute.String("labels.format", format),
+	))
+	defer span.End()
+
+	if format != LabelFormatPDF && format != LabelFormatZPL {
+		err := fmt.Errorf("%w: unsupported label format %q", domain.ErrValidation, format)
+		recordError(span, err)
+		return nil, "", err
+	}
+
+	order, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		recordError(span, err)
+		return nil, "", err
+	}
+	labels := order.BoxLabels()
+	span.SetAttributes(attribute.Int("labels.count", len(labels)))
+
+	if format == LabelFormatZPL {
+		return export.LabelsZPL(labels), export.ContentTypeZPL, nil
+	}
+	data, err := export.LabelsPDF(labels)
+	if err != nil {
+		recordError(span, err)
+		return nil, "", err
+	}
+	return data, export.ContentTypePDF, nil
+}
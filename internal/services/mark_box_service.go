@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+// maxMarkBoxLength совпадает с ограничением mark_box в заказе
+const maxMarkBoxLength = 10
+
+type MarkBoxService struct {
+	markBoxes domain.MarkBoxRepository
+	customers domain.CustomerRepository
+}
+
+func NewMarkBoxService(markBoxes domain.MarkBoxRepository, customers domain.CustomerRepository) *MarkBoxService {
+	return &MarkBoxService{markBoxes: markBoxes, customers: customers}
+}
+
+// SaveMarkBox создает код маркировки или обновляет получателя
+func (s *MarkBoxService) SaveMarkBox(ctx context.Context, code string, req dto.SaveMarkBoxRequest) (*domain.MarkBox, error) {
+	if code == "" || len(code) > maxMarkBoxLength {
+		return nil, fmt.Errorf("%w: mark box code must be 1-%d characters", domain.ErrValidation, maxMarkBoxLength)
+	}
+	if req.CustomerID != "" {
+		if _, err := s.customers.GetCustomer(ctx, req.CustomerID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("%w: customer %s not found", domain.ErrValidation, req.CustomerID)
+			}
+			return nil, fmt.Errorf("failed to get customer: %w", err)
+		}
+	}
+
+	markBox := &domain.MarkBox{
+		Code:       code,
+		CustomerID: req.CustomerID,
+		Consignee: domain.Consignee{
+			Name:               req.ConsigneeName,
+			Address:            toDomainAddress(req.Address),
+			Phone:              req.Phone,
+			DestinationAirport: strings.ToUpper(req.DestinationAirport),
+		},
+		Notes:  req.Notes,
+		Active: req.Active == nil || *req.Active,
+	}
+	if err := s.markBoxes.SaveMarkBox(ctx, markBox); err != nil {
+		return nil, fmt.Errorf("failed to save mark box: %w", err)
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"mark_box":    code,
+		"customer_id": req.CustomerID,
+	}).Info("Mark box saved")
+	return markBox, nil
+}
+
+func (s *MarkBoxService) GetMarkBox(ctx context.Context, code string) (*domain.MarkBox, error) {
+	return s.markBoxes.GetMarkBox(ctx, code)
+}
+
+func (s *MarkBoxService) ListMarkBoxes(ctx context.Context, customerID string) ([]domain.MarkBox, error) {
+	return s.markBoxes.ListMarkBoxes(ctx, customerID)
+}
+
+func (s *MarkBoxService) DeleteMarkBox(ctx context.Context, code string) error {
+	return s.markBoxes.DeleteMarkBox(ctx, code)
+}
+
+// ValidateForCustomer проверяет, что код зарегистрирован, активен и разрешен клиенту
+func (s *MarkBoxService) ValidateForCustomer(ctx context.Context, code, customerID string) error {
+	markBox, err := s.markBoxes.GetMarkBox(ctx, code)
+	if errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("%w: unknown mark box %q", domain.ErrValidation, code)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get mark box: %w", err)
+	}
+	if !markBox.AllowedFor(customerID) {
+		return fmt.Errorf("%w: mark box %q is not allowed for customer %s", domain.ErrValidation, code, customerID)
+	}
+	return nil
+}
+
+// findConsignee возвращает получателя по коду маркировки или nil для незарегистрированного кода
+func findConsignee(ctx context.Context, markBoxes domain.MarkBoxRepository, code string) (*domain.Consignee, error) {
+	markBox, err := markBoxes.GetMarkBox(ctx, code)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mark box: %w", err)
+	}
+	return &markBox.Consignee, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+type fakeMarkBoxRepository struct {
+	domain.MarkBoxRepository
+	markBoxes map[string]*domain.MarkBox
+	err       error
+}
+
+func (r *fakeMarkBoxRepository) GetMarkBox(_ context.Context, code string) (*domain.MarkBox, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	markBox, ok := r.markBoxes[code]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return markBox, nil
+}
+
+func TestValidateForCustomer(t *testing.T) {
+	service := NewMarkBoxService(&fakeMarkBoxRepository{markBoxes: map[string]*domain.MarkBox{
+		"ACME1":  {Code: "ACME1", CustomerID: "acme", Active: true},
+		"SHARED": {Code: "SHARED", Active: true},
+		"OLD":    {Code: "OLD", CustomerID: "acme", Active: false},
+	}}, &fakeCustomerRepository{})
+
+	tests := []struct {
+		name       string
+		code       string
+		customerID string
+		wantErr    bool
+	}{
+		{name: "allowed", code: "ACME1", customerID: "acme"},
+		{name: "shared", code: "SHARED", customerID: "globex"},
+		{name: "other customer", code: "ACME1", customerID: "globex", wantErr: true},
+		{name: "inactive", code: "OLD", customerID: "acme", wantErr: true},
+		{name: "unknown", code: "NOPE", customerID: "acme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateForCustomer(context.Background(), tt.code, tt.customerID)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("ValidateForCustomer: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("ValidateForCustomer error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestValidateForCustomerRepositoryError(t *testing.T) {
+	failure := errors.New("connection refused")
+	service := NewMarkBoxService(&fakeMarkBoxRepository{err: failure}, &fakeCustomerRepository{})
+
+	err := service.ValidateForCustomer(context.Background(), "ACME1", "acme")
+	if !errors.Is(err, failure) || errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("ValidateForCustomer error = %v, want the repository error", err)
+	}
+}
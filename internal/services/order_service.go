@@ -2,35 +2,105 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
 	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/metrics"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
 )
 
 type OrderService struct {
-	repo domain.OrderRepository
+	repo        domain.OrderRepository
+	prices      *PriceService
+	rates       *ExchangeRateService
+	adjustments *AdjustmentService
+	taxes       *TaxService
+	markBoxes   *MarkBoxService
+	farms       *FarmService
+	invoices    domain.InvoiceRepository
+	metrics     *metrics.Metrics
 }
 
-func NewOrderService(repo domain.OrderRepository) *OrderService {
-	return &OrderService{repo: repo}
+func NewOrderService(
+	repo domain.OrderRepository,
+	prices *PriceService,
+	rates *ExchangeRateService,
+	adjustments *AdjustmentService,
+	taxes *TaxService,
+	markBoxes *MarkBoxService,
+	farms *FarmService,
+	invoices domain.InvoiceRepository,
+	metrics *metrics.Metrics,
+) *OrderService {
+	return &OrderService{
+		repo:        repo,
+		prices:      prices,
+		rates:       rates,
+		adjustments: adjustments,
+		taxes:       taxes,
+		markBoxes:   markBoxes,
+		farms:       farms,
+		invoices:    invoices,
+		metrics:     metrics,
+	}
 }
 
 func (s *OrderService) GetAvailableFlowers(ctx context.Context) ([]domain.Item, error) {
-	return s.repo.GetAvailableFlowers(ctx)
+	ctx, span := tracing.Tracer().Start(ctx, "OrderService.GetAvailableFlowers")
+	defer span.End()
+
+	flowers, err := s.repo.GetAvailableFlowers(ctx)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("flowers.count", len(flowers)))
+	return flowers, nil
 }
 
 func (s *OrderService) CreateOrder(ctx context.Context, req dto.CreateOrderRequest) (*domain.Order, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "OrderService.CreateOrder")
+	defer span.End()
+
 	order := &domain.Order{
 		ID:         uuid.New().String(),
 		MarkBox:    req.MarkBox,
 		CustomerID: req.CustomerID,
 		Status:     domain.OrderStatusPending,
+		Currency:   strings.ToUpper(req.Currency),
 		CreatedAt:  time.Now(),
 		Notes:      req.Notes,
+		Version:    1,
+	}
+	order.UpdatedAt = order.CreatedAt
+	if order.Currency != "" && !domain.IsValidCurrency(order.Currency) {
+		err := fmt.Errorf("%w: invalid currency %q", domain.ErrValidation, req.Currency)
+		recordError(span, err)
+		return nil, err
+	}
+	if err := s.markBoxes.ValidateForCustomer(ctx, order.MarkBox, order.CustomerID); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	var err error
+	if order.ShipDate, err = parseOptionalDate(req.ShipDate, "ship date"); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if order.DeliveryDate, err = parseOptionalDate(req.DeliveryDate, "delivery date"); err != nil {
+		recordError(span, err)
+		return nil, err
 	}
 
 	for _, itemReq := range req.Items {
@@ -45,20 +115,484 @@ func (s *OrderService) CreateOrder(ctx context.Context, req dto.CreateOrderReque
 			FarmName:   itemReq.FarmName,
 			TruckName:  itemReq.TruckName,
 			Comments:   itemReq.Comments,
-			Price:      itemReq.Price,
+			Category:   itemReq.Category,
+		}
+		if item.Category == "" {
+			item.Category = domain.TaxCategoryFlowers
 		}
 		order.Items = append(order.Items, item)
 	}
 
-	order.TotalAmount = order.CalculateTotal()
+	if err := s.farms.AssignFarms(ctx, order, order.CreatedAt); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if order.ShipDate != nil || order.DeliveryDate != nil {
+		windows, err := s.repo.ListCatalogWindows(ctx)
+		if err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("failed to get catalog windows: %w", err)
+		}
+		if err := order.CheckSchedule(windows); err != nil {
+			recordError(span, err)
+			return nil, err
+		}
+	}
+	// Цены действуют на дату отправки: заказы, созданные заранее (в том числе
+	// постоянные), получают прайс-лист, который будет действовать при отгрузке
+	priceDate := order.CreatedAt
+	if order.ShipDate != nil {
+		priceDate = *order.ShipDate
+	}
+	if err := s.prices.PriceItems(ctx, order.CustomerID, priceDate, order.Items); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	if order.Currency == "" {
+		currency, err := s.prices.BillingCurrency(ctx, order.CustomerID)
+		if err != nil {
+			recordError(span, err)
+			return nil, err
+		}
+		order.Currency = currency
+	}
+	if err := s.adjustments.ApplyRules(ctx, order); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if err := s.taxes.ApplyTaxes(ctx, order); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if err := s.rates.SnapshotRates(ctx, order); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if _, err := order.CalculateTotal(); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("order.id", order.ID),
+		attribute.String("order.currency", order.Currency),
+		attribute.String("order.customer_id", order.CustomerID),
+		attribute.Int("order.item_count", len(order.Items)),
+	)
+
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"order_id":    order.ID,
+		"customer_id": order.CustomerID,
+		"items":       len(order.Items),
+	})
 
 	if err := s.repo.Create(ctx, order); err != nil {
+		log.WithError(err).Error("Failed to create order")
+		recordError(span, err)
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
+	s.metrics.OrderCreated(order)
+	log.Info("Order created")
 	return order, nil
 }
 
+// DuplicateOrder создает новый заказ в статусе pending по позициям существующего.
+// Позиции заново оцениваются по текущему прайс-листу через CreateOrder; позиции,
+// которые CreateOrder отклонил бы (нет цены, ферма неактивна или прошло ее время
+// отсечки, позиция недоступна в каталоге на дату отправки), не переносятся
+// и возвращаются в UnavailableItems.
+func (s *OrderService) DuplicateOrder(
+	ctx context.Context,
+	id string,
+	req dto.DuplicateOrderRequest,
+) (*dto.DuplicateOrderResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "OrderService.DuplicateOrder", trace.WithAttributes(
+		attribute.String("order.source_id", id),
+	))
+	defer span.End()
+
+	source, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	sourceItems := make(map[string]bool, len(source.Items))
+	for _, item := range source.Items {
+		sourceItems[item.ID] = true
+	}
+	overrides := make(map[string]dto.DuplicateOrderItemRequest, len(req.Items))
+	for _, override := range req.Items {
+		if !sourceItems[override.ID] {
+			err := fmt.Errorf("%w: item %s is not in order %s", domain.ErrValidation, override.ID, source.ID)
+			recordError(span, err)
+			return nil, err
+		}
+		if _, ok := overrides[override.ID]; ok {
+			err := fmt.Errorf("%w: item %s is listed more than once", domain.ErrValidation, override.ID)
+			recordError(span, err)
+			return nil, err
+		}
+		overrides[override.ID] = override
+	}
+
+	createReq := dto.CreateOrderRequest{
+		MarkBox:      source.MarkBox,
+		CustomerID:   source.CustomerID,
+		Currency:     source.Currency,
+		ShipDate:     req.ShipDate,
+		DeliveryDate: req.DeliveryDate,
+		Notes:        source.Notes,
+	}
+	if req.MarkBox != nil {
+		createReq.MarkBox = *req.MarkBox
+	}
+	if req.Notes != nil {
+		createReq.Notes = *req.Notes
+	}
+	shipDate, err := parseOptionalDate(req.ShipDate, "ship date")
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	response := &dto.DuplicateOrderResponse{}
+	createReq.Items, response.UnavailableItems, err = s.duplicateItems(
+		ctx, source, overrides, createReq.CustomerID, shipDate, time.Now(),
+	)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if len(createReq.Items) == 0 {
+		err := fmt.Errorf("%w: no items of order %s are available to duplicate", domain.ErrValidation, source.ID)
+		recordError(span, err)
+		return nil, err
+	}
+
+	order, err := s.CreateOrder(ctx, createReq)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	response.Order = order
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"order_id":          order.ID,
+		"source_order_id":   source.ID,
+		"unavailable_items": len(response.UnavailableItems),
+	}).Info("Order duplicated")
+	return response, nil
+}
+
+// duplicateItems строит позиции нового заказа по позициям source с учетом
+// переопределений количества. Позиции проверяются по тем же правилам, что
+// и в CreateOrder (цена, ферма, окно каталога), иначе одна позиция отклонила бы
+// весь новый заказ; не прошедшие проверку возвращаются отдельно.
+func (s *OrderService) duplicateItems(
+	ctx context.Context,
+	source *domain.Order,
+	overrides map[string]dto.DuplicateOrderItemRequest,
+	customerID string,
+	shipDate *time.Time,
+	now time.Time,
+) ([]dto.CreateOrderItemRequest, []domain.Item, error) {
+	priceDate := now
+	var windows []domain.CatalogWindow
+	if shipDate != nil {
+		priceDate = *shipDate
+		var err error
+		if windows, err = s.repo.ListCatalogWindows(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to get catalog windows: %w", err)
+		}
+	}
+
+	var items []dto.CreateOrderItemRequest
+	unavailable := []domain.Item{}
+	for _, item := range source.Items {
+		boxCount, totalStems := item.BoxCount, item.TotalStems
+		if override, ok := overrides[item.ID]; ok {
+			boxCount = *override.BoxCount
+			if boxCount == 0 {
+				continue
+			}
+			if override.TotalStems != nil {
+				totalStems = *override.TotalStems
+			} else if item.BoxCount > 0 {
+				totalStems = max(1, int(math.Round(float64(item.TotalStems)*boxCount/item.BoxCount)))
+			}
+		}
+
+		problem, err := s.farms.OrderProblem(ctx, item.FarmName, shipDate, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		if problem == "" && shipDate != nil && !domain.IsAvailable(item, *shipDate, windows) {
+			problem = "not available in catalog for ship date " + shipDate.Format(dateLayout)
+		}
+		if problem == "" {
+			_, err := s.prices.ResolvePrice(ctx, customerID, item.Variety, item.Length, item.FarmName, priceDate)
+			if errors.Is(err, domain.ErrValidation) {
+				problem = err.Error()
+			} else if err != nil {
+				return nil, nil, err
+			}
+		}
+		if problem != "" {
+			logger.FromContext(ctx).WithFields(map[string]interface{}{
+				"item_id": item.ID,
+				"reason":  problem,
+			}).Debug("Item is unavailable for duplication")
+			unavailable = append(unavailable, item)
+			continue
+		}
+
+		items = append(items, dto.CreateOrderItemRequest{
+			Variety:    item.Variety,
+			Length:     item.Length,
+			BoxCount:   boxCount,
+			PackRate:   item.PackRate,
+			TotalStems: totalStems,
+			FarmName:   item.FarmName,
+			TruckName:  item.TruckName,
+			Comments:   item.Comments,
+			Category:   item.Category,
+		})
+	}
+	return items, unavailable, nil
+}
+
+// ListOrders возвращает заказы по статусу и периоду дат отправки
+func (s *OrderService) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "OrderService.ListOrders")
+	defer span.End()
+
+	orders, err := s.repo.List(ctx, filter)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("orders.count", len(orders)))
+	return orders, nil
+}
+
+func (s *OrderService) ListCatalogWindows(ctx context.Context) ([]domain.CatalogWindow, error) {
+	return s.repo.ListCatalogWindows(ctx)
+}
+
+// SetCatalogWindow задает период, в который позицию каталога можно заказать на отправку
+func (s *OrderService) SetCatalogWindow(ctx context.Context, req dto.CatalogWindowRequest) (*domain.CatalogWindow, error) {
+	window := domain.CatalogWindow{Variety: req.Variety, Length: req.Length, FarmName: req.FarmName}
+	var err error
+	if window.AvailableFrom, err = parseOptionalDate(req.AvailableFrom, "available_from"); err != nil {
+		return nil, err
+	}
+	if window.AvailableTo, err = parseOptionalDate(req.AvailableTo, "available_to"); err != nil {
+		return nil, err
+	}
+	if window.AvailableFrom != nil && window.AvailableTo != nil && window.AvailableTo.Before(*window.AvailableFrom) {
+		return nil, fmt.Errorf("%w: available_to is before available_from", domain.ErrValidation)
+	}
+	if err := s.repo.SetCatalogWindow(ctx, window); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"variety":   window.Variety,
+		"length":    window.Length,
+		"farm_name": window.FarmName,
+	}).Info("Catalog window updated")
+	return &window, nil
+}
+
 func (s *OrderService) GetOrderByID(ctx context.Context, id string) (*domain.Order, error) {
-	return s.repo.GetByID(ctx, id)
+	ctx, span := tracing.Tracer().Start(ctx, "OrderService.GetOrderByID", trace.WithAttributes(
+		attribute.String("order.id", id),
+	))
+	defer span.End()
+
+	order, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.FromContext(ctx).WithField("order_id", id).WithError(err).Warn("Failed to get order")
+		recordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("order.item_count", len(order.Items)))
+	return order, nil
+}
+
+// UpdateOrder применяет изменения к заказу, если его текущая версия
+// совпадает с expectedVersion. Иначе возвращается *domain.VersionConflictError.
+func (s *OrderService) UpdateOrder(
+	ctx context.Context, id string, expectedVersion int, req dto.UpdateOrderRequest,
+) (*domain.Order, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "OrderService.UpdateOrder", trace.WithAttributes(
+		attribute.String("order.id", id),
+		attribute.Int("order.expected_version", expectedVersion),
+	))
+	defer span.End()
+
+	log := logger.FromContext(ctx).WithField("order_id", id)
+
+	order, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	if order.Version != expectedVersion {
+		err := &domain.VersionConflictError{
+			Entity:          "order",
+			ID:              id,
+			ExpectedVersion: expectedVersion,
+			CurrentVersion:  order.Version,
+		}
+		log.WithError(err).Warn("Rejected stale order update")
+		recordError(span, err)
+		return nil, err
+	}
+
+	if len(req.Items) > 0 || req.Adjustments != nil {
+		if err := s.checkFinancialEdit(ctx, order); err != nil {
+			log.WithError(err).Warn("Rejected edit of invoiced order")
+			recordError(span, err)
+			return nil, err
+		}
+	}
+	if req.MarkBox != nil && *req.MarkBox != order.MarkBox {
+		if err := s.markBoxes.ValidateForCustomer(ctx, *req.MarkBox, order.CustomerID); err != nil {
+			recordError(span, err)
+			return nil, err
+		}
+	}
+	if err := applyOrderUpdate(order, req); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+	if req.Adjustments != nil {
+		// фиксированная корректировка может быть в валюте, курса которой в заказе нет
+		if err := s.rates.SnapshotMissingRates(ctx, order); err != nil {
+			recordError(span, err)
+			return nil, err
+		}
+		// новые ручные корректировки облагаются по текущим правилам страны клиента,
+		// ставки позиций и прочих корректировок остаются зафиксированными при создании
+		var added []string
+		for _, adj := range order.Adjustments {
+			if adj.Source == domain.AdjustmentSourceManual {
+				added = append(added, adj.ID)
+			}
+		}
+		if err := s.taxes.ApplyAdjustmentTaxes(ctx, order, added); err != nil {
+			recordError(span, err)
+			return nil, err
+		}
+	}
+	if _, err := order.CalculateTotal(); err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, order); err != nil {
+		log.WithError(err).Warn("Failed to update order")
+		recordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("order.version", order.Version))
+	log.WithField("version", order.Version).Info("Order updated")
+	return order, nil
+}
+
+// checkFinancialEdit запрещает менять позиции и корректировки выполненного заказа
+// и заказа с действующим счетом
+func (s *OrderService) checkFinancialEdit(ctx context.Context, order *domain.Order) error {
+	if err := order.CheckFinancialEdit(false); err != nil {
+		return err
+	}
+	invoices, err := s.invoices.ListOrderInvoices(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	_, err = currentInvoice(order.ID, invoices)
+	return order.CheckFinancialEdit(err == nil)
+}
+
+// applyOrderUpdate переносит изменения из запроса в заказ с проверкой статуса
+func applyOrderUpdate(order *domain.Order, req dto.UpdateOrderRequest) error {
+	if req.Status != nil {
+		status := domain.OrderStatus(*req.Status)
+		if !order.IsValidStatus(status) {
+			return fmt.Errorf("%w: unknown status %q", domain.ErrValidation, status)
+		}
+		if status != order.Status {
+			if !order.CanTransitionTo(status) {
+				return fmt.Errorf("%w: cannot change status from %s to %s", domain.ErrValidation, order.Status, status)
+			}
+			if status == domain.OrderStatusProcessing {
+				now := time.Now()
+				order.ProcessedAt = &now
+			}
+			order.Status = status
+		}
+	}
+
+	if req.MarkBox != nil {
+		order.MarkBox = *req.MarkBox
+	}
+	if req.Notes != nil {
+		order.Notes = *req.Notes
+	}
+
+	for _, itemReq := range req.Items {
+		item := findItem(order, itemReq.ID)
+		if item == nil {
+			return fmt.Errorf("%w: item %s does not belong to order", domain.ErrValidation, itemReq.ID)
+		}
+		if itemReq.Price != nil {
+			if itemReq.Price.IsNegative() {
+				return fmt.Errorf("%w: item %s price must not be negative", domain.ErrValidation, itemReq.ID)
+			}
+			item.Price = *itemReq.Price
+		}
+		if itemReq.Comments != nil {
+			item.Comments = *itemReq.Comments
+		}
+	}
+
+	if req.Adjustments != nil {
+		if err := replaceManualAdjustments(order, *req.Adjustments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findItem(order *domain.Order, itemID string) *domain.Item {
+	for i := range order.Items {
+		if order.Items[i].ID == itemID {
+			return &order.Items[i]
+		}
+	}
+	return nil
+}
+
+// recordError отмечает спан как завершившийся ошибкой
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// parseOptionalDate разбирает необязательную дату вида 2006-01-02
+func parseOptionalDate(value, field string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s %q", domain.ErrValidation, field, value)
+	}
+	return &date, nil
 }
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+)
+
+// Фейковые хранилища реализуют только методы, которые вызывает тест;
+// вызов остальных методов встроенного nil-интерфейса приводит к панике.
+
+type fakeOrderRepository struct {
+	domain.OrderRepository
+	windows []domain.CatalogWindow
+}
+
+func (r *fakeOrderRepository) ListCatalogWindows(context.Context) ([]domain.CatalogWindow, error) {
+	return r.windows, nil
+}
+
+type fakePriceRepository struct {
+	domain.PriceRepository
+	// prices цены по ключу "сорт/длина/ферма"
+	prices map[string]domain.Money
+}
+
+func (r *fakePriceRepository) FindPrice(_ context.Context, query domain.PriceQuery) (*domain.PriceEntry, error) {
+	price, ok := r.prices[fmt.Sprintf("%s/%d/%s", query.Variety, query.Length, query.FarmName)]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.PriceEntry{ID: "price", Price: price, Currency: domain.DefaultCurrency}, nil
+}
+
+func (r *fakePriceRepository) FindCatalogPrice(context.Context, string, int, string) (*domain.PriceEntry, error) {
+	return nil, domain.ErrNotFound
+}
+
+type fakeCustomerRepository struct {
+	domain.CustomerRepository
+}
+
+func (r *fakeCustomerRepository) GetCustomer(context.Context, string) (*domain.Customer, error) {
+	return nil, domain.ErrNotFound
+}
+
+type fakeFarmRepository struct {
+	domain.FarmRepository
+	farms map[string]*domain.Farm
+}
+
+func (r *fakeFarmRepository) GetFarmByName(_ context.Context, name string) (*domain.Farm, error) {
+	farm, ok := r.farms[name]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return farm, nil
+}
+
+func TestDuplicateItems(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	shipDate := time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	price, _ := domain.ParseMoney("0.50")
+
+	service := &OrderService{
+		repo: &fakeOrderRepository{windows: []domain.CatalogWindow{
+			{Variety: "Tulip", Length: 40, FarmName: "Open", AvailableTo: &windowEnd},
+		}},
+		prices: NewPriceService(&fakePriceRepository{prices: map[string]domain.Money{
+			"Rose/50/Open":     price,
+			"Rose/60/Late":     price,
+			"Tulip/40/Open":    price,
+			"Lily/70/Inactive": price,
+		}}, &fakeCustomerRepository{}),
+		farms: NewFarmService(&fakeFarmRepository{farms: map[string]*domain.Farm{
+			"Open": {Name: "Open", TimeZone: "UTC", CutoffTime: "00:00", Active: true},
+			// отсечка за 3 дня до отправки: 3 марта 10:00, раньше now
+			"Late":     {Name: "Late", TimeZone: "UTC", CutoffTime: "10:00", LeadTimeDays: 3, Active: true},
+			"Inactive": {Name: "Inactive", TimeZone: "UTC", CutoffTime: "00:00", Active: false},
+		}}, nil),
+	}
+
+	source := &domain.Order{ID: "source", Items: []domain.Item{
+		{ID: "available", Variety: "Rose", Length: 50, FarmName: "Open", BoxCount: 2, TotalStems: 200},
+		{ID: "past-cutoff", Variety: "Rose", Length: 60, FarmName: "Late", BoxCount: 1, TotalStems: 100},
+		{ID: "no-price", Variety: "Rose", Length: 70, FarmName: "Open", BoxCount: 1, TotalStems: 100},
+		{ID: "out-of-window", Variety: "Tulip", Length: 40, FarmName: "Open", BoxCount: 1, TotalStems: 100},
+		{ID: "inactive-farm", Variety: "Lily", Length: 70, FarmName: "Inactive", BoxCount: 1, TotalStems: 100},
+		{ID: "unknown-farm", Variety: "Rose", Length: 50, FarmName: "Nowhere", BoxCount: 1, TotalStems: 100},
+	}}
+
+	oneBox := 1.0
+	overrides := map[string]dto.DuplicateOrderItemRequest{"available": {ID: "available", BoxCount: &oneBox}}
+
+	items, unavailable, err := service.duplicateItems(context.Background(), source, overrides, "", &shipDate, now)
+	if err != nil {
+		t.Fatalf("duplicateItems: %v", err)
+	}
+
+	if len(items) != 1 || items[0].Variety != "Rose" || items[0].Length != 50 {
+		t.Fatalf("items = %+v, want only Rose 50cm", items)
+	}
+	if items[0].BoxCount != 1 || items[0].TotalStems != 100 {
+		t.Errorf("override gives %v boxes and %d stems, want 1 and 100", items[0].BoxCount, items[0].TotalStems)
+	}
+
+	want := []string{"past-cutoff", "no-price", "out-of-window", "inactive-farm", "unknown-farm"}
+	if len(unavailable) != len(want) {
+		t.Fatalf("unavailable = %d items, want %d", len(unavailable), len(want))
+	}
+	for i, item := range unavailable {
+		if item.ID != want[i] {
+			t.Errorf("unavailable[%d] = %s, want %s", i, item.ID, want[i])
+		}
+	}
+}
+
+func TestDuplicateItemsWithoutShipDate(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	price, _ := domain.ParseMoney("0.50")
+
+	service := &OrderService{
+		repo: &fakeOrderRepository{},
+		prices: NewPriceService(&fakePriceRepository{prices: map[string]domain.Money{
+			"Rose/60/Late": price,
+		}}, &fakeCustomerRepository{}),
+		farms: NewFarmService(&fakeFarmRepository{farms: map[string]*domain.Farm{
+			"Late": {Name: "Late", TimeZone: "UTC", CutoffTime: "10:00", LeadTimeDays: 3, Active: true},
+		}}, nil),
+	}
+	source := &domain.Order{ID: "source", Items: []domain.Item{
+		{ID: "1", Variety: "Rose", Length: 60, FarmName: "Late", BoxCount: 1, TotalStems: 100},
+	}}
+
+	// без даты отправки время отсечки не проверяется, как и в CreateOrder
+	items, unavailable, err := service.duplicateItems(context.Background(), source, nil, "", nil, now)
+	if err != nil {
+		t.Fatalf("duplicateItems: %v", err)
+	}
+	if len(items) != 1 || len(unavailable) != 0 {
+		t.Errorf("items = %d, unavailable = %d, want 1 and 0", len(items), len(unavailable))
+	}
+}
+
+type fakeInvoiceRepository struct {
+	domain.InvoiceRepository
+	invoices []domain.Invoice
+}
+
+func (r *fakeInvoiceRepository) ListOrderInvoices(context.Context, string) ([]domain.Invoice, error) {
+	return r.invoices, nil
+}
+
+func TestCheckFinancialEdit(t *testing.T) {
+	invoice := domain.Invoice{ID: "inv-1", Type: domain.InvoiceTypeInvoice}
+	creditNote := domain.Invoice{ID: "cn-1", Type: domain.InvoiceTypeCreditNote, CreditedInvoiceID: "inv-1"}
+
+	tests := []struct {
+		name         string
+		status       domain.OrderStatus
+		invoices     []domain.Invoice
+		wantConflict bool
+	}{
+		{name: "open order", status: domain.OrderStatusFarmOrder},
+		{name: "completed order", status: domain.OrderStatusCompleted, wantConflict: true},
+		{name: "active invoice", status: domain.OrderStatusFarmOrder, invoices: []domain.Invoice{invoice}, wantConflict: true},
+		{
+			name:     "credited invoice",
+			status:   domain.OrderStatusFarmOrder,
+			invoices: []domain.Invoice{creditNote, invoice},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &OrderService{invoices: &fakeInvoiceRepository{invoices: tt.invoices}}
+			order := &domain.Order{ID: "order", Status: tt.status}
+
+			err := service.checkFinancialEdit(context.Background(), order)
+			if tt.wantConflict {
+				if !errors.Is(err, domain.ErrConflict) {
+					t.Fatalf("checkFinancialEdit error = %v, want ErrConflict", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkFinancialEdit: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/packing"
+)
+
+type PackingService struct {
+	boxTypes domain.BoxTypeRepository
+	orders   domain.OrderRepository
+}
+
+func NewPackingService(boxTypes domain.BoxTypeRepository, orders domain.OrderRepository) *PackingService {
+	return &PackingService{boxTypes: boxTypes, orders: orders}
+}
+
+func (s *PackingService) CreateBoxType(ctx context.Context, req dto.CreateBoxTypeRequest) (*domain.BoxType, error) {
+	boxType := &domain.BoxType{
+		ID:            uuid.New().String(),
+		FarmName:      req.FarmName,
+		Code:          strings.ToUpper(req.Code),
+		Name:          req.Name,
+		Size:          req.Size,
+		LengthCm:      req.LengthCm,
+		WidthCm:       req.WidthCm,
+		HeightCm:      req.HeightCm,
+		TareWeightKg:  req.TareWeightKg,
+		GrossWeightKg: req.GrossWeightKg,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.boxTypes.CreateBoxType(ctx, boxType); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"box_type_id": boxType.ID,
+		"farm_name":   boxType.FarmName,
+		"code":        boxType.Code,
+	}).Info("Box type created")
+	return boxType, nil
+}
+
+func (s *PackingService) ListBoxTypes(ctx context.Context, farmName string) ([]domain.BoxType, error) {
+	return s.boxTypes.ListBoxTypes(ctx, farmName)
+}
+
+func (s *PackingService) DeleteBoxType(ctx context.Context, id string) error {
+	return s.boxTypes.DeleteBoxType(ctx, id)
+}
+
+// PackOrder раскладывает позиции заказа по коробкам ферм
+func (s *PackingService) PackOrder(ctx context.Context, orderID string) (*packing.Plan, error) {
+	order, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	boxTypes, err := s.boxTypes.ListBoxTypes(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return packing.Pack(order, boxTypes), nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+const dateLayout = "2006-01-02"
+
+type PriceService struct {
+	prices    domain.PriceRepository
+	customers domain.CustomerRepository
+}
+
+func NewPriceService(prices domain.PriceRepository, customers domain.CustomerRepository) *PriceService {
+	return &PriceService{prices: prices, customers: customers}
+}
+
+func (s *PriceService) CreatePrice(ctx context.Context, req dto.CreatePriceRequest) (*domain.PriceEntry, error) {
+	validFrom, err := time.Parse(dateLayout, req.ValidFrom)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid valid_from: %v", domain.ErrValidation, err)
+	}
+
+	if req.Price == nil || req.Price.IsZero() || req.Price.IsNegative() {
+		return nil, fmt.Errorf("%w: price must be greater than zero", domain.ErrValidation)
+	}
+
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &domain.PriceEntry{
+		ID:           uuid.New().String(),
+		Variety:      req.Variety,
+		Length:       req.Length,
+		FarmName:     req.FarmName,
+		Price:        *req.Price,
+		Currency:     currency,
+		CustomerTier: req.CustomerTier,
+		CustomerID:   req.CustomerID,
+		ValidFrom:    validFrom,
+		CreatedAt:    time.Now(),
+	}
+
+	if req.ValidTo != "" {
+		validTo, err := time.Parse(dateLayout, req.ValidTo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid valid_to: %v", domain.ErrValidation, err)
+		}
+		if validTo.Before(validFrom) {
+			return nil, fmt.Errorf("%w: valid_to is before valid_from", domain.ErrValidation)
+		}
+		entry.ValidTo = &validTo
+	}
+
+	if err := s.prices.CreatePrice(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create price: %w", err)
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"price_id": entry.ID,
+		"source":   entry.Source(),
+	}).Info("Price created")
+	return entry, nil
+}
+
+func (s *PriceService) ListPrices(ctx context.Context, filter domain.PriceFilter) ([]domain.PriceEntry, error) {
+	return s.prices.ListPrices(ctx, filter)
+}
+
+func (s *PriceService) DeletePrice(ctx context.Context, id string) error {
+	return s.prices.DeletePrice(ctx, id)
+}
+
+// ResolvePrice находит цену для позиции заказа с учетом уровня клиента
+// и персональных цен. Если в прайс-листе цены нет, используется цена
+// из каталога цветов; если нет и ее, возвращается ошибка валидации.
+func (s *PriceService) ResolvePrice(
+	ctx context.Context, customerID, variety string, length int, farmName string, date time.Time,
+) (*domain.PriceEntry, error) {
+	tier, err := s.customerTier(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.findPrice(ctx, customerID, tier, variety, length, farmName, date)
+}
+
+// PriceItems заполняет цены позиций из прайс-листа, игнорируя цены,
+// переданные клиентом
+func (s *PriceService) PriceItems(ctx context.Context, customerID string, date time.Time, items []domain.Item) error {
+	tier, err := s.customerTier(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		entry, err := s.findPrice(ctx, customerID, tier, item.Variety, item.Length, item.FarmName, date)
+		if err != nil {
+			return err
+		}
+		item.Price = entry.Price
+		item.Currency = entry.Currency
+	}
+	return nil
+}
+
+func (s *PriceService) findPrice(
+	ctx context.Context, customerID, tier, variety string, length int, farmName string, date time.Time,
+) (*domain.PriceEntry, error) {
+	entry, err := s.prices.FindPrice(ctx, domain.PriceQuery{
+		Variety:      variety,
+		Length:       length,
+		FarmName:     farmName,
+		CustomerID:   customerID,
+		CustomerTier: tier,
+		Date:         date,
+	})
+	if errors.Is(err, domain.ErrNotFound) {
+		entry, err = s.prices.FindCatalogPrice(ctx, variety, length, farmName)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: no price for %s %dcm from %s on %s",
+			domain.ErrValidation, variety, length, farmName, date.Format(dateLayout))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find price: %w", err)
+	}
+	return entry, nil
+}
+
+// PreviewPrice показывает цену, которую получит клиент на указанную дату
+func (s *PriceService) PreviewPrice(
+	ctx context.Context, customerID, variety string, length int, farmName string, date time.Time,
+) (*dto.PricePreviewResponse, error) {
+	entry, err := s.ResolvePrice(ctx, customerID, variety, length, farmName, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.PricePreviewResponse{
+		CustomerID: customerID,
+		Variety:    variety,
+		Length:     length,
+		FarmName:   farmName,
+		Date:       date.Format(dateLayout),
+		Price:      entry.Price,
+		Currency:   entry.Currency,
+		Source:     string(entry.Source()),
+		PriceID:    entry.ID,
+	}, nil
+}
+
+// customerTier возвращает уровень клиента. Незарегистрированный клиент
+// получает базовые и персональные цены без уровня.
+func (s *PriceService) customerTier(ctx context.Context, customerID string) (string, error) {
+	customer, err := s.findCustomer(ctx, customerID)
+	if err != nil || customer == nil {
+		return "", err
+	}
+	return customer.Tier, nil
+}
+
+// BillingCurrency возвращает валюту счетов клиента.
+// Для незарегистрированного клиента используется валюта по умолчанию.
+func (s *PriceService) BillingCurrency(ctx context.Context, customerID string) (string, error) {
+	customer, err := s.findCustomer(ctx, customerID)
+	if err != nil {
+		return "", err
+	}
+	if customer == nil || customer.Currency == "" {
+		return domain.DefaultCurrency, nil
+	}
+	return customer.Currency, nil
+}
+
+// findCustomer возвращает клиента или nil, если он не зарегистрирован
+func (s *PriceService) findCustomer(ctx context.Context, customerID string) (*domain.Customer, error) {
+	if customerID == "" {
+		return nil, nil
+	}
+	customer, err := s.customers.GetCustomer(ctx, customerID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	return customer, nil
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+// Ограничения предпросмотра предстоящих дат постоянного заказа
+const (
+	defaultPreviewCount = 10
+	maxPreviewCount     = 52
+	// previewHorizonDays дальше этого срока даты не ищутся
+	previewHorizonDays = 2 * 366
+	// recurringRunLease через этот срок незавершенная попытка считается брошенной
+	// (процесс упал во время создания заказа), и дата обрабатывается заново
+	recurringRunLease = 10 * time.Minute
+)
+
+type RecurringOrderService struct {
+	recurring domain.RecurringOrderRepository
+	orders    *OrderService
+	markBoxes *MarkBoxService
+	// leadTime за сколько до даты отправки создается заказ
+	leadTime time.Duration
+}
+
+func NewRecurringOrderService(
+	recurring domain.RecurringOrderRepository,
+	orders *OrderService,
+	markBoxes *MarkBoxService,
+	leadTime time.Duration,
+) *RecurringOrderService {
+	return &RecurringOrderService{recurring: recurring, orders: orders, markBoxes: markBoxes, leadTime: leadTime}
+}
+
+func (s *RecurringOrderService) Create(
+	ctx context.Context,
+	req dto.SaveRecurringOrderRequest,
+) (*domain.RecurringOrder, error) {
+	order, err := s.toDomain(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	order.ID = uuid.New().String()
+	order.Status = domain.RecurringOrderStatusActive
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	if err := s.recurring.CreateRecurringOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to create recurring order: %w", err)
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"recurring_order_id": order.ID,
+		"customer_id":        order.CustomerID,
+		"frequency":          order.Frequency,
+	}).Info("Recurring order created")
+	return order, nil
+}
+
+// Update заменяет правило, даты и позиции шаблона. Уже созданные заказы не меняются.
+func (s *RecurringOrderService) Update(
+	ctx context.Context,
+	id string,
+	req dto.SaveRecurringOrderRequest,
+) (*domain.RecurringOrder, error) {
+	order, err := s.toDomain(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	order.ID = id
+	if err := s.recurring.UpdateRecurringOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to update recurring order: %w", err)
+	}
+
+	logger.FromContext(ctx).WithField("recurring_order_id", order.ID).Info("Recurring order updated")
+	return order, nil
+}
+
+func (s *RecurringOrderService) Get(ctx context.Context, id string) (*domain.RecurringOrder, error) {
+	return s.recurring.GetRecurringOrder(ctx, id)
+}
+
+func (s *RecurringOrderService) List(
+	ctx context.Context,
+	filter domain.RecurringOrderFilter,
+) ([]domain.RecurringOrder, error) {
+	return s.recurring.ListRecurringOrders(ctx, filter)
+}
+
+// Pause приостанавливает создание заказов по шаблону
+func (s *RecurringOrderService) Pause(ctx context.Context, id string) (*domain.RecurringOrder, error) {
+	return s.setStatus(ctx, id, domain.RecurringOrderStatusPaused)
+}
+
+// Resume возобновляет создание заказов. Даты, пропущенные за время паузы,
+// не восполняются: создаются заказы на даты не раньше сегодняшней.
+func (s *RecurringOrderService) Resume(ctx context.Context, id string) (*domain.RecurringOrder, error) {
+	return s.setStatus(ctx, id, domain.RecurringOrderStatusActive)
+}
+
+func (s *RecurringOrderService) setStatus(
+	ctx context.Context,
+	id string,
+	status domain.RecurringOrderStatus,
+) (*domain.RecurringOrder, error) {
+	if err := s.recurring.SetRecurringOrderStatus(ctx, id, status); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"recurring_order_id": id,
+		"status":             status,
+	}).Info("Recurring order status changed")
+	return s.recurring.GetRecurringOrder(ctx, id)
+}
+
+// Preview возвращает ближайшие count дат отправки начиная с сегодняшней,
+// с отметками о пропусках и уже созданных заказах
+func (s *RecurringOrderService) Preview(
+	ctx context.Context,
+	id string,
+	count int,
+) ([]domain.RecurringOccurrence, error) {
+	if count <= 0 {
+		count = defaultPreviewCount
+	}
+	if count > maxPreviewCount {
+		count = maxPreviewCount
+	}
+
+	order, err := s.recurring.GetRecurringOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().UTC()
+	runs, err := s.recurring.ListRecurringRuns(ctx, id, today)
+	if err != nil {
+		return nil, err
+	}
+	runsByDate := make(map[string]domain.RecurringOrderRun, len(runs))
+	for _, run := range runs {
+		runsByDate[run.ShipDate.Format(dateLayout)] = run
+	}
+
+	occurrences := []domain.RecurringOccurrence{}
+	for _, date := range order.Occurrences(today, today.AddDate(0, 0, previewHorizonDays)) {
+		occurrence := domain.RecurringOccurrence{ShipDate: date, Skipped: order.IsSkipped(date)}
+		if run, ok := runsByDate[date.Format(dateLayout)]; ok {
+			occurrence.OrderID = run.OrderID
+			occurrence.Error = run.Error
+			occurrence.Failed = run.Failed
+		}
+		occurrences = append(occurrences, occurrence)
+		if len(occurrences) == count {
+			break
+		}
+	}
+	return occurrences, nil
+}
+
+// MaterializeDue создает заказы по активным шаблонам на даты отправки
+// в пределах leadTime от now и возвращает число созданных заказов.
+// Отказ валидации сохраняется и больше не повторяется; прочие ошибки
+// повторяются при следующих запусках, пока дата остается в окне.
+// Сбой хранилища по одному шаблону не останавливает обработку остальных.
+func (s *RecurringOrderService) MaterializeDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecurringOrderService.MaterializeDue")
+	defer span.End()
+
+	templates, err := s.recurring.ListRecurringOrders(
+		ctx, domain.RecurringOrderFilter{Status: domain.RecurringOrderStatusActive},
+	)
+	if err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("failed to list recurring orders: %w", err)
+	}
+
+	created := 0
+	for i := range templates {
+		template := &templates[i]
+		for _, shipDate := range template.DueOccurrences(now, s.leadTime) {
+			ok, err := s.materialize(ctx, template, shipDate, now)
+			if err != nil {
+				recordError(span, err)
+				logger.FromContext(ctx).WithError(err).WithField("recurring_order_id", template.ID).
+					Error("Failed to materialize recurring order")
+				break
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	span.SetAttributes(attribute.Int("recurring.created_orders", created))
+	return created, nil
+}
+
+// materialize создает заказ по шаблону на дату отправки. Возвращает false,
+// если дата уже обработана или заказ не создан; ошибка возвращается только
+// при сбое хранилища попыток.
+func (s *RecurringOrderService) materialize(
+	ctx context.Context,
+	template *domain.RecurringOrder,
+	shipDate time.Time,
+	now time.Time,
+) (bool, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecurringOrderService.materialize", trace.WithAttributes(
+		attribute.String("recurring_order.id", template.ID),
+		attribute.String("recurring_order.ship_date", shipDate.Format(dateLayout)),
+	))
+	defer span.End()
+
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"recurring_order_id": template.ID,
+		"ship_date":          shipDate.Format(dateLayout),
+	})
+
+	run := &domain.RecurringOrderRun{RecurringOrderID: template.ID, ShipDate: shipDate, CreatedAt: now}
+	started, err := s.recurring.StartRecurringRun(ctx, run, now.Add(-recurringRunLease))
+	if err != nil {
+		recordError(span, err)
+		return false, fmt.Errorf("failed to start recurring order run: %w", err)
+	}
+	if !started {
+		return false, nil
+	}
+
+	order, err := s.orders.CreateOrder(ctx, orderRequestFromTemplate(template, shipDate))
+	if err != nil {
+		recordError(span, err)
+		run.Error = err.Error()
+		run.Failed = errors.Is(err, domain.ErrValidation)
+		log.WithError(err).WithField("retry", !run.Failed).Warn("Failed to create order from recurring order")
+	} else {
+		run.OrderID = order.ID
+		log.WithField("order_id", order.ID).Info("Order created from recurring order")
+	}
+	if err := s.recurring.FinishRecurringRun(ctx, run); err != nil {
+		recordError(span, err)
+		return false, fmt.Errorf("failed to finish recurring order run: %w", err)
+	}
+	return run.OrderID != "", nil
+}
+
+func orderRequestFromTemplate(template *domain.RecurringOrder, shipDate time.Time) dto.CreateOrderRequest {
+	req := dto.CreateOrderRequest{
+		MarkBox:    template.MarkBox,
+		CustomerID: template.CustomerID,
+		Currency:   template.Currency,
+		ShipDate:   shipDate.Format(dateLayout),
+		Notes:      template.Notes,
+		Items:      make([]dto.CreateOrderItemRequest, len(template.Items)),
+	}
+	for i, item := range template.Items {
+		req.Items[i] = dto.CreateOrderItemRequest{
+			Variety:    item.Variety,
+			Length:     item.Length,
+			BoxCount:   item.BoxCount,
+			PackRate:   item.PackRate,
+			TotalStems: item.TotalStems,
+			FarmName:   item.FarmName,
+			TruckName:  item.TruckName,
+			Comments:   item.Comments,
+			Category:   item.Category,
+		}
+	}
+	return req
+}
+
+func (s *RecurringOrderService) toDomain(
+	ctx context.Context,
+	req dto.SaveRecurringOrderRequest,
+) (*domain.RecurringOrder, error) {
+	order := &domain.RecurringOrder{
+		CustomerID:   req.CustomerID,
+		MarkBox:      req.MarkBox,
+		Currency:     strings.ToUpper(req.Currency),
+		Notes:        req.Notes,
+		Frequency:    domain.RecurrenceFrequency(req.Frequency),
+		Weekdays:     req.Weekdays,
+		IntervalDays: req.IntervalDays,
+		SkipDates:    make([]time.Time, 0, len(req.SkipDates)),
+		Items:        make([]domain.RecurringOrderItem, len(req.Items)),
+	}
+	if order.Weekdays == nil {
+		order.Weekdays = []string{}
+	}
+	if order.Currency != "" && !domain.IsValidCurrency(order.Currency) {
+		return nil, fmt.Errorf("%w: invalid currency %q", domain.ErrValidation, req.Currency)
+	}
+
+	startDate, err := parseOptionalDate(req.StartDate, "start date")
+	if err != nil {
+		return nil, err
+	}
+	if startDate == nil {
+		return nil, fmt.Errorf("%w: start date is required", domain.ErrValidation)
+	}
+	order.StartDate = *startDate
+	if order.EndDate, err = parseOptionalDate(req.EndDate, "end date"); err != nil {
+		return nil, err
+	}
+	for _, value := range req.SkipDates {
+		date, err := parseOptionalDate(value, "skip date")
+		if err != nil {
+			return nil, err
+		}
+		if date != nil {
+			order.SkipDates = append(order.SkipDates, *date)
+		}
+	}
+
+	for i, itemReq := range req.Items {
+		order.Items[i] = domain.RecurringOrderItem{
+			ID:         uuid.New().String(),
+			Variety:    itemReq.Variety,
+			Length:     itemReq.Length,
+			BoxCount:   itemReq.BoxCount,
+			PackRate:   itemReq.PackRate,
+			TotalStems: itemReq.TotalStems,
+			FarmName:   itemReq.FarmName,
+			TruckName:  itemReq.TruckName,
+			Comments:   itemReq.Comments,
+			Category:   itemReq.Category,
+		}
+	}
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.markBoxes.ValidateForCustomer(ctx, order.MarkBox, order.CustomerID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
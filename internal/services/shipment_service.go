@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/export"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/metrics"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/tracing"
+)
+
+type ShipmentService struct {
+	shipments domain.ShipmentRepository
+	markBoxes domain.MarkBoxRepository
+	metrics   *metrics.Metrics
+}
+
+func NewShipmentService(
+	shipments domain.ShipmentRepository,
+	markBoxes domain.MarkBoxRepository,
+	metrics *metrics.Metrics,
+) *ShipmentService {
+	return &ShipmentService{shipments: shipments, markBoxes: markBoxes, metrics: metrics}
+}
+
+func (s *ShipmentService) CreateShipment(ctx context.Context, req dto.CreateShipmentRequest) (*domain.Shipment, error) {
+	departure, err := time.Parse(dateLayout, req.DepartureDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid departure_date: %v", domain.ErrValidation, err)
+	}
+
+	shipment := &domain.Shipment{
+		ID:            uuid.New().String(),
+		TruckName:     req.TruckName,
+		DepartureDate: departure,
+		CapacityBoxes: req.CapacityBoxes,
+		Notes:         req.Notes,
+		Items:         []domain.ShipmentItem{},
+		CreatedAt:     time.Now(),
+	}
+	if err := s.shipments.CreateShipment(ctx, shipment); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"shipment_id": shipment.ID,
+		"truck_name":  shipment.TruckName,
+		"departure":   req.DepartureDate,
+	}).Info("Shipment created")
+	return shipment, nil
+}
+
+func (s *ShipmentService) GetShipment(ctx context.Context, id string) (*domain.Shipment, error) {
+	return s.shipments.GetShipment(ctx, id)
+}
+
+func (s *ShipmentService) ListShipments(ctx context.Context, filter domain.ShipmentFilter) ([]domain.Shipment, error) {
+	return s.shipments.ListShipments(ctx, filter)
+}
+
+// AssignItems загружает позиции заказов в рейс. Позиции должны быть назначены
+// на грузовик рейса и помещаться в его вместимость.
+func (s *ShipmentService) AssignItems(ctx context.Context, shipmentID string, itemIDs []string) (*domain.Shipment, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ShipmentService.AssignItems", trace.WithAttributes(
+		attribute.String("shipment.id", shipmentID),
+		attribute.Int("shipment.items", len(itemIDs)),
+	))
+	defer span.End()
+
+	shipmentID, unique, err := normalizeShipmentIDs(shipmentID, itemIDs)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	shipment, err := s.shipments.AssignShipmentItems(ctx, shipmentID, unique)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"shipment_id":  shipmentID,
+		"items":        len(unique),
+		"loaded_boxes": shipment.LoadedBoxes(),
+	}).Info("Shipment items assigned")
+	return shipment, nil
+}
+
+func (s *ShipmentService) RemoveItem(ctx context.Context, shipmentID, itemID string) error {
+	shipmentID, ids, err := normalizeShipmentIDs(shipmentID, []string{itemID})
+	if err != nil {
+		return err
+	}
+	return s.shipments.RemoveShipmentItem(ctx, shipmentID, ids[0])
+}
+
+// normalizeShipmentIDs проверяет идентификаторы рейса и позиций, приводит их
+// к каноническому виду UUID и убирает повторы позиций. Некорректный идентификатор
+// отклоняется до запроса к базе, которая ответила бы внутренней ошибкой.
+func normalizeShipmentIDs(shipmentID string, itemIDs []string) (string, []string, error) {
+	parsed, err := uuid.Parse(shipmentID)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: invalid shipment id %q", domain.ErrValidation, shipmentID)
+	}
+
+	seen := make(map[string]bool, len(itemIDs))
+	unique := make([]string, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		item, err := uuid.Parse(id)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: invalid item id %q", domain.ErrValidation, id)
+		}
+		if key := item.String(); !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return parsed.String(), unique, nil
+}
+
+// Manifest возвращает загрузочную ведомость рейса с получателями по кодам маркировки
+func (s *ShipmentService) Manifest(ctx context.Context, shipmentID string) (*domain.ShipmentManifest, error) {
+	shipment, err := s.shipments.GetShipment(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	manifest := shipment.Manifest()
+	for i := range manifest.MarkBoxes {
+		markBox := &manifest.MarkBoxes[i]
+		if markBox.Consignee, err = findConsignee(ctx, s.markBoxes, markBox.MarkBox); err != nil {
+			return nil, err
+		}
+	}
+	return &manifest, nil
+}
+
+// RenderManifest формирует загрузочную ведомость в формате Excel
+func (s *ShipmentService) RenderManifest(manifest *domain.ShipmentManifest) ([]byte, error) {
+	data, err := export.ManifestXLSX(manifest)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.ExcelExportGenerated("manifest")
+	return data, nil
+}
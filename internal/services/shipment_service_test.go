@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+)
+
+func TestNormalizeShipmentIDs(t *testing.T) {
+	const (
+		shipment = "6f1c2a3b-4d5e-4f60-8a7b-9c0d1e2f3a4b"
+		item     = "0a1b2c3d-4e5f-4a6b-8c7d-8e9f0a1b2c3d"
+	)
+
+	tests := []struct {
+		name         string
+		shipmentID   string
+		itemIDs      []string
+		wantItems    string
+		wantValidErr bool
+	}{
+		{name: "valid ids", shipmentID: shipment, itemIDs: []string{item}, wantItems: item},
+		{
+			name:       "duplicates in different case are merged",
+			shipmentID: shipment,
+			itemIDs:    []string{item, strings.ToUpper(item)},
+			wantItems:  item,
+		},
+		{name: "malformed shipment id", shipmentID: "42", itemIDs: []string{item}, wantValidErr: true},
+		{name: "malformed item id", shipmentID: shipment, itemIDs: []string{item, "not-a-uuid"}, wantValidErr: true},
+		{name: "empty item id", shipmentID: shipment, itemIDs: []string{""}, wantValidErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shipmentID, items, err := normalizeShipmentIDs(tt.shipmentID, tt.itemIDs)
+			if tt.wantValidErr {
+				if !errors.Is(err, domain.ErrValidation) {
+					t.Fatalf("normalizeShipmentIDs error = %v, want ErrValidation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeShipmentIDs: %v", err)
+			}
+			if shipmentID != shipment {
+				t.Errorf("shipment id = %s, want %s", shipmentID, shipment)
+			}
+			if got := strings.Join(items, ","); got != tt.wantItems {
+				t.Errorf("items = %s, want %s", got, tt.wantItems)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/maxviazov/dolina-flower-order-backend/internal/domain"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/dto"
+	"github.com/maxviazov/dolina-flower-order-backend/internal/logger"
+)
+
+type TaxService struct {
+	rules     domain.TaxRuleRepository
+	customers domain.CustomerRepository
+}
+
+func NewTaxService(rules domain.TaxRuleRepository, customers domain.CustomerRepository) *TaxService {
+	return &TaxService{rules: rules, customers: customers}
+}
+
+func (s *TaxService) CreateRule(ctx context.Context, req dto.CreateTaxRuleRequest) (*domain.TaxRule, error) {
+	if req.Rate.IsNegative() {
+		return nil, fmt.Errorf("%w: tax rate must not be negative", domain.ErrValidation)
+	}
+
+	rule := &domain.TaxRule{
+		ID:            uuid.New().String(),
+		Country:       strings.ToUpper(req.Country),
+		Category:      req.Category,
+		Rate:          req.Rate,
+		ReverseCharge: req.ReverseCharge,
+		Description:   req.Description,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.rules.CreateTaxRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"rule_id": rule.ID,
+		"country": rule.Country,
+	}).Info("Tax rule created")
+	return rule, nil
+}
+
+func (s *TaxService) ListRules(ctx context.Context, country string) ([]domain.TaxRule, error) {
+	return s.rules.ListTaxRules(ctx, strings.ToUpper(country))
+}
+
+func (s *TaxService) DeleteRule(ctx context.Context, id string) error {
+	return s.rules.DeleteTaxRule(ctx, id)
+}
+
+// ApplyTaxes фиксирует в заказе ставки налога по стране клиента.
+// Для незарегистрированного клиента или клиента без страны налог не начисляется.
+func (s *TaxService) ApplyTaxes(ctx context.Context, order *domain.Order) error {
+	rules, vatID, err := s.customerRules(ctx, order.CustomerID)
+	if err != nil {
+		return err
+	}
+	order.ApplyTaxRules(rules, vatID)
+	return nil
+}
+
+// ApplyAdjustmentTaxes фиксирует ставки налога по текущим правилам только для
+// корректировок adjustmentIDs; ставки, зафиксированные при создании заказа, сохраняются
+func (s *TaxService) ApplyAdjustmentTaxes(ctx context.Context, order *domain.Order, adjustmentIDs []string) error {
+	if len(adjustmentIDs) == 0 {
+		return nil
+	}
+	rules, vatID, err := s.customerRules(ctx, order.CustomerID)
+	if err != nil {
+		return err
+	}
+	order.ApplyAdjustmentTaxRules(rules, vatID, adjustmentIDs)
+	return nil
+}
+
+// customerRules возвращает налоговые правила страны клиента и его VAT ID
+func (s *TaxService) customerRules(ctx context.Context, customerID string) ([]domain.TaxRule, string, error) {
+	var country, vatID string
+	if customerID != "" {
+		customer, err := s.customers.GetCustomer(ctx, customerID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, "", fmt.Errorf("failed to get customer: %w", err)
+		}
+		if customer != nil {
+			country, vatID = customer.Address.Country, customer.VATID
+		}
+	}
+
+	if country == "" {
+		return nil, vatID, nil
+	}
+	rules, err := s.rules.ListTaxRules(ctx, country)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list tax rules: %w", err)
+	}
+	return rules, vatID, nil
+}